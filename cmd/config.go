@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/Verce11o/yata-tweets/config"
+	"gopkg.in/yaml.v3"
+	"os"
+)
+
+const configUsage = "usage: yata-tweets config print [--config path] [--redacted]"
+
+// runConfig prints the config the server would run with, after env overrides and defaults.
+// Validation problems are reported on stderr, the config is printed anyway.
+func runConfig(args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, configUsage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("config print", flag.ExitOnError)
+	configPath := configFlag(flags)
+	redact := flags.Bool("redacted", false, "replace secrets with REDACTED")
+	_ = flags.Parse(args[1:])
+
+	cfg, err := config.Load(config.Path(*configPath))
+
+	var validationErr *config.ValidationError
+
+	if err != nil && !errors.As(err, &validationErr) {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *redact {
+		cfg = cfg.Redacted()
+	}
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "cannot encode config: %v\n", err)
+		return 1
+	}
+
+	if validationErr != nil {
+		fmt.Fprintln(os.Stderr, validationErr)
+		return 1
+	}
+
+	return 0
+}
@@ -1,9 +1,113 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/Verce11o/yata-tweets/config"
 	"github.com/Verce11o/yata-tweets/internal/app"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	// scheduled tweets are read in IANA time zones, which slim images do not ship
+	_ "time/tzdata"
 )
 
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) int
+}
+
+var commands = []command{
+	{name: "serve", usage: "serve [--config path]", run: runServe},
+	{name: "migrate", usage: "migrate up|down|status|redo [--config path]", run: runMigrate},
+	{name: "seed", usage: "seed [--count n] [--users n] [--image-every n]", run: runSeed},
+	{name: "reindex", usage: "reindex [--since 24h|2006-01-02T15:04:05Z]", run: runReindex},
+	{name: "purge-orphans", usage: "purge-orphans [--min-age 24h] [--dry-run]", run: runPurgeOrphans},
+	{name: "replay-events", usage: "replay-events --since 1h|2006-01-02T15:04:05Z [--dry-run]", run: runReplayEvents},
+	{name: "export-user", usage: "export-user <user id> [--images] [--out file]", run: runExportUser},
+	{name: "config", usage: "config print [--config path] [--redacted]", run: runConfig},
+}
+
 func main() {
-	app.Run()
+	args := os.Args[1:]
+
+	// without a command the server is started, as before commands existed
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		os.Exit(runServe(args))
+	}
+
+	for _, c := range commands {
+		if c.name == args[0] {
+			os.Exit(c.run(args[1:]))
+		}
+	}
+
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: yata-tweets <command> [flags]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %s\n", c.usage)
+	}
+
+	fmt.Fprintln(os.Stderr, "\ncommands using the service's dependencies accept --config, see config print")
+}
+
+func runServe(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	configPath := configFlag(flags)
+	_ = flags.Parse(args)
+
+	path := config.Path(*configPath)
+	cfg, err := config.Load(path)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	app.Run(cfg, path)
+
+	return 0
+}
+
+func configFlag(flags *flag.FlagSet) *string {
+	return flags.String("config", "", "path to the config file (default $"+config.PathEnv+" or "+config.DefaultPath+")")
+}
+
+// withDeps loads the config, builds the wiring shared with the server and runs fn with it.
+// fn is cancelled on SIGINT or SIGTERM.
+func withDeps(configPath string, fn func(ctx context.Context, deps *app.Deps) error) int {
+	path := config.Path(configPath)
+	cfg, err := config.Load(path)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	deps := app.NewDeps(cfg, path)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = fn(ctx, deps)
+
+	if closeErr := deps.Close(); closeErr != nil {
+		deps.Log.Errorf("cannot close dependencies: %v", closeErr)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
 }
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/Verce11o/yata-tweets/config"
+	"github.com/Verce11o/yata-tweets/internal/repository/postgres"
+	"github.com/pressly/goose/v3"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = "usage: yata-tweets migrate up|down|status|redo [--config path]"
+
+// runMigrate applies the embedded migrations: up applies all pending ones,
+// down rolls back the latest one and redo rolls it back and applies it again.
+func runMigrate(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	command := args[0]
+
+	flags := flag.NewFlagSet("migrate "+command, flag.ExitOnError)
+	configPath := configFlag(flags)
+	_ = flags.Parse(args[1:])
+
+	cfg, err := config.Load(config.Path(*configPath))
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	db := postgres.NewPostgres(cfg)
+	defer db.Close()
+
+	migrator, err := postgres.NewMigrator(db.DB)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot init migrations: %v\n", err)
+		return 1
+	}
+
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		results, err := migrator.Up(ctx)
+		printMigrations(results...)
+		if err == nil && len(results) == 0 {
+			fmt.Println("no pending migrations")
+		}
+		return migrateResult(err)
+	case "down":
+		result, err := migrator.Down(ctx)
+		printMigrations(result)
+		return migrateResult(err)
+	case "redo":
+		result, err := migrator.Down(ctx)
+		printMigrations(result)
+		if err != nil {
+			return migrateResult(err)
+		}
+
+		result, err = migrator.UpByOne(ctx)
+		printMigrations(result)
+		return migrateResult(err)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return migrateResult(err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "MIGRATION\tSTATE\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := ""
+			if s.State == goose.StateApplied {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", s.Source.Path, s.State, appliedAt)
+		}
+		_ = w.Flush()
+		return 0
+	}
+
+	fmt.Fprintln(os.Stderr, migrateUsage)
+	return 2
+}
+
+func printMigrations(results ...*goose.MigrationResult) {
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+
+		state := "OK"
+		if r.Error != nil {
+			state = "FAIL"
+		}
+
+		fmt.Printf("%-4s %-4s %s (%s)\n", state, r.Direction, r.Source.Path, r.Duration.Round(time.Millisecond))
+	}
+}
+
+func migrateResult(err error) int {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migration failed: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
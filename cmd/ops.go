@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/Verce11o/yata-tweets/internal/app"
+	"github.com/Verce11o/yata-tweets/internal/service"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+func runSeed(args []string) int {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	configPath := configFlag(flags)
+	count := flags.Int("count", 1000, "number of tweets to create")
+	users := flags.Int("users", 50, "number of fake authors")
+	imageEvery := flags.Int("image-every", 10, "attach an image to every n-th tweet, 0 disables images")
+	_ = flags.Parse(args)
+
+	return withDeps(*configPath, func(ctx context.Context, deps *app.Deps) error {
+		created, err := deps.NewOpsService(nil).Seed(ctx, service.SeedOptions{Count: *count, Users: *users, ImageEvery: *imageEvery})
+		fmt.Printf("created %d tweets\n", created)
+		return err
+	})
+}
+
+func runReindex(args []string) int {
+	flags := flag.NewFlagSet("reindex", flag.ExitOnError)
+	configPath := configFlag(flags)
+	since := flags.String("since", "", "only tweets created since, a duration back from now or a RFC 3339 time (default all)")
+	_ = flags.Parse(args)
+
+	sinceTime, err := parseSince(*since)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	return withDeps(*configPath, func(ctx context.Context, deps *app.Deps) error {
+		reindexed, err := deps.NewOpsService(nil).Reindex(ctx, sinceTime)
+		fmt.Printf("reindexed %d tweets\n", reindexed)
+		return err
+	})
+}
+
+func runPurgeOrphans(args []string) int {
+	flags := flag.NewFlagSet("purge-orphans", flag.ExitOnError)
+	configPath := configFlag(flags)
+	minAge := flags.Duration("min-age", 24*time.Hour, "keep images younger than this, they may belong to tweets being created")
+	dryRun := flags.Bool("dry-run", false, "only list the orphaned images")
+	_ = flags.Parse(args)
+
+	return withDeps(*configPath, func(ctx context.Context, deps *app.Deps) error {
+		purged, err := deps.NewOpsService(nil).PurgeOrphans(ctx, service.PurgeOptions{MinAge: *minAge, DryRun: *dryRun})
+
+		for _, name := range purged {
+			fmt.Println(name)
+		}
+
+		verb := "purged"
+		if *dryRun {
+			verb = "would purge"
+		}
+		fmt.Fprintf(os.Stderr, "%s %d images\n", verb, len(purged))
+
+		return err
+	})
+}
+
+func runReplayEvents(args []string) int {
+	flags := flag.NewFlagSet("replay-events", flag.ExitOnError)
+	configPath := configFlag(flags)
+	since := flags.String("since", "", "replay tweets created since, a duration back from now or a RFC 3339 time")
+	dryRun := flags.Bool("dry-run", false, "only count the events")
+	_ = flags.Parse(args)
+
+	if *since == "" {
+		fmt.Fprintln(os.Stderr, "--since is required")
+		return 2
+	}
+
+	sinceTime, err := parseSince(*since)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	return withDeps(*configPath, func(ctx context.Context, deps *app.Deps) error {
+		amqpConn, publisher := deps.NewPublisher()
+
+		replayed, err := deps.NewOpsService(publisher).ReplayEvents(ctx, sinceTime, *dryRun)
+		fmt.Printf("replayed %d events\n", replayed)
+
+		if closeErr := publisher.Close(ctx); closeErr != nil {
+			deps.Log.Errorf("cannot close publisher: %v", closeErr)
+		}
+
+		if closeErr := amqpConn.Close(); closeErr != nil {
+			deps.Log.Errorf("cannot close rabbitmq connection: %v", closeErr)
+		}
+
+		return err
+	})
+}
+
+func runExportUser(args []string) int {
+	flags := flag.NewFlagSet("export-user", flag.ExitOnError)
+	configPath := configFlag(flags)
+	images := flags.Bool("images", false, "include images, base64 encoded")
+	out := flags.String("out", "", "write the export to a file instead of stdout")
+
+	// the user id may come before the flags
+	var userID string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		userID, args = args[0], args[1:]
+	}
+
+	_ = flags.Parse(args)
+
+	if userID == "" {
+		userID = flags.Arg(0)
+	}
+
+	if userID == "" {
+		fmt.Fprintln(os.Stderr, "usage: yata-tweets export-user <user id> [--images] [--out file]")
+		return 2
+	}
+
+	return withDeps(*configPath, func(ctx context.Context, deps *app.Deps) error {
+		export, err := deps.NewOpsService(nil).ExportUser(ctx, userID, *images)
+
+		if err != nil {
+			return err
+		}
+
+		if *out == "" {
+			return writeJSON(os.Stdout, export)
+		}
+
+		f, err := os.Create(*out)
+
+		if err != nil {
+			return err
+		}
+
+		if err := writeJSON(f, export); err != nil {
+			f.Close()
+			return err
+		}
+
+		return f.Close()
+	})
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// parseSince accepts a duration back from now, e.g. 2h, or a RFC 3339 time. Empty means the beginning of time.
+func parseSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q, want a duration or a RFC 3339 time", value)
+	}
+
+	return t, nil
+}
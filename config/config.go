@@ -1,8 +1,9 @@
 package config
 
 import (
-	"github.com/ilyakaznacheev/cleanenv"
-	"log"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"time"
 )
 
 type Config struct {
@@ -12,60 +13,296 @@ type Config struct {
 	App         App            `yaml:"app"`
 	MinioConfig MinioConfig    `yaml:"minio"`
 	Metrics     Metrics        `yaml:"metrics"`
+	Auth        Auth           `yaml:"auth"`
+	RateLimit   RateLimit      `yaml:"rateLimit"`
+	Idempotency Idempotency    `yaml:"idempotency"`
+	LocalCache  LocalCache     `yaml:"localCache"`
+	Feed        Feed           `yaml:"feed"`
+	Scheduler   Scheduler      `yaml:"scheduler"`
+	Gateway     Gateway        `yaml:"gateway"`
+	Health      Health         `yaml:"health"`
+	Logger      Logger         `yaml:"logger"`
+	Admin       Admin          `yaml:"admin"`
+	Settings    Settings       `yaml:"settings"`
 }
 
 type PostgresConfig struct {
 	Host     string `yaml:"PostgresqlHost" env:"POSTGRESQL_HOST"`
 	Port     string `yaml:"PostgresqlPort" env:"POSTGRESQL_PORT"`
 	User     string `yaml:"PostgresqlUser" env:"POSTGRESQL_USERNAME"`
-	Password string `yaml:"PostgresqlPassword" env:"POSTGRESQL_PASSWORD"`
+	Password string `yaml:"PostgresqlPassword" env:"POSTGRESQL_PASSWORD" secret:"true"`
 	Name     string `yaml:"PostgresqlDbname" env:"POSTGRESQL_NAME"`
+	// AutoMigrate applies pending migrations on start, see the migrate command.
+	AutoMigrate bool `yaml:"PostgresqlAutoMigrate" env:"POSTGRESQL_AUTO_MIGRATE"`
+	// SSLMode is one of disable, require, verify-ca or verify-full.
+	SSLMode     string `yaml:"PostgresqlSSLMode" env:"POSTGRESQL_SSL_MODE" env-default:"disable"`
+	SSLRootCert string `yaml:"PostgresqlSSLRootCert" env:"POSTGRESQL_SSL_ROOT_CERT"`
+	SSLCert     string `yaml:"PostgresqlSSLCert" env:"POSTGRESQL_SSL_CERT"`
+	SSLKey      string `yaml:"PostgresqlSSLKey" env:"POSTGRESQL_SSL_KEY"`
+	// StatementCacheSize is how many prepared statements are kept per pool.
+	StatementCacheSize int              `yaml:"PostgresqlStatementCacheSize" env:"POSTGRESQL_STATEMENT_CACHE_SIZE" env-default:"100"`
+	Pool               PostgresPool     `yaml:"PostgresqlPool"`
+	Replicas           PostgresReplicas `yaml:"PostgresqlReplicas"`
+}
+
+// PostgresPool applies to the primary and to every replica.
+type PostgresPool struct {
+	MaxOpenConns    int           `yaml:"maxOpenConns" env:"POSTGRESQL_MAX_OPEN_CONNS" env-default:"25"`
+	MaxIdleConns    int           `yaml:"maxIdleConns" env:"POSTGRESQL_MAX_IDLE_CONNS" env-default:"10"`
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime" env:"POSTGRESQL_CONN_MAX_LIFETIME" env-default:"30m"`
+	ConnMaxIdleTime time.Duration `yaml:"connMaxIdleTime" env:"POSTGRESQL_CONN_MAX_IDLE_TIME" env-default:"5m"`
+}
+
+// PostgresReplicas serve the read paths. They share the credentials and the database name of the primary.
+type PostgresReplicas struct {
+	// Hosts are host:port pairs.
+	Hosts []string `yaml:"hosts" env:"POSTGRESQL_REPLICA_HOSTS" env-separator:","`
+	// ReadYourWritesWindow is how long after a write the reads of the same user go to the primary,
+	// it should exceed the replication lag.
+	ReadYourWritesWindow time.Duration `yaml:"readYourWritesWindow" env:"POSTGRESQL_READ_YOUR_WRITES_WINDOW" env-default:"5s"`
 }
 
 type RabbitMQ struct {
-	Username     string `yaml:"username" env-required:"true"`
-	Password     string `yaml:"password" env-required:"true"`
-	Host         string `yaml:"host" env-required:"true"`
-	Port         string `yaml:"port" env-required:"true"`
-	ExchangeName string `yaml:"exchangeName" env-required:"true"`
-	QueueName    string `yaml:"queueName" env-required:"true"`
-	ConsumerTag  string `yaml:"consumerTag" env-required:"true"`
-	BindingKey   string `yaml:"bindingKey" env-required:"true"`
+	Username     string `yaml:"username" env:"RABBITMQ_USERNAME"`
+	Password     string `yaml:"password" env:"RABBITMQ_PASSWORD" secret:"true"`
+	Host         string `yaml:"host" env:"RABBITMQ_HOST"`
+	Port         string `yaml:"port" env:"RABBITMQ_PORT"`
+	ExchangeName string `yaml:"exchangeName" env:"RABBITMQ_EXCHANGE_NAME"`
+	QueueName    string `yaml:"queueName" env:"RABBITMQ_QUEUE_NAME"`
+	ConsumerTag  string `yaml:"consumerTag" env:"RABBITMQ_CONSUMER_TAG"`
+	BindingKey   string `yaml:"bindingKey" env:"RABBITMQ_BINDING_KEY"`
 }
 
 type RedisConfig struct {
-	Host     string `yaml:"RedisHost" env:"REDISHOST"`
-	Port     string `yaml:"RedisPort" env:"REDISPORT"`
-	User     string `yaml:"RedisUser" env:"REDISUSER"`
-	Password string `yaml:"RedisPassword" env:"REDISPASSWORD"`
-	DB       int    `yaml:"RedisDB" env:"REDISDB"`
+	// Mode is standalone, sentinel or cluster.
+	Mode string `yaml:"RedisMode" env:"REDIS_MODE" env-default:"standalone"`
+	// Host and Port address the server in standalone mode.
+	Host string `yaml:"RedisHost" env:"REDISHOST"`
+	Port string `yaml:"RedisPort" env:"REDISPORT"`
+	// Addrs are host:port pairs of the sentinels in sentinel mode and of the seed nodes in cluster mode.
+	Addrs            []string `yaml:"RedisAddrs" env:"REDIS_ADDRS" env-separator:","`
+	MasterName       string   `yaml:"RedisMasterName" env:"REDIS_MASTER_NAME"`
+	SentinelPassword string   `yaml:"RedisSentinelPassword" env:"REDIS_SENTINEL_PASSWORD" secret:"true"`
+	User             string   `yaml:"RedisUser" env:"REDISUSER"`
+	Password         string   `yaml:"RedisPassword" env:"REDISPASSWORD" secret:"true"`
+	// DB must be 0 in cluster mode.
+	DB       int           `yaml:"RedisDB" env:"REDISDB"`
+	TLS      RedisTLS      `yaml:"RedisTLS"`
+	Pool     RedisPool     `yaml:"RedisPool"`
+	Timeouts RedisTimeouts `yaml:"RedisTimeouts"`
+	// CacheCodec writes cached tweets as protobuf, msgpack or json. Entries of every codec are read,
+	// so it can be changed without flushing the cache.
+	CacheCodec string `yaml:"RedisCacheCodec" env:"REDIS_CACHE_CODEC" env-default:"protobuf"`
+	// CacheCompressAbove compresses cached tweets larger than this many bytes, zero disables compression.
+	CacheCompressAbove int `yaml:"RedisCacheCompressAbove" env:"REDIS_CACHE_COMPRESS_ABOVE" env-default:"512"`
+}
+
+type RedisTLS struct {
+	Enabled bool `yaml:"enabled" env:"REDIS_TLS_ENABLED"`
+	// CAFile verifies the server instead of the system roots.
+	CAFile string `yaml:"caFile" env:"REDIS_TLS_CA_FILE"`
+	// CertFile and KeyFile are the client certificate, when the server requires one.
+	CertFile           string `yaml:"certFile" env:"REDIS_TLS_CERT_FILE"`
+	KeyFile            string `yaml:"keyFile" env:"REDIS_TLS_KEY_FILE"`
+	ServerName         string `yaml:"serverName" env:"REDIS_TLS_SERVER_NAME"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify" env:"REDIS_TLS_INSECURE_SKIP_VERIFY"`
+}
+
+// RedisPool applies per node in cluster mode. Zero values keep the go-redis defaults.
+type RedisPool struct {
+	Size            int           `yaml:"size" env:"REDIS_POOL_SIZE"`
+	MinIdleConns    int           `yaml:"minIdleConns" env:"REDIS_POOL_MIN_IDLE_CONNS"`
+	Timeout         time.Duration `yaml:"timeout" env:"REDIS_POOL_TIMEOUT"`
+	ConnMaxIdleTime time.Duration `yaml:"connMaxIdleTime" env:"REDIS_POOL_CONN_MAX_IDLE_TIME" env-default:"30m"`
+}
+
+type RedisTimeouts struct {
+	Dial  time.Duration `yaml:"dial" env:"REDIS_DIAL_TIMEOUT" env-default:"5s"`
+	Read  time.Duration `yaml:"read" env:"REDIS_READ_TIMEOUT" env-default:"3s"`
+	Write time.Duration `yaml:"write" env:"REDIS_WRITE_TIMEOUT" env-default:"3s"`
 }
 
 type MinioConfig struct {
-	Endpoint  string `yaml:"Endpoint"`
-	AccessKey string `yaml:"MinioAccessKey"`
-	SecretKey string `yaml:"MinioSecretKey"`
-	SSL       bool   `yaml:"UseSSL"`
+	Backend    string `yaml:"Backend" env:"STORAGE_BACKEND" env-default:"minio"`
+	Endpoint   string `yaml:"Endpoint" env:"MINIO_ENDPOINT"`
+	AccessKey  string `yaml:"MinioAccessKey" env:"MINIO_ACCESS_KEY" secret:"true"`
+	SecretKey  string `yaml:"MinioSecretKey" env:"MINIO_SECRET_KEY" secret:"true"`
+	SSL        bool   `yaml:"UseSSL" env:"MINIO_USE_SSL"`
+	Bucket     string `yaml:"Bucket" env:"STORAGE_BUCKET" env-default:"user-tweets"`
+	LocalPath  string `yaml:"LocalPath" env:"STORAGE_LOCAL_PATH" env-default:"./data"`
+	ExpireDays int    `yaml:"ExpireDays" env:"STORAGE_EXPIRE_DAYS"`
 }
 
 type Metrics struct {
-	Jaeger Jaeger `yaml:"jaeger"`
+	Tracing    Tracing    `yaml:"tracing"`
+	Prometheus Prometheus `yaml:"prometheus"`
 }
 
-type Jaeger struct {
-	Endpoint string `yaml:"endpoint"`
+type Prometheus struct {
+	Enabled bool   `yaml:"enabled" env:"PROMETHEUS_ENABLED"`
+	Port    string `yaml:"port" env:"PROMETHEUS_PORT" env-default:"9100"`
+	Path    string `yaml:"path" env:"PROMETHEUS_PATH" env-default:"/metrics"`
 }
 
-type App struct {
-	Port string `yaml:"port"`
+type Tracing struct {
+	Exporter    string            `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"otlpgrpc"`
+	Endpoint    string            `yaml:"endpoint" env:"TRACING_ENDPOINT"`
+	Headers     map[string]string `yaml:"headers" env:"TRACING_HEADERS" secret:"true"`
+	Insecure    bool              `yaml:"insecure" env:"TRACING_INSECURE"`
+	CAFile      string            `yaml:"caFile" env:"TRACING_CA_FILE"`
+	SampleRatio float64           `yaml:"sampleRatio" env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+	ServiceName string            `yaml:"serviceName" env:"TRACING_SERVICE_NAME" env-default:"yata-tweets"`
+	Version     string            `yaml:"version" env:"SERVICE_VERSION" env-default:"dev"`
+	Environment string            `yaml:"environment" env:"DEPLOYMENT_ENVIRONMENT" env-default:"development"`
+	InstanceID  string            `yaml:"instanceID" env:"SERVICE_INSTANCE_ID"`
+}
+
+type Auth struct {
+	Secret   string `yaml:"secret" env:"AUTH_SECRET" secret:"true"`
+	JWKSFile string `yaml:"jwksFile" env:"AUTH_JWKS_FILE"`
+	Issuer   string `yaml:"issuer" env:"AUTH_ISSUER"`
+	Audience string `yaml:"audience" env:"AUTH_AUDIENCE"`
+}
+
+type RateLimit struct {
+	Enabled bool   `yaml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	Methods Quotas `yaml:"methods" env:"RATE_LIMIT_METHODS"`
 }
 
-func LoadConfig() *Config {
-	var cfg Config
+// Quotas are keyed by the short name of the gRPC method. RATE_LIMIT_METHODS holds them as JSON with the keys
+// of the config file, e.g. {"CreateTweet": {"perUser": {"rate": 10, "period": "1m", "burst": 5}}}.
+type Quotas map[string]Quota
 
-	if err := cleanenv.ReadConfig("config.yml", &cfg); err != nil {
-		log.Fatalf("error while reading config file: %s", err)
+// SetValue replaces the quotas of the config file with the ones from the env.
+func (q *Quotas) SetValue(value string) error {
+	var quotas Quotas
+
+	// JSON is valid YAML, and the YAML decoder reads durations such as 1m
+	if err := yaml.Unmarshal([]byte(value), &quotas); err != nil {
+		return fmt.Errorf("parse rate limit quotas: %w", err)
 	}
-	return &cfg
 
+	*q = quotas
+
+	return nil
+}
+
+type Quota struct {
+	PerUser Limit `yaml:"perUser"`
+	PerIP   Limit `yaml:"perIP"`
+}
+
+type Limit struct {
+	Rate   int           `yaml:"rate"`
+	Period time.Duration `yaml:"period"`
+	Burst  int           `yaml:"burst"`
+}
+
+type Idempotency struct {
+	TTL         time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL" env-default:"24h"`
+	LockTTL     time.Duration `yaml:"lockTTL" env:"IDEMPOTENCY_LOCK_TTL" env-default:"30s"`
+	WaitTimeout time.Duration `yaml:"waitTimeout" env:"IDEMPOTENCY_WAIT_TIMEOUT" env-default:"5s"`
+}
+
+// LocalCache keeps recently read tweets in memory in front of Redis.
+type LocalCache struct {
+	// Size is the number of tweets kept, zero disables the local cache.
+	Size int `yaml:"size" env:"LOCAL_CACHE_SIZE" env-default:"10000"`
+	// TTL bounds how long a replica serves a tweet whose invalidation it missed.
+	TTL time.Duration `yaml:"ttl" env:"LOCAL_CACHE_TTL" env-default:"5s"`
+	// Channel is the Redis pub/sub channel invalidations are published on.
+	Channel string `yaml:"channel" env:"LOCAL_CACHE_CHANNEL" env-default:"tweet-cache-invalidations"`
+}
+
+type Feed struct {
+	HeartbeatInterval time.Duration `yaml:"heartbeatInterval" env:"FEED_HEARTBEAT_INTERVAL" env-default:"15s"`
+	BufferSize        int           `yaml:"bufferSize" env:"FEED_BUFFER_SIZE" env-default:"256"`
+	MaxLen            int64         `yaml:"maxLen" env:"FEED_MAX_LEN" env-default:"10000"`
+}
+
+// Scheduler publishes scheduled tweets once they are due.
+type Scheduler struct {
+	// Interval is how often due tweets are looked for, zero disables publishing on this replica.
+	Interval time.Duration `yaml:"interval" env:"SCHEDULER_INTERVAL" env-default:"10s"`
+	// Batch is the most tweets a replica publishes per interval.
+	Batch int `yaml:"batch" env:"SCHEDULER_BATCH" env-default:"100"`
+	// MaxAttempts marks a scheduled tweet as failed after it could not be published this many times.
+	MaxAttempts int `yaml:"maxAttempts" env:"SCHEDULER_MAX_ATTEMPTS" env-default:"5"`
+	// RetryBackoff puts off the next attempt after a failure, it doubles with every attempt up to MaxRetryBackoff.
+	RetryBackoff    time.Duration `yaml:"retryBackoff" env:"SCHEDULER_RETRY_BACKOFF" env-default:"30s"`
+	MaxRetryBackoff time.Duration `yaml:"maxRetryBackoff" env:"SCHEDULER_MAX_RETRY_BACKOFF" env-default:"1h"`
+	// MaxAhead is how far in the future tweets can be scheduled.
+	MaxAhead time.Duration `yaml:"maxAhead" env:"SCHEDULER_MAX_AHEAD" env-default:"8760h"`
+}
+
+type Gateway struct {
+	Enabled       bool   `yaml:"enabled" env:"GATEWAY_ENABLED"`
+	Port          string `yaml:"port" env:"GATEWAY_PORT" env-default:"8080"`
+	MaxUploadSize int64  `yaml:"maxUploadSize" env:"GATEWAY_MAX_UPLOAD_SIZE" env-default:"6291456"`
+	// TrustedProxies are the CIDRs of the proxies in front of the gateway. The client address is read
+	// from the X-Forwarded-For hops they add, the rest of the header is ignored.
+	TrustedProxies []string `yaml:"trustedProxies" env:"GATEWAY_TRUSTED_PROXIES" env-separator:","`
+	CORS           CORS     `yaml:"cors"`
+}
+
+type CORS struct {
+	AllowedOrigins   []string      `yaml:"allowedOrigins" env:"GATEWAY_CORS_ALLOWED_ORIGINS" env-separator:","`
+	AllowedHeaders   []string      `yaml:"allowedHeaders" env:"GATEWAY_CORS_ALLOWED_HEADERS" env-separator:"," env-default:"Authorization,Content-Type,Idempotency-Key"`
+	AllowCredentials bool          `yaml:"allowCredentials" env:"GATEWAY_CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `yaml:"maxAge" env:"GATEWAY_CORS_MAX_AGE" env-default:"10m"`
+}
+
+type Health struct {
+	Interval time.Duration `yaml:"interval" env:"HEALTH_INTERVAL" env-default:"10s"`
+	Timeout  time.Duration `yaml:"timeout" env:"HEALTH_TIMEOUT" env-default:"2s"`
+}
+
+type Logger struct {
+	Level    string         `yaml:"level" env:"LOG_LEVEL" env-default:"info"`
+	Encoding string         `yaml:"encoding" env:"LOG_ENCODING" env-default:"json"`
+	Sampling LoggerSampling `yaml:"sampling"`
+}
+
+// LoggerSampling keeps the first Initial entries with the same message every second and then every Thereafter-th.
+// Zero Initial disables sampling.
+type LoggerSampling struct {
+	Initial    int `yaml:"initial" env:"LOG_SAMPLING_INITIAL"`
+	Thereafter int `yaml:"thereafter" env:"LOG_SAMPLING_THEREAFTER"`
+}
+
+type Admin struct {
+	Enabled bool   `yaml:"enabled" env:"ADMIN_ENABLED"`
+	Host    string `yaml:"host" env:"ADMIN_HOST" env-default:"127.0.0.1"`
+	Port    string `yaml:"port" env:"ADMIN_PORT" env-default:"9101"`
+}
+
+type App struct {
+	Port       string `yaml:"port" env:"APP_PORT"`
+	Reflection bool   `yaml:"reflection" env:"APP_REFLECTION"`
+	// ShutdownPhaseTimeout bounds each shutdown phase, see internal/lib/lifecycle.
+	ShutdownPhaseTimeout time.Duration `yaml:"shutdownPhaseTimeout" env:"APP_SHUTDOWN_PHASE_TIMEOUT" env-default:"10s"`
+}
+
+// Settings can be changed without a restart, see internal/lib/settings.
+type Settings struct {
+	TweetCacheTTL time.Duration `yaml:"tweetCacheTTL" env:"SETTINGS_TWEET_CACHE_TTL" env-default:"1h"`
+	// TweetCacheNegativeTTL is how long a lookup of a missing tweet is cached.
+	TweetCacheNegativeTTL time.Duration `yaml:"tweetCacheNegativeTTL" env:"SETTINGS_TWEET_CACHE_NEGATIVE_TTL" env-default:"30s"`
+	// TweetCacheJitter shortens every cache TTL by a random fraction up to this value.
+	TweetCacheJitter float64 `yaml:"tweetCacheJitter" env:"SETTINGS_TWEET_CACHE_JITTER" env-default:"0.1"`
+	// TweetCacheEarlyRefresh is the XFetch beta: higher values refresh entries earlier before they expire, zero disables it.
+	TweetCacheEarlyRefresh float64 `yaml:"tweetCacheEarlyRefresh" env:"SETTINGS_TWEET_CACHE_EARLY_REFRESH" env-default:"1"`
+	PageSize               int     `yaml:"pageSize" env:"SETTINGS_PAGE_SIZE" env-default:"10"`
+	// PageCacheTTL is how long pages of GetAllTweets are cached, zero disables page caching.
+	PageCacheTTL time.Duration `yaml:"pageCacheTTL" env:"SETTINGS_PAGE_CACHE_TTL" env-default:"30s"`
+	// EditWindow is how long after creation a tweet can be updated, zero means forever.
+	EditWindow time.Duration  `yaml:"editWindow" env:"SETTINGS_EDIT_WINDOW"`
+	Reload     SettingsReload `yaml:"reload"`
+}
+
+type SettingsReload struct {
+	// Interval between checks of the config file and RedisKey, zero disables reloading.
+	Interval time.Duration `yaml:"interval" env:"SETTINGS_RELOAD_INTERVAL" env-default:"30s"`
+	// RedisKey names an optional hash whose fields override the settings, e.g. HSET settings pageSize 20.
+	RedisKey string `yaml:"redisKey" env:"SETTINGS_REDIS_KEY"`
 }
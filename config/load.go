@@ -0,0 +1,159 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ilyakaznacheev/cleanenv"
+	"os"
+	"reflect"
+	"strings"
+)
+
+const (
+	DefaultPath = "config.yml"
+	PathEnv     = "CONFIG_PATH"
+
+	// fileEnvSuffix marks an env variable holding the path of a file with the value, e.g. POSTGRESQL_PASSWORD_FILE.
+	fileEnvSuffix = "_FILE"
+	redacted      = "REDACTED"
+)
+
+// Path picks the config file: the --config flag, then CONFIG_PATH, then DefaultPath.
+func Path(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+
+	if path := os.Getenv(PathEnv); path != "" {
+		return path
+	}
+
+	return DefaultPath
+}
+
+// Load reads the config file at path, overrides it with env variables and validates the result.
+// A missing DefaultPath is not an error, the service can then be configured by env alone.
+// When validation fails the loaded config is returned along with a *ValidationError.
+func Load(path string) (*Config, error) {
+	var cfg Config
+
+	err := cleanenv.ReadConfig(path, &cfg)
+
+	if errors.Is(err, os.ErrNotExist) && path == DefaultPath {
+		err = cleanenv.ReadEnv(&cfg)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("read config %s: %w", path, err)
+	}
+
+	problems := readFiles(reflect.ValueOf(&cfg).Elem(), "")
+	problems = append(problems, cfg.Validate()...)
+
+	if len(problems) > 0 {
+		return &cfg, &ValidationError{Problems: problems}
+	}
+
+	return &cfg, nil
+}
+
+// readFiles sets every string field whose env variable has a *_FILE variant to the content of that file.
+// The plain variable wins when both are set.
+func readFiles(v reflect.Value, path string) []string {
+	var problems []string
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name := joinPath(path, field)
+
+		if field.Type.Kind() == reflect.Struct {
+			problems = append(problems, readFiles(v.Field(i), name)...)
+			continue
+		}
+
+		env := field.Tag.Get("env")
+
+		if env == "" {
+			continue
+		}
+
+		file, ok := os.LookupEnv(env + fileEnvSuffix)
+
+		if !ok {
+			continue
+		}
+
+		if _, set := os.LookupEnv(env); set {
+			continue
+		}
+
+		if field.Type.Kind() != reflect.String {
+			problems = append(problems, fmt.Sprintf("%s: %s%s is only supported for string values", name, env, fileEnvSuffix))
+			continue
+		}
+
+		content, err := os.ReadFile(file)
+
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: cannot read %s%s: %v", name, env, fileEnvSuffix, err))
+			continue
+		}
+
+		v.Field(i).SetString(strings.TrimRight(string(content), "\r\n"))
+	}
+
+	return problems
+}
+
+// Redacted returns a copy of cfg with the fields tagged secret replaced, safe to print or log.
+func (c *Config) Redacted() *Config {
+	cp := *c
+	redact(reflect.ValueOf(&cp).Elem())
+	return &cp
+}
+
+func redact(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		value := v.Field(i)
+
+		if field.Tag.Get("secret") != "true" {
+			if field.Type.Kind() == reflect.Struct {
+				redact(value)
+			}
+			continue
+		}
+
+		switch field.Type.Kind() {
+		case reflect.String:
+			if value.Len() > 0 {
+				value.SetString(redacted)
+			}
+		case reflect.Map:
+			if value.Len() == 0 {
+				continue
+			}
+
+			// the map is shared with the original config
+			m := reflect.MakeMapWithSize(field.Type, value.Len())
+			for _, key := range value.MapKeys() {
+				m.SetMapIndex(key, reflect.ValueOf(redacted))
+			}
+			value.Set(m)
+		}
+	}
+}
+
+func joinPath(path string, field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+
+	if name == "" {
+		name = field.Name
+	}
+
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fileTarget struct {
+	Password string `yaml:"password" env:"TEST_PASSWORD"`
+	Port     int    `yaml:"port" env:"TEST_PORT"`
+	Nested   struct {
+		Token string `yaml:"token" env:"TEST_TOKEN"`
+	} `yaml:"nested"`
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "value")
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestReadFiles(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		// preset is what the env gave the fields before the files are read
+		preset       fileTarget
+		wantPassword string
+		wantToken    string
+		wantProblem  string
+	}{
+		{
+			name:         "file",
+			env:          map[string]string{"TEST_PASSWORD_FILE": "s3cret"},
+			wantPassword: "s3cret",
+		},
+		{
+			name:         "trailing newline",
+			env:          map[string]string{"TEST_PASSWORD_FILE": "s3cret\n"},
+			wantPassword: "s3cret",
+		},
+		{
+			name:         "trailing crlf, inner newline kept",
+			env:          map[string]string{"TEST_PASSWORD_FILE": "line one\nline two\r\n"},
+			wantPassword: "line one\nline two",
+		},
+		{
+			name:         "plain variable wins",
+			env:          map[string]string{"TEST_PASSWORD": "plain", "TEST_PASSWORD_FILE": "from file"},
+			preset:       fileTarget{Password: "plain"},
+			wantPassword: "plain",
+		},
+		{
+			name:      "nested field",
+			env:       map[string]string{"TEST_TOKEN_FILE": "token"},
+			wantToken: "token",
+		},
+		{
+			name:        "not a string",
+			env:         map[string]string{"TEST_PORT_FILE": "8080"},
+			wantProblem: "port: TEST_PORT_FILE is only supported for string values",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				if strings.HasSuffix(name, fileEnvSuffix) {
+					value = writeFile(t, value)
+				}
+				t.Setenv(name, value)
+			}
+
+			target := tt.preset
+			problems := readFiles(reflect.ValueOf(&target).Elem(), "")
+
+			if tt.wantProblem != "" {
+				if len(problems) != 1 || problems[0] != tt.wantProblem {
+					t.Errorf("readFiles() = %q, want %q", problems, tt.wantProblem)
+				}
+				return
+			}
+
+			if len(problems) > 0 || target.Password != tt.wantPassword || target.Nested.Token != tt.wantToken {
+				t.Errorf("readFiles() = %q, read %+v", problems, target)
+			}
+		})
+	}
+}
+
+func TestReadFilesMissingFile(t *testing.T) {
+	t.Setenv("TEST_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	var target fileTarget
+	problems := readFiles(reflect.ValueOf(&target).Elem(), "")
+
+	if len(problems) != 1 || !strings.HasPrefix(problems[0], "password: cannot read TEST_PASSWORD_FILE") {
+		t.Errorf("readFiles() = %q", problems)
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	t.Setenv("POSTGRESQL_PASSWORD_FILE", writeFile(t, "from file\n"))
+	t.Setenv("AUTH_SECRET", "plain")
+	t.Setenv("AUTH_SECRET_FILE", writeFile(t, "from file"))
+	t.Setenv("RATE_LIMIT_METHODS", `{"GetTweet": {"perIP": {"rate": 100, "period": "1m", "burst": 10}}}`)
+
+	cfg, err := Load(filepath.Join("..", "cmd", "config_example.yml"))
+
+	var invalid *ValidationError
+	if err != nil && !errors.As(err, &invalid) {
+		t.Fatal(err)
+	}
+
+	if cfg.Postgres.Password != "from file" || cfg.Auth.Secret != "plain" {
+		t.Errorf("password %q, secret %q", cfg.Postgres.Password, cfg.Auth.Secret)
+	}
+
+	want := Quotas{"GetTweet": {PerIP: Limit{Rate: 100, Period: time.Minute, Burst: 10}}}
+
+	if !reflect.DeepEqual(cfg.RateLimit.Methods, want) {
+		t.Errorf("quotas = %+v, want %+v", cfg.RateLimit.Methods, want)
+	}
+}
+
+func TestLoadInvalidQuotas(t *testing.T) {
+	t.Setenv("RATE_LIMIT_METHODS", `{"GetTweet": {"perIP": {"period": "soon"}}}`)
+
+	if _, err := Load(filepath.Join("..", "cmd", "config_example.yml")); err == nil || !strings.Contains(err.Error(), "parse rate limit quotas") {
+		t.Errorf("Load() = %v, want a parse error", err)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := &Config{}
+	cfg.Postgres.Password = "password"
+	cfg.Postgres.User = "user"
+	cfg.Metrics.Tracing.Headers = map[string]string{"authorization": "Bearer token"}
+
+	redactedCfg := cfg.Redacted()
+
+	if redactedCfg.Postgres.Password != redacted || redactedCfg.Metrics.Tracing.Headers["authorization"] != redacted {
+		t.Errorf("Redacted() = %+v", redactedCfg)
+	}
+
+	if redactedCfg.Postgres.User != "user" || redactedCfg.Auth.Secret != "" {
+		t.Errorf("Redacted() changed values that are not secret or empty: %+v", redactedCfg)
+	}
+
+	if cfg.Postgres.Password != "password" || cfg.Metrics.Tracing.Headers["authorization"] != "Bearer token" {
+		t.Errorf("Redacted() changed the original config: %+v", cfg)
+	}
+}
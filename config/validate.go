@@ -0,0 +1,297 @@
+package config
+
+import (
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxPageSize = 100
+)
+
+// ValidationError lists every problem found in the config, so that a deployment can be fixed in one go.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) addf(format string, args ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) required(name string, value string) {
+	if value == "" {
+		v.addf("%s: required", name)
+	}
+}
+
+func (v *validator) port(name string, value string) {
+	if value == "" {
+		v.addf("%s: required", name)
+		return
+	}
+
+	if port, err := strconv.Atoi(value); err != nil || port < 0 || port > 65535 {
+		v.addf("%s: %q is not a valid port", name, value)
+	}
+}
+
+func (v *validator) hostPort(name string, value string) {
+	_, port, err := net.SplitHostPort(value)
+
+	if err != nil {
+		v.addf("%s: %v", name, err)
+		return
+	}
+
+	v.port(name, port)
+}
+
+func (v *validator) positive(name string, value time.Duration) {
+	if value <= 0 {
+		v.addf("%s: must be positive, got %s", name, value)
+	}
+}
+
+func (v *validator) oneOf(name string, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+
+	v.addf("%s: %q is not one of %s", name, value, strings.Join(allowed, ", "))
+}
+
+func (v *validator) limit(name string, value Limit) {
+	if value.Rate > 0 && value.Period <= 0 {
+		v.addf("%s.period: must be positive when rate is set", name)
+	}
+
+	// the Redis limiter counts in microseconds
+	if value.Rate > 0 && value.Period > 0 && value.Period/time.Duration(value.Rate) < time.Microsecond {
+		v.addf("%s.rate: must allow at most one request per microsecond", name)
+	}
+}
+
+// Validate returns every problem with the values of c. Field names are the yaml paths.
+func (c *Config) Validate() []string {
+	v := &validator{}
+
+	v.required("postgres.PostgresqlHost", c.Postgres.Host)
+	v.port("postgres.PostgresqlPort", c.Postgres.Port)
+	v.required("postgres.PostgresqlUser", c.Postgres.User)
+	v.required("postgres.PostgresqlDbname", c.Postgres.Name)
+	v.oneOf("postgres.PostgresqlSSLMode", c.Postgres.SSLMode, "disable", "require", "verify-ca", "verify-full")
+
+	if c.Postgres.StatementCacheSize < 1 {
+		v.addf("postgres.PostgresqlStatementCacheSize: must be positive, got %d", c.Postgres.StatementCacheSize)
+	}
+
+	if c.Postgres.Pool.MaxOpenConns < 0 || c.Postgres.Pool.MaxIdleConns < 0 {
+		v.addf("postgres.PostgresqlPool: connection limits must not be negative")
+	}
+
+	for i, host := range c.Postgres.Replicas.Hosts {
+		v.hostPort(fmt.Sprintf("postgres.PostgresqlReplicas.hosts[%d]", i), host)
+	}
+
+	if len(c.Postgres.Replicas.Hosts) > 0 {
+		v.positive("postgres.PostgresqlReplicas.readYourWritesWindow", c.Postgres.Replicas.ReadYourWritesWindow)
+	}
+
+	v.required("rabbitmq.username", c.RabbitMQ.Username)
+	v.required("rabbitmq.password", c.RabbitMQ.Password)
+	v.required("rabbitmq.host", c.RabbitMQ.Host)
+	v.port("rabbitmq.port", c.RabbitMQ.Port)
+	v.required("rabbitmq.exchangeName", c.RabbitMQ.ExchangeName)
+	v.required("rabbitmq.queueName", c.RabbitMQ.QueueName)
+	v.required("rabbitmq.consumerTag", c.RabbitMQ.ConsumerTag)
+	v.required("rabbitmq.bindingKey", c.RabbitMQ.BindingKey)
+
+	v.oneOf("redis.RedisMode", c.Redis.Mode, "standalone", "sentinel", "cluster")
+
+	switch c.Redis.Mode {
+	case "standalone":
+		v.required("redis.RedisHost", c.Redis.Host)
+		v.port("redis.RedisPort", c.Redis.Port)
+	case "sentinel", "cluster":
+		if len(c.Redis.Addrs) == 0 {
+			v.addf("redis.RedisAddrs: required in %s mode", c.Redis.Mode)
+		}
+
+		for i, addr := range c.Redis.Addrs {
+			v.hostPort(fmt.Sprintf("redis.RedisAddrs[%d]", i), addr)
+		}
+	}
+
+	if c.Redis.Mode == "sentinel" {
+		v.required("redis.RedisMasterName", c.Redis.MasterName)
+	}
+
+	if c.Redis.Mode == "cluster" && c.Redis.DB != 0 {
+		v.addf("redis.RedisDB: must be 0 in cluster mode, got %d", c.Redis.DB)
+	}
+
+	if (c.Redis.TLS.CertFile == "") != (c.Redis.TLS.KeyFile == "") {
+		v.addf("redis.RedisTLS: certFile and keyFile must be set together")
+	}
+
+	if c.Redis.Pool.Size < 0 || c.Redis.Pool.MinIdleConns < 0 {
+		v.addf("redis.RedisPool: connection limits must not be negative")
+	}
+
+	v.oneOf("redis.RedisCacheCodec", c.Redis.CacheCodec, "protobuf", "msgpack", "json")
+
+	if c.Redis.CacheCompressAbove < 0 {
+		v.addf("redis.RedisCacheCompressAbove: must not be negative, got %d", c.Redis.CacheCompressAbove)
+	}
+
+	v.port("app.port", c.App.Port)
+	v.positive("app.shutdownPhaseTimeout", c.App.ShutdownPhaseTimeout)
+
+	v.oneOf("minio.Backend", c.MinioConfig.Backend, "minio", "local")
+	v.required("minio.Bucket", c.MinioConfig.Bucket)
+
+	switch c.MinioConfig.Backend {
+	case "minio":
+		v.required("minio.Endpoint", c.MinioConfig.Endpoint)
+		v.required("minio.MinioAccessKey", c.MinioConfig.AccessKey)
+		v.required("minio.MinioSecretKey", c.MinioConfig.SecretKey)
+	case "local":
+		v.required("minio.LocalPath", c.MinioConfig.LocalPath)
+	}
+
+	tracing := c.Metrics.Tracing
+	v.oneOf("metrics.tracing.exporter", tracing.Exporter, "otlpgrpc", "otlphttp", "stdout", "none")
+
+	if tracing.SampleRatio < 0 || tracing.SampleRatio > 1 {
+		v.addf("metrics.tracing.sampleRatio: must be between 0 and 1, got %v", tracing.SampleRatio)
+	}
+
+	if c.Metrics.Prometheus.Enabled {
+		v.port("metrics.prometheus.port", c.Metrics.Prometheus.Port)
+	}
+
+	if c.Auth.Secret == "" && c.Auth.JWKSFile == "" {
+		v.addf("auth: one of secret or jwksFile is required")
+	}
+
+	methods := make([]string, 0, len(c.RateLimit.Methods))
+	for method := range c.RateLimit.Methods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	for _, method := range methods {
+		quota := c.RateLimit.Methods[method]
+		v.limit("rateLimit.methods."+method+".perUser", quota.PerUser)
+		v.limit("rateLimit.methods."+method+".perIP", quota.PerIP)
+	}
+
+	v.positive("idempotency.ttl", c.Idempotency.TTL)
+	v.positive("idempotency.lockTTL", c.Idempotency.LockTTL)
+	v.positive("idempotency.waitTimeout", c.Idempotency.WaitTimeout)
+
+	if c.LocalCache.Size < 0 {
+		v.addf("localCache.size: must not be negative, got %d", c.LocalCache.Size)
+	}
+
+	if c.LocalCache.Size > 0 {
+		v.positive("localCache.ttl", c.LocalCache.TTL)
+		v.required("localCache.channel", c.LocalCache.Channel)
+	}
+
+	v.positive("feed.heartbeatInterval", c.Feed.HeartbeatInterval)
+
+	if c.Feed.BufferSize <= 0 {
+		v.addf("feed.bufferSize: must be positive, got %d", c.Feed.BufferSize)
+	}
+
+	if c.Scheduler.Interval < 0 {
+		v.addf("scheduler.interval: must not be negative, got %s", c.Scheduler.Interval)
+	}
+
+	if c.Scheduler.Batch < 1 {
+		v.addf("scheduler.batch: must be positive, got %d", c.Scheduler.Batch)
+	}
+
+	if c.Scheduler.MaxAttempts < 1 {
+		v.addf("scheduler.maxAttempts: must be positive, got %d", c.Scheduler.MaxAttempts)
+	}
+
+	v.positive("scheduler.retryBackoff", c.Scheduler.RetryBackoff)
+	v.positive("scheduler.maxRetryBackoff", c.Scheduler.MaxRetryBackoff)
+	v.positive("scheduler.maxAhead", c.Scheduler.MaxAhead)
+
+	if c.Gateway.Enabled {
+		v.port("gateway.port", c.Gateway.Port)
+	}
+
+	for _, cidr := range c.Gateway.TrustedProxies {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			v.addf("gateway.trustedProxies: %v", err)
+		}
+	}
+
+	v.positive("health.interval", c.Health.Interval)
+	v.positive("health.timeout", c.Health.Timeout)
+
+	if _, err := zapcore.ParseLevel(c.Logger.Level); err != nil {
+		v.addf("logger.level: %v", err)
+	}
+
+	v.oneOf("logger.encoding", c.Logger.Encoding, "json", "console")
+
+	if c.Admin.Enabled {
+		v.port("admin.port", c.Admin.Port)
+	}
+
+	return append(v.problems, c.Settings.Validate()...)
+}
+
+// Validate returns every problem with the runtime settings, it is also run before a reload is applied.
+func (s *Settings) Validate() []string {
+	v := &validator{}
+
+	v.positive("settings.tweetCacheTTL", s.TweetCacheTTL)
+	v.positive("settings.tweetCacheNegativeTTL", s.TweetCacheNegativeTTL)
+
+	if s.TweetCacheJitter < 0 || s.TweetCacheJitter >= 1 {
+		v.addf("settings.tweetCacheJitter: must be at least 0 and below 1, got %v", s.TweetCacheJitter)
+	}
+
+	if s.TweetCacheEarlyRefresh < 0 {
+		v.addf("settings.tweetCacheEarlyRefresh: must not be negative, got %v", s.TweetCacheEarlyRefresh)
+	}
+
+	if s.PageSize < 1 || s.PageSize > maxPageSize {
+		v.addf("settings.pageSize: must be between 1 and %d, got %d", maxPageSize, s.PageSize)
+	}
+
+	if s.PageCacheTTL < 0 {
+		v.addf("settings.pageCacheTTL: must not be negative, got %s", s.PageCacheTTL)
+	}
+
+	if s.EditWindow < 0 {
+		v.addf("settings.editWindow: must not be negative, got %s", s.EditWindow)
+	}
+
+	if s.Reload.Interval < 0 {
+		v.addf("settings.reload.interval: must not be negative, got %s", s.Reload.Interval)
+	}
+
+	return v.problems
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := &Config{}
+	cfg.Gateway.TrustedProxies = []string{"10.0.0.1"}
+	cfg.RateLimit.Methods = Quotas{"CreateTweet": {PerUser: Limit{Rate: 10}}}
+	cfg.Settings.PageSize = maxPageSize + 1
+	cfg.Logger.Level = "loud"
+
+	problems := cfg.Validate()
+
+	for _, want := range []string{
+		"postgres.PostgresqlHost: required",
+		"postgres.PostgresqlPort: required",
+		"auth: one of secret or jwksFile is required",
+		"gateway.trustedProxies: invalid CIDR address: 10.0.0.1",
+		"rateLimit.methods.CreateTweet.perUser.period: must be positive when rate is set",
+		"idempotency.ttl: must be positive, got 0s",
+		"logger.level: ",
+		"settings.pageSize",
+	} {
+		if !containsPrefix(problems, want) {
+			t.Errorf("problem %q is missing", want)
+		}
+	}
+}
+
+func TestValidateExample(t *testing.T) {
+	cfg, err := Load("../cmd/config_example.yml")
+
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+
+	if problems := cfg.Validate(); len(problems) > 0 {
+		t.Errorf("Validate() = %q", problems)
+	}
+
+	cfg.Scheduler.RetryBackoff = -time.Second
+
+	if problems := cfg.Validate(); len(problems) != 1 {
+		t.Errorf("Validate() = %q, want one problem", problems)
+	}
+}
+
+func containsPrefix(problems []string, prefix string) bool {
+	for _, problem := range problems {
+		if strings.HasPrefix(problem, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,136 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: feed/feed.proto
+
+package feed
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Feed_StreamTweets_FullMethodName = "/feed.Feed/StreamTweets"
+)
+
+// FeedClient is the client API for Feed service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type FeedClient interface {
+	StreamTweets(ctx context.Context, in *StreamTweetsRequest, opts ...grpc.CallOption) (Feed_StreamTweetsClient, error)
+}
+
+type feedClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFeedClient(cc grpc.ClientConnInterface) FeedClient {
+	return &feedClient{cc}
+}
+
+func (c *feedClient) StreamTweets(ctx context.Context, in *StreamTweetsRequest, opts ...grpc.CallOption) (Feed_StreamTweetsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Feed_ServiceDesc.Streams[0], Feed_StreamTweets_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &feedStreamTweetsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Feed_StreamTweetsClient interface {
+	Recv() (*FeedEvent, error)
+	grpc.ClientStream
+}
+
+type feedStreamTweetsClient struct {
+	grpc.ClientStream
+}
+
+func (x *feedStreamTweetsClient) Recv() (*FeedEvent, error) {
+	m := new(FeedEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// FeedServer is the server API for Feed service.
+// All implementations must embed UnimplementedFeedServer
+// for forward compatibility
+type FeedServer interface {
+	StreamTweets(*StreamTweetsRequest, Feed_StreamTweetsServer) error
+	mustEmbedUnimplementedFeedServer()
+}
+
+// UnimplementedFeedServer must be embedded to have forward compatible implementations.
+type UnimplementedFeedServer struct {
+}
+
+func (UnimplementedFeedServer) StreamTweets(*StreamTweetsRequest, Feed_StreamTweetsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamTweets not implemented")
+}
+func (UnimplementedFeedServer) mustEmbedUnimplementedFeedServer() {}
+
+// UnsafeFeedServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FeedServer will
+// result in compilation errors.
+type UnsafeFeedServer interface {
+	mustEmbedUnimplementedFeedServer()
+}
+
+func RegisterFeedServer(s grpc.ServiceRegistrar, srv FeedServer) {
+	s.RegisterService(&Feed_ServiceDesc, srv)
+}
+
+func _Feed_StreamTweets_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamTweetsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FeedServer).StreamTweets(m, &feedStreamTweetsServer{stream})
+}
+
+type Feed_StreamTweetsServer interface {
+	Send(*FeedEvent) error
+	grpc.ServerStream
+}
+
+type feedStreamTweetsServer struct {
+	grpc.ServerStream
+}
+
+func (x *feedStreamTweetsServer) Send(m *FeedEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Feed_ServiceDesc is the grpc.ServiceDesc for Feed service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Feed_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "feed.Feed",
+	HandlerType: (*FeedServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamTweets",
+			Handler:       _Feed_StreamTweets_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "feed/feed.proto",
+}
@@ -0,0 +1,146 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: moderation/moderation.proto
+
+package moderation
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Moderation_HideTweet_FullMethodName   = "/moderation.Moderation/HideTweet"
+	Moderation_LockReplies_FullMethodName = "/moderation.Moderation/LockReplies"
+)
+
+// ModerationClient is the client API for Moderation service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ModerationClient interface {
+	HideTweet(ctx context.Context, in *HideTweetRequest, opts ...grpc.CallOption) (*ModerationResponse, error)
+	LockReplies(ctx context.Context, in *LockRepliesRequest, opts ...grpc.CallOption) (*ModerationResponse, error)
+}
+
+type moderationClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewModerationClient(cc grpc.ClientConnInterface) ModerationClient {
+	return &moderationClient{cc}
+}
+
+func (c *moderationClient) HideTweet(ctx context.Context, in *HideTweetRequest, opts ...grpc.CallOption) (*ModerationResponse, error) {
+	out := new(ModerationResponse)
+	err := c.cc.Invoke(ctx, Moderation_HideTweet_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *moderationClient) LockReplies(ctx context.Context, in *LockRepliesRequest, opts ...grpc.CallOption) (*ModerationResponse, error) {
+	out := new(ModerationResponse)
+	err := c.cc.Invoke(ctx, Moderation_LockReplies_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ModerationServer is the server API for Moderation service.
+// All implementations must embed UnimplementedModerationServer
+// for forward compatibility
+type ModerationServer interface {
+	HideTweet(context.Context, *HideTweetRequest) (*ModerationResponse, error)
+	LockReplies(context.Context, *LockRepliesRequest) (*ModerationResponse, error)
+	mustEmbedUnimplementedModerationServer()
+}
+
+// UnimplementedModerationServer must be embedded to have forward compatible implementations.
+type UnimplementedModerationServer struct {
+}
+
+func (UnimplementedModerationServer) HideTweet(context.Context, *HideTweetRequest) (*ModerationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HideTweet not implemented")
+}
+func (UnimplementedModerationServer) LockReplies(context.Context, *LockRepliesRequest) (*ModerationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LockReplies not implemented")
+}
+func (UnimplementedModerationServer) mustEmbedUnimplementedModerationServer() {}
+
+// UnsafeModerationServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ModerationServer will
+// result in compilation errors.
+type UnsafeModerationServer interface {
+	mustEmbedUnimplementedModerationServer()
+}
+
+func RegisterModerationServer(s grpc.ServiceRegistrar, srv ModerationServer) {
+	s.RegisterService(&Moderation_ServiceDesc, srv)
+}
+
+func _Moderation_HideTweet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HideTweetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ModerationServer).HideTweet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Moderation_HideTweet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ModerationServer).HideTweet(ctx, req.(*HideTweetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Moderation_LockReplies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LockRepliesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ModerationServer).LockReplies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Moderation_LockReplies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ModerationServer).LockReplies(ctx, req.(*LockRepliesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Moderation_ServiceDesc is the grpc.ServiceDesc for Moderation service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Moderation_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "moderation.Moderation",
+	HandlerType: (*ModerationServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "HideTweet",
+			Handler:    _Moderation_HideTweet_Handler,
+		},
+		{
+			MethodName: "LockReplies",
+			Handler:    _Moderation_LockReplies_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "moderation/moderation.proto",
+}
@@ -0,0 +1,220 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: schedule/schedule.proto
+
+package schedule
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Schedule_CreateScheduledTweet_FullMethodName = "/schedule.Schedule/CreateScheduledTweet"
+	Schedule_ListScheduledTweets_FullMethodName  = "/schedule.Schedule/ListScheduledTweets"
+	Schedule_UpdateScheduledTweet_FullMethodName = "/schedule.Schedule/UpdateScheduledTweet"
+	Schedule_CancelScheduledTweet_FullMethodName = "/schedule.Schedule/CancelScheduledTweet"
+)
+
+// ScheduleClient is the client API for Schedule service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ScheduleClient interface {
+	CreateScheduledTweet(ctx context.Context, in *CreateScheduledTweetRequest, opts ...grpc.CallOption) (*ScheduledTweet, error)
+	ListScheduledTweets(ctx context.Context, in *ListScheduledTweetsRequest, opts ...grpc.CallOption) (*ListScheduledTweetsResponse, error)
+	UpdateScheduledTweet(ctx context.Context, in *UpdateScheduledTweetRequest, opts ...grpc.CallOption) (*ScheduledTweet, error)
+	CancelScheduledTweet(ctx context.Context, in *CancelScheduledTweetRequest, opts ...grpc.CallOption) (*ScheduledTweet, error)
+}
+
+type scheduleClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewScheduleClient(cc grpc.ClientConnInterface) ScheduleClient {
+	return &scheduleClient{cc}
+}
+
+func (c *scheduleClient) CreateScheduledTweet(ctx context.Context, in *CreateScheduledTweetRequest, opts ...grpc.CallOption) (*ScheduledTweet, error) {
+	out := new(ScheduledTweet)
+	err := c.cc.Invoke(ctx, Schedule_CreateScheduledTweet_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *scheduleClient) ListScheduledTweets(ctx context.Context, in *ListScheduledTweetsRequest, opts ...grpc.CallOption) (*ListScheduledTweetsResponse, error) {
+	out := new(ListScheduledTweetsResponse)
+	err := c.cc.Invoke(ctx, Schedule_ListScheduledTweets_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *scheduleClient) UpdateScheduledTweet(ctx context.Context, in *UpdateScheduledTweetRequest, opts ...grpc.CallOption) (*ScheduledTweet, error) {
+	out := new(ScheduledTweet)
+	err := c.cc.Invoke(ctx, Schedule_UpdateScheduledTweet_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *scheduleClient) CancelScheduledTweet(ctx context.Context, in *CancelScheduledTweetRequest, opts ...grpc.CallOption) (*ScheduledTweet, error) {
+	out := new(ScheduledTweet)
+	err := c.cc.Invoke(ctx, Schedule_CancelScheduledTweet_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ScheduleServer is the server API for Schedule service.
+// All implementations must embed UnimplementedScheduleServer
+// for forward compatibility
+type ScheduleServer interface {
+	CreateScheduledTweet(context.Context, *CreateScheduledTweetRequest) (*ScheduledTweet, error)
+	ListScheduledTweets(context.Context, *ListScheduledTweetsRequest) (*ListScheduledTweetsResponse, error)
+	UpdateScheduledTweet(context.Context, *UpdateScheduledTweetRequest) (*ScheduledTweet, error)
+	CancelScheduledTweet(context.Context, *CancelScheduledTweetRequest) (*ScheduledTweet, error)
+	mustEmbedUnimplementedScheduleServer()
+}
+
+// UnimplementedScheduleServer must be embedded to have forward compatible implementations.
+type UnimplementedScheduleServer struct {
+}
+
+func (UnimplementedScheduleServer) CreateScheduledTweet(context.Context, *CreateScheduledTweetRequest) (*ScheduledTweet, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateScheduledTweet not implemented")
+}
+func (UnimplementedScheduleServer) ListScheduledTweets(context.Context, *ListScheduledTweetsRequest) (*ListScheduledTweetsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListScheduledTweets not implemented")
+}
+func (UnimplementedScheduleServer) UpdateScheduledTweet(context.Context, *UpdateScheduledTweetRequest) (*ScheduledTweet, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateScheduledTweet not implemented")
+}
+func (UnimplementedScheduleServer) CancelScheduledTweet(context.Context, *CancelScheduledTweetRequest) (*ScheduledTweet, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelScheduledTweet not implemented")
+}
+func (UnimplementedScheduleServer) mustEmbedUnimplementedScheduleServer() {}
+
+// UnsafeScheduleServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ScheduleServer will
+// result in compilation errors.
+type UnsafeScheduleServer interface {
+	mustEmbedUnimplementedScheduleServer()
+}
+
+func RegisterScheduleServer(s grpc.ServiceRegistrar, srv ScheduleServer) {
+	s.RegisterService(&Schedule_ServiceDesc, srv)
+}
+
+func _Schedule_CreateScheduledTweet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateScheduledTweetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScheduleServer).CreateScheduledTweet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Schedule_CreateScheduledTweet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScheduleServer).CreateScheduledTweet(ctx, req.(*CreateScheduledTweetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Schedule_ListScheduledTweets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListScheduledTweetsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScheduleServer).ListScheduledTweets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Schedule_ListScheduledTweets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScheduleServer).ListScheduledTweets(ctx, req.(*ListScheduledTweetsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Schedule_UpdateScheduledTweet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateScheduledTweetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScheduleServer).UpdateScheduledTweet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Schedule_UpdateScheduledTweet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScheduleServer).UpdateScheduledTweet(ctx, req.(*UpdateScheduledTweetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Schedule_CancelScheduledTweet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelScheduledTweetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ScheduleServer).CancelScheduledTweet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Schedule_CancelScheduledTweet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ScheduleServer).CancelScheduledTweet(ctx, req.(*CancelScheduledTweetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Schedule_ServiceDesc is the grpc.ServiceDesc for Schedule service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Schedule_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "schedule.Schedule",
+	HandlerType: (*ScheduleServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateScheduledTweet",
+			Handler:    _Schedule_CreateScheduledTweet_Handler,
+		},
+		{
+			MethodName: "ListScheduledTweets",
+			Handler:    _Schedule_ListScheduledTweets_Handler,
+		},
+		{
+			MethodName: "UpdateScheduledTweet",
+			Handler:    _Schedule_UpdateScheduledTweet_Handler,
+		},
+		{
+			MethodName: "CancelScheduledTweet",
+			Handler:    _Schedule_CancelScheduledTweet_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "schedule/schedule.proto",
+}
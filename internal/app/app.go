@@ -1,54 +1,136 @@
 package app
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
 	"github.com/Verce11o/yata-tweets/config"
+	feedPb "github.com/Verce11o/yata-tweets/gen/go/feed"
+	moderationPb "github.com/Verce11o/yata-tweets/gen/go/moderation"
+	schedulePb "github.com/Verce11o/yata-tweets/gen/go/schedule"
+	"github.com/Verce11o/yata-tweets/internal/handler/gateway"
 	tweetGrpc "github.com/Verce11o/yata-tweets/internal/handler/grpc"
+	"github.com/Verce11o/yata-tweets/internal/lib/auth"
+	"github.com/Verce11o/yata-tweets/internal/lib/healthcheck"
+	"github.com/Verce11o/yata-tweets/internal/lib/lifecycle"
 	"github.com/Verce11o/yata-tweets/internal/lib/logger"
-	"github.com/Verce11o/yata-tweets/internal/lib/notification/rabbitmq"
-	"github.com/Verce11o/yata-tweets/internal/metrics/trace"
-	"github.com/Verce11o/yata-tweets/internal/repository/minio"
+	"github.com/Verce11o/yata-tweets/internal/lib/ratelimit"
 	"github.com/Verce11o/yata-tweets/internal/repository/postgres"
-	"github.com/Verce11o/yata-tweets/internal/repository/redis"
 	"github.com/Verce11o/yata-tweets/internal/service"
+	amqp "github.com/rabbitmq/amqp091-go"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
-func Run() {
-	log := logger.NewLogger()
-	cfg := config.LoadConfig()
+// Run serves until SIGINT or SIGTERM. configPath is watched for changes of the runtime settings.
+func Run(cfg *config.Config, configPath string) {
+	deps := NewDeps(cfg, configPath)
 
-	tracer := trace.InitTracer("yata-tweets")
+	log, tracer, metrics := deps.Log, deps.Tracer, deps.Metrics
 
-	// Init repos
-	db := postgres.NewPostgres(cfg)
-	repo := postgres.NewTweetPostgres(db, tracer.Tracer)
+	settingsCtx, stopSettings := context.WithCancel(context.Background())
 
-	rdb := redis.NewRedis(cfg)
-	redisRepo := redis.NewTweetsRedis(rdb, tracer.Tracer)
+	go deps.Settings.Run(settingsCtx)
 
-	minioClient := minio.NewMinio(cfg)
-	minioRepo := minio.NewTweetMinio(minioClient, tracer.Tracer)
+	verifier, err := auth.NewVerifier(cfg.Auth)
 
-	// Init broker
+	if err != nil {
+		log.Fatalf("failed to init auth verifier: %v", err)
+	}
+
+	limiter := ratelimit.NewFallbackLimiter(ratelimit.NewRedisLimiter(deps.Redis), ratelimit.NewMemoryLimiter(), log)
+
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			otelgrpc.UnaryServerInterceptor(
+				otelgrpc.WithTracerProvider(tracer.Provider),
+				otelgrpc.WithPropagators(propagation.TraceContext{}),
+			),
+			metrics.UnaryServerInterceptor(),
+			logger.UnaryServerInterceptor(log),
+			auth.UnaryServerInterceptor(verifier),
+			ratelimit.UnaryServerInterceptor(limiter, deps.Settings, log),
+		),
+		grpc.ChainStreamInterceptor(
+			metrics.StreamServerInterceptor(),
+			logger.StreamServerInterceptor(log),
+			auth.StreamServerInterceptor(verifier),
+		),
+	)
+
+	amqpConn, tweetPublisher := deps.NewPublisher()
+	tweetService := service.NewTweetService(log, tracer.Tracer, metrics.InstrumentPublisher(tweetPublisher), deps.Tweets, deps.Cache, deps.Pages, deps.Storage, deps.Idempotency, cfg.Idempotency, deps.Feed, deps.Settings)
+	feedService := service.NewFeedService(log, tracer.Tracer, deps.Feed, cfg.Feed)
+	scheduleService := service.NewScheduleService(log, tracer.Tracer, tweetService, deps.Schedules, deps.Storage, deps.Settings, cfg.Scheduler)
 
-	s := grpc.NewServer(grpc.UnaryInterceptor(otelgrpc.UnaryServerInterceptor(
-		otelgrpc.WithTracerProvider(tracer.Provider),
-		otelgrpc.WithPropagators(propagation.TraceContext{}),
-	)))
+	feedCtx, stopFeed := context.WithCancel(context.Background())
 
-	amqpConn := rabbitmq.NewAmqpConnection(cfg.RabbitMQ)
-	tweetPublisher := rabbitmq.NewTweetPublisher(amqpConn, log, tracer.Tracer, cfg.RabbitMQ)
-	tweetService := service.NewTweetService(log, tracer.Tracer, tweetPublisher, repo, redisRepo, minioRepo)
+	go feedService.Run(feedCtx)
+
+	invalidationsCtx, stopInvalidations := context.WithCancel(context.Background())
+
+	if deps.LocalCache != nil {
+		go deps.LocalCache.Run(invalidationsCtx)
+	}
+
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	schedulerDone := make(chan struct{})
+
+	go func() {
+		defer close(schedulerDone)
+		scheduleService.Run(schedulerCtx)
+	}()
 
 	pb.RegisterTweetsServer(s, tweetGrpc.NewTweetGRPC(log, tracer.Tracer, tweetService))
+	moderationPb.RegisterModerationServer(s, tweetGrpc.NewModerationGRPC(log, tracer.Tracer, tweetService))
+	feedPb.RegisterFeedServer(s, tweetGrpc.NewFeedGRPC(log, tracer.Tracer, feedService))
+	schedulePb.RegisterScheduleServer(s, tweetGrpc.NewScheduleGRPC(log, tracer.Tracer, scheduleService))
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(s, healthServer)
+
+	checker := healthcheck.NewChecker(log, healthServer, cfg.Health,
+		pb.Tweets_ServiceDesc.ServiceName,
+		moderationPb.Moderation_ServiceDesc.ServiceName,
+		feedPb.Feed_ServiceDesc.ServiceName,
+		schedulePb.Schedule_ServiceDesc.ServiceName,
+	)
+	checker.Add("postgres", deps.DB.PingContext)
+	for i, replica := range deps.Cluster.Replicas() {
+		checker.Add(fmt.Sprintf("postgres replica %d", i), replica.PingContext)
+	}
+	checker.Add("redis", func(ctx context.Context) error {
+		return deps.Redis.Ping(ctx).Err()
+	})
+	checker.Add("rabbitmq", func(ctx context.Context) error {
+		if amqpConn.IsClosed() {
+			return amqp.ErrClosed
+		}
+		return nil
+	})
+	checker.Add("storage", deps.Storage.Ping)
+
+	checkerCtx, stopChecker := context.WithCancel(context.Background())
+
+	go checker.Run(checkerCtx)
+
+	if cfg.App.Reflection {
+		reflection.Register(s)
+	}
 
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.App.Port))
 
@@ -64,16 +146,199 @@ func Run() {
 
 	log.Info(fmt.Sprintf("server listening at %s", lis.Addr().String()))
 
-	defer log.Sync()
+	var (
+		httpServer    *http.Server
+		gatewayConn   *grpc.ClientConn
+		metricsServer *http.Server
+		adminServer   *http.Server
+	)
+
+	if cfg.Admin.Enabled {
+		mux := http.NewServeMux()
+		// GET returns the current level, PUT {"level":"debug"} changes it
+		mux.Handle("/log/level", deps.LogLevel)
+
+		adminServer = &http.Server{
+			Addr:              net.JoinHostPort(cfg.Admin.Host, cfg.Admin.Port),
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		go func() {
+			if err := adminServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Infof("error while listen admin: %s", err)
+			}
+		}()
+
+		log.Info(fmt.Sprintf("admin listening at %s", adminServer.Addr))
+	}
+
+	if cfg.Metrics.Prometheus.Enabled {
+		mux := http.NewServeMux()
+		mux.Handle(cfg.Metrics.Prometheus.Path, metrics.Handler())
+
+		metricsServer = &http.Server{
+			Addr:              fmt.Sprintf(":%s", cfg.Metrics.Prometheus.Port),
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		go func() {
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Infof("error while listen metrics: %s", err)
+			}
+		}()
+
+		log.Info(fmt.Sprintf("metrics listening at %s%s", metricsServer.Addr, cfg.Metrics.Prometheus.Path))
+	}
+
+	if cfg.Gateway.Enabled {
+		gatewayConn, err = grpc.Dial(
+			fmt.Sprintf("localhost:%s", cfg.App.Port),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor(
+				otelgrpc.WithTracerProvider(tracer.Provider),
+				otelgrpc.WithPropagators(propagation.TraceContext{}),
+			)),
+		)
+
+		if err != nil {
+			log.Fatalf("failed to dial grpc server for gateway: %v", err)
+		}
+
+		gw, err := gateway.NewGateway(log, gatewayConn, cfg.Gateway)
+
+		if err != nil {
+			log.Fatalf("failed to init gateway: %v", err)
+		}
+
+		httpServer = &http.Server{
+			Addr:              fmt.Sprintf(":%s", cfg.Gateway.Port),
+			Handler:           gw.Handler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		go func() {
+			if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Infof("error while listen gateway: %s", err)
+			}
+		}()
+
+		log.Info(fmt.Sprintf("gateway listening at %s", httpServer.Addr))
+	}
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	s.GracefulStop()
+	log.Info("shutting down")
+
+	lc := lifecycle.NewManager(log, cfg.App.ShutdownPhaseTimeout)
+
+	// let load balancers and probes stop routing traffic here before anything is torn down
+	lc.Add(lifecycle.PhaseStopTraffic, "health checker", func(context.Context) error {
+		checker.Shutdown()
+		stopChecker()
+		return nil
+	})
+	lc.Add(lifecycle.PhaseStopTraffic, "settings reload", func(context.Context) error {
+		stopSettings()
+		return nil
+	})
+
+	if httpServer != nil {
+		lc.Add(lifecycle.PhaseDrain, "gateway", func(ctx context.Context) error {
+			if err := httpServer.Shutdown(ctx); err != nil {
+				return errors.Join(err, httpServer.Close())
+			}
+			return nil
+		})
+	}
+
+	// streams never end on their own, stop them before draining the server
+	lc.Add(lifecycle.PhaseDrain, "feed", func(context.Context) error {
+		stopFeed()
+		return nil
+	})
+	lc.Add(lifecycle.PhaseDrain, "grpc server", func(ctx context.Context) error {
+		done := make(chan struct{})
+
+		go func() {
+			s.GracefulStop()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			s.Stop()
+			return fmt.Errorf("in-flight rpcs cancelled: %w", ctx.Err())
+		}
+	})
+
+	// other replicas keep changing tweets until the end, so invalidations are followed while draining
+	lc.Add(lifecycle.PhaseDrain, "local cache invalidations", func(context.Context) error {
+		stopInvalidations()
+		return nil
+	})
+
+	// a tweet being published is finished, so that its announcement goes out before the publisher closes
+	lc.Add(lifecycle.PhaseDrain, "scheduler", func(ctx context.Context) error {
+		stopScheduler()
+
+		select {
+		case <-schedulerDone:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	lc.Add(lifecycle.PhaseFlush, "tweet publisher", tweetPublisher.Close)
+	lc.Add(lifecycle.PhaseFlush, "tracer provider", tracer.Provider.Shutdown)
+
+	if gatewayConn != nil {
+		lc.Close("gateway connection", gatewayConn.Close)
+	}
+	lc.Close("rabbitmq", amqpConn.Close)
+	lc.Close("redis", deps.Redis.Close)
+	lc.Close("postgres replicas", deps.Cluster.Close)
+	lc.Close("postgres", deps.DB.Close)
+
+	// metrics stay up until the end, so the shutdown itself can be scraped
+	if metricsServer != nil {
+		lc.Close("metrics server", metricsServer.Close)
+	}
+
+	if adminServer != nil {
+		lc.Close("admin server", adminServer.Close)
+	}
+
+	if err := lc.Shutdown(context.Background()); err != nil {
+		log.Errorf("shutdown finished with errors: %v", err)
+	} else {
+		log.Info("shutdown complete")
+	}
+
+	_ = log.Sync()
+}
+
+// migrate applies pending migrations before anything uses the database.
+func migrate(log *zap.SugaredLogger, db *sql.DB) {
+	migrator, err := postgres.NewMigrator(db)
 
-	if err := db.Close(); err != nil {
-		log.Infof("error while close db: %s", err)
+	if err != nil {
+		log.Fatalf("cannot init migrations: %v", err)
 	}
 
+	results, err := migrator.Up(context.Background())
+
+	if err != nil {
+		log.Fatalf("cannot apply migrations: %v", err)
+	}
+
+	for _, r := range results {
+		log.Infow("applied migration", "migration", r.Source.Path, "duration", r.Duration)
+	}
 }
@@ -0,0 +1,158 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Verce11o/yata-tweets/config"
+	"github.com/Verce11o/yata-tweets/internal/lib/codec"
+	"github.com/Verce11o/yata-tweets/internal/lib/logger"
+	"github.com/Verce11o/yata-tweets/internal/lib/notification/rabbitmq"
+	"github.com/Verce11o/yata-tweets/internal/lib/settings"
+	"github.com/Verce11o/yata-tweets/internal/metrics/metric"
+	"github.com/Verce11o/yata-tweets/internal/metrics/trace"
+	"github.com/Verce11o/yata-tweets/internal/repository"
+	"github.com/Verce11o/yata-tweets/internal/repository/postgres"
+	"github.com/Verce11o/yata-tweets/internal/repository/redis"
+	"github.com/Verce11o/yata-tweets/internal/repository/storage"
+	"github.com/Verce11o/yata-tweets/internal/service"
+	"github.com/jmoiron/sqlx"
+	amqp "github.com/rabbitmq/amqp091-go"
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	closeTimeout = 10 * time.Second
+)
+
+// Deps is the wiring shared by the server and the operational commands:
+// logging, tracing, metrics, the database and Redis clients and the repositories built on them.
+type Deps struct {
+	Config   *config.Config
+	Log      *zap.SugaredLogger
+	LogLevel zap.AtomicLevel
+	Tracer   *trace.Tracing
+	Metrics  *metric.Metrics
+	Settings *settings.Store
+
+	DB      *sqlx.DB
+	Cluster *postgres.Cluster
+	Redis   goredis.UniversalClient
+
+	Tweets repository.PostgresRepository
+	Cache  repository.RedisRepository
+	// LocalCache is the in-memory tier of Cache, nil when localCache.size is zero.
+	LocalCache  *redis.TweetsLocal
+	Pages       repository.TweetPagesRepository
+	Idempotency repository.IdempotencyRepository
+	Feed        repository.FeedRepository
+	Schedules   repository.ScheduleRepository
+	Storage     repository.StorageRepository
+}
+
+// NewDeps connects to Postgres, Redis and image storage. configPath is where cfg was loaded from.
+func NewDeps(cfg *config.Config, configPath string) *Deps {
+	log, logLevel := logger.NewLogger(cfg.Logger)
+
+	tracer := trace.InitTracer(cfg.Metrics.Tracing)
+
+	metrics := metric.NewMetrics()
+
+	rdb := redis.NewRedis(cfg)
+
+	settingsStore := settings.NewStore(log, cfg, configPath, rdb)
+
+	db := postgres.NewPostgres(cfg)
+
+	if cfg.Postgres.AutoMigrate {
+		migrate(log, db.DB)
+	}
+
+	metrics.RegisterDB(db.DB, cfg.Postgres.Name)
+
+	replicas := postgres.NewReplicas(cfg)
+
+	for i, replica := range replicas {
+		metrics.RegisterDB(replica.DB, fmt.Sprintf("%s-replica-%d", cfg.Postgres.Name, i))
+	}
+
+	recentWrites := redis.NewRecentWritesRedis(rdb, tracer.Tracer, cfg.Postgres.Replicas.ReadYourWritesWindow)
+	cluster := postgres.NewCluster(log, db, replicas, recentWrites, cfg.Postgres.StatementCacheSize)
+
+	cacheCodec, err := codec.ByName(cfg.Redis.CacheCodec)
+
+	if err != nil {
+		log.Fatalf("failed to init cache codec: %v", err)
+	}
+
+	envelope := codec.NewEnvelope(cacheCodec, cfg.Redis.CacheCompressAbove)
+
+	var cache repository.RedisRepository = metrics.InstrumentCache(redis.NewTweetsRedis(rdb, tracer.Tracer, settingsStore, envelope))
+	var localCache *redis.TweetsLocal
+
+	if cfg.LocalCache.Size > 0 {
+		localCache = redis.NewTweetsLocal(cache, rdb, tracer.Tracer, log, cfg.LocalCache)
+		metrics.RegisterLocalCache(localCache.Stats)
+		cache = localCache
+	}
+
+	storageRepo, err := storage.NewStorage(context.Background(), cfg, tracer.Tracer)
+
+	if err != nil {
+		log.Fatalf("failed to init storage: %v", err)
+	}
+
+	// scheduled tweets are the outbox of the service, the only events stored to be sent later
+	schedules := postgres.NewSchedulePostgres(db, tracer.Tracer)
+	metrics.RegisterOutbox(schedules.GetPublishLag)
+
+	return &Deps{
+		Config:   cfg,
+		Log:      log,
+		LogLevel: logLevel,
+		Tracer:   tracer,
+		Metrics:  metrics,
+		Settings: settingsStore,
+
+		DB:      db,
+		Cluster: cluster,
+		Redis:   rdb,
+
+		Tweets:      metrics.InstrumentTweets(postgres.NewTweetPostgres(cluster, tracer.Tracer, settingsStore)),
+		Cache:       cache,
+		LocalCache:  localCache,
+		Pages:       redis.NewTweetPagesRedis(rdb, tracer.Tracer),
+		Idempotency: redis.NewIdempotencyRedis(rdb, tracer.Tracer),
+		Feed:        redis.NewFeedRedis(rdb, tracer.Tracer, log, cfg.Feed.MaxLen),
+		Schedules:   metrics.InstrumentSchedules(schedules),
+		Storage:     metrics.InstrumentStorage(storageRepo),
+	}
+}
+
+// NewPublisher connects to RabbitMQ. The caller closes the publisher before the connection.
+func (d *Deps) NewPublisher() (*amqp.Connection, *rabbitmq.TweetPublisher) {
+	amqpConn := rabbitmq.NewAmqpConnection(d.Config.RabbitMQ)
+	return amqpConn, rabbitmq.NewTweetPublisher(amqpConn, d.Log, d.Tracer.Tracer, d.Config.RabbitMQ)
+}
+
+// NewOpsService builds the service behind the operational commands.
+func (d *Deps) NewOpsService(publisher *rabbitmq.TweetPublisher) *service.OpsService {
+	if publisher == nil {
+		return service.NewOpsService(d.Log, d.Tracer.Tracer, nil, d.Tweets, d.Cache, d.Schedules, d.Storage)
+	}
+
+	return service.NewOpsService(d.Log, d.Tracer.Tracer, d.Metrics.InstrumentPublisher(publisher), d.Tweets, d.Cache, d.Schedules, d.Storage)
+}
+
+// Close flushes traces and closes the clients. The server shuts down through its lifecycle instead.
+func (d *Deps) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+
+	err := errors.Join(d.Tracer.Provider.Shutdown(ctx), d.Redis.Close(), d.Cluster.Close(), d.DB.Close())
+	_ = d.Log.Sync()
+
+	return err
+}
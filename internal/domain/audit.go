@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// AuditRecord describes a privileged action performed by a moderator or an admin.
+type AuditRecord struct {
+	AuditID    uuid.UUID `json:"audit_id" db:"audit_id"`
+	ActorID    string    `json:"actor_id" db:"actor_id"`
+	ActorRoles string    `json:"actor_roles" db:"actor_roles"`
+	Action     string    `json:"action" db:"action"`
+	TweetID    string    `json:"tweet_id" db:"tweet_id"`
+	OwnerID    string    `json:"owner_id" db:"owner_id"`
+	Reason     string    `json:"reason" db:"reason"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
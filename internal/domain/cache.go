@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// CachedTweet is an entry of the tweet cache. A nil Tweet records that the tweet does not exist.
+type CachedTweet struct {
+	Tweet *Tweet `json:"tweet"`
+	// Delta is how long loading the tweet from the database took.
+	Delta     time.Duration `json:"delta"`
+	ExpiresAt time.Time     `json:"expiresAt"`
+}
+
+// TweetPageKey identifies a cached page of GetAllTweets. After is the position the cursor points at,
+// zero for the first page.
+type TweetPageKey struct {
+	Filter string
+	Size   int
+	Cursor string
+	After  time.Time
+}
+
+// TweetPage is a cached page of GetAllTweets. Only the IDs are stored, the tweets are read through the tweet cache.
+type TweetPage struct {
+	TweetIDs   []string `json:"tweet_ids"`
+	NextCursor string   `json:"next_cursor"`
+	// Last is the creation time of the last tweet. A page that is not Full also covers every later tweet.
+	Last time.Time `json:"last"`
+	Full bool      `json:"full"`
+}
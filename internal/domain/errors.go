@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type ErrorKind int
+
+const (
+	KindInternal ErrorKind = iota
+	KindNotFound
+	KindAlreadyExists
+	KindInvalidArgument
+	KindFailedPrecondition
+	KindPermissionDenied
+	KindUnauthenticated
+	KindAborted
+	KindUnavailable
+	KindDeadlineExceeded
+	KindResourceExhausted
+)
+
+// Error is a classified failure. Message and the resource fields are safe to show to clients,
+// the wrapped cause is only meant for logs.
+type Error struct {
+	Kind       ErrorKind
+	Reason     string
+	Message    string
+	Resource   string
+	ResourceID string
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %v", e.Message, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether the same request may succeed later.
+func (e *Error) Retryable() bool {
+	return e.Kind == KindAborted || e.Kind == KindUnavailable || e.Kind == KindDeadlineExceeded
+}
+
+// IsKind reports whether err is or wraps an *Error of the given kind.
+func IsKind(err error, kind ErrorKind) bool {
+	var domainErr *Error
+	return errors.As(err, &domainErr) && domainErr.Kind == kind
+}
+
+func NotFound(resource string, id string, err error) *Error {
+	return &Error{
+		Kind:       KindNotFound,
+		Reason:     reason(resource, "NOT_FOUND"),
+		Message:    fmt.Sprintf("%s not found", resource),
+		Resource:   resource,
+		ResourceID: id,
+		Err:        err,
+	}
+}
+
+func AlreadyExists(resource string, id string, err error) *Error {
+	return &Error{
+		Kind:       KindAlreadyExists,
+		Reason:     reason(resource, "ALREADY_EXISTS"),
+		Message:    fmt.Sprintf("%s already exists", resource),
+		Resource:   resource,
+		ResourceID: id,
+		Err:        err,
+	}
+}
+
+func InvalidArgument(resource string, message string, err error) *Error {
+	return &Error{
+		Kind:     KindInvalidArgument,
+		Reason:   reason(resource, "INVALID_ARGUMENT"),
+		Message:  message,
+		Resource: resource,
+		Err:      err,
+	}
+}
+
+func FailedPrecondition(resource string, id string, message string, err error) *Error {
+	return &Error{
+		Kind:       KindFailedPrecondition,
+		Reason:     reason(resource, "FAILED_PRECONDITION"),
+		Message:    message,
+		Resource:   resource,
+		ResourceID: id,
+		Err:        err,
+	}
+}
+
+// Conflict is a transient concurrency failure such as a serialization failure or a deadlock.
+func Conflict(resource string, retryAfter time.Duration, err error) *Error {
+	return &Error{
+		Kind:       KindAborted,
+		Reason:     reason(resource, "CONFLICT"),
+		Message:    "concurrent modification, retry the request",
+		Resource:   resource,
+		RetryAfter: retryAfter,
+		Err:        err,
+	}
+}
+
+func Unavailable(dependency string, retryAfter time.Duration, err error) *Error {
+	return &Error{
+		Kind:       KindUnavailable,
+		Reason:     reason(dependency, "UNAVAILABLE"),
+		Message:    "service temporarily unavailable",
+		RetryAfter: retryAfter,
+		Err:        err,
+	}
+}
+
+func Timeout(dependency string, err error) *Error {
+	return &Error{
+		Kind:    KindDeadlineExceeded,
+		Reason:  reason(dependency, "TIMEOUT"),
+		Message: "request timed out",
+		Err:     err,
+	}
+}
+
+func Internal(err error) *Error {
+	return &Error{
+		Kind:    KindInternal,
+		Reason:  "INTERNAL",
+		Message: "internal error",
+		Err:     err,
+	}
+}
+
+func reason(resource string, suffix string) string {
+	if resource == "" {
+		return suffix
+	}
+	return strings.ToUpper(strings.ReplaceAll(resource, " ", "_")) + "_" + suffix
+}
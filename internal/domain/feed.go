@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+type FeedEventType string
+
+const (
+	FeedEventCreated   FeedEventType = "created"
+	FeedEventUpdated   FeedEventType = "updated"
+	FeedEventDeleted   FeedEventType = "deleted"
+	FeedEventHeartbeat FeedEventType = "heartbeat"
+)
+
+// hashtags are letters, digits and underscores in any script, like the feed request hashtag is validated
+var hashtagRegexp = regexp.MustCompile(`#([\p{L}\p{N}_]+)`)
+
+// FeedEvent is a change of a tweet pushed to live feed subscribers.
+// ID is assigned by the feed log and doubles as the resume cursor.
+type FeedEvent struct {
+	ID         string        `json:"-"`
+	Type       FeedEventType `json:"type"`
+	Tweet      Tweet         `json:"tweet"`
+	OccurredAt time.Time     `json:"occurred_at"`
+}
+
+// FeedFilter selects events by tweet author and hashtag. The zero value matches every event.
+type FeedFilter struct {
+	AuthorIDs []string
+	Hashtag   string
+}
+
+func (f FeedFilter) Match(event *FeedEvent) bool {
+	if len(f.AuthorIDs) > 0 {
+		found := false
+		for _, id := range f.AuthorIDs {
+			if id == event.Tweet.UserID.String() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if f.Hashtag != "" {
+		for _, match := range hashtagRegexp.FindAllStringSubmatch(event.Tweet.Text, -1) {
+			if strings.EqualFold(match[1], f.Hashtag) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
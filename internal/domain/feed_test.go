@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"testing"
+)
+
+func TestFeedFilterMatch(t *testing.T) {
+	author := uuid.New()
+	other := uuid.New()
+
+	tests := []struct {
+		name   string
+		filter FeedFilter
+		userID uuid.UUID
+		text   string
+		want   bool
+	}{
+		{"zero value", FeedFilter{}, author, "hello", true},
+		{"author", FeedFilter{AuthorIDs: []string{other.String(), author.String()}}, author, "hello", true},
+		{"other author", FeedFilter{AuthorIDs: []string{other.String()}}, author, "hello", false},
+		{"hashtag", FeedFilter{Hashtag: "golang"}, author, "learning #golang today", true},
+		{"hashtag case", FeedFilter{Hashtag: "GoLang"}, author, "#golang", true},
+		{"hashtag prefix", FeedFilter{Hashtag: "go"}, author, "#golang", false},
+		{"missing hashtag", FeedFilter{Hashtag: "golang"}, author, "golang without a hash", false},
+		{"cyrillic hashtag", FeedFilter{Hashtag: "привет"}, author, "всем #привет!", true},
+		{"japanese hashtag", FeedFilter{Hashtag: "東京"}, author, "#東京 2026", true},
+		{"digits and underscores", FeedFilter{Hashtag: "go_1_23"}, author, "#go_1_23 is out", true},
+		{"author and hashtag", FeedFilter{AuthorIDs: []string{other.String()}, Hashtag: "golang"}, author, "#golang", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := &FeedEvent{Type: FeedEventCreated, Tweet: Tweet{UserID: tt.userID, Text: tt.text}}
+
+			if got := tt.filter.Match(event); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
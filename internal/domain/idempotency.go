@@ -0,0 +1,7 @@
+package domain
+
+// IdempotencyRecord is the stored outcome of a request made with an idempotency key.
+type IdempotencyRecord struct {
+	RequestHash string `json:"request_hash"`
+	TweetID     string `json:"tweet_id"`
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type ScheduleStatus string
+
+const (
+	ScheduleStatusPending   ScheduleStatus = "pending"
+	ScheduleStatusPublished ScheduleStatus = "published"
+	ScheduleStatusCancelled ScheduleStatus = "cancelled"
+	ScheduleStatusFailed    ScheduleStatus = "failed"
+)
+
+// ScheduledTweet is a tweet to be published at PublishAt. Its image is uploaded when it is scheduled.
+type ScheduledTweet struct {
+	ScheduleID uuid.UUID      `json:"schedule_id" db:"schedule_id"`
+	UserID     uuid.UUID      `json:"user_id" db:"user_id"`
+	Text       string         `json:"text" db:"text"`
+	ImageName  string         `json:"image" db:"image_name"`
+	PublishAt  time.Time      `json:"publish_at" db:"publish_at"`
+	TimeZone   string         `json:"time_zone" db:"time_zone"`
+	Status     ScheduleStatus `json:"status" db:"status"`
+	// TweetID and PublishedAt are set once the tweet is published.
+	TweetID     *uuid.UUID `json:"tweet_id" db:"tweet_id"`
+	PublishedAt *time.Time `json:"published_at" db:"published_at"`
+	// Attempts counts failed attempts to publish, LastError is the latest failure.
+	Attempts  int    `json:"attempts" db:"attempts"`
+	LastError string `json:"last_error" db:"last_error"`
+	// NextAttemptAt puts off the next attempt after a failure.
+	NextAttemptAt *time.Time `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// LocalPublishAt is PublishAt in the time zone it was scheduled in.
+func (s *ScheduledTweet) LocalPublishAt() time.Time {
+	location, err := time.LoadLocation(s.TimeZone)
+
+	if err != nil {
+		return s.PublishAt.UTC()
+	}
+
+	return s.PublishAt.In(location)
+}
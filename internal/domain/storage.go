@@ -0,0 +1,12 @@
+package domain
+
+import (
+	"time"
+)
+
+// StoredFile is an object in image storage.
+type StoredFile struct {
+	Name       string
+	Size       int64
+	ModifiedAt time.Time
+}
@@ -10,15 +10,37 @@ const (
 )
 
 type Tweet struct {
-	TweetID   uuid.UUID `json:"tweet_id" db:"tweet_id"`
-	UserID    uuid.UUID `json:"user_id" db:"user_id"`
-	Text      string    `json:"text" db:"text"`
-	ImageName string    `json:"image" db:"image_name"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	TweetID       uuid.UUID `json:"tweet_id" db:"tweet_id"`
+	UserID        uuid.UUID `json:"user_id" db:"user_id"`
+	Text          string    `json:"text" db:"text"`
+	ImageName     string    `json:"image" db:"image_name"`
+	Hidden        bool      `json:"hidden" db:"hidden"`
+	RepliesLocked bool      `json:"replies_locked" db:"replies_locked"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
 }
 
 type SendNewTweetNotification struct {
 	SenderID string `json:"sender_id"`
 	Type     string `json:"type"`
 }
+
+// TweetFilter selects tweets for batch operations. Zero fields match every tweet, hidden ones included.
+type TweetFilter struct {
+	UserID       string
+	CreatedSince time.Time
+}
+
+// UserExport is everything stored about the tweets of a user.
+type UserExport struct {
+	UserID     string         `json:"user_id"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Tweets     []*Tweet       `json:"tweets"`
+	Images     []*ExportImage `json:"images,omitempty"`
+}
+
+type ExportImage struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
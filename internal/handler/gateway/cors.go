@@ -0,0 +1,62 @@
+package gateway
+
+import (
+	"github.com/Verce11o/yata-tweets/config"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const anyOrigin = "*"
+
+// cors answers preflight requests and sets CORS headers for allowed origins.
+// Requests from other origins are passed through without CORS headers, so browsers reject them.
+func cors(cfg config.CORS, methods string, next http.Handler) http.Handler {
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+
+		if origin == "" || !originAllowed(cfg.AllowedOrigins, origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Add("Vary", "Origin")
+
+		// a wildcard cannot be combined with credentials, so the origin is echoed instead
+		if contains(cfg.AllowedOrigins, anyOrigin) && !cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Origin", anyOrigin)
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+
+		if cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", methods)
+			h.Set("Access-Control-Allow-Headers", headers)
+			h.Set("Access-Control-Max-Age", maxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		h.Set("Access-Control-Expose-Headers", "Retry-After")
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func originAllowed(allowed []string, origin string) bool {
+	for _, o := range allowed {
+		if o == anyOrigin || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,305 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
+	"github.com/Verce11o/yata-tweets/config"
+	"github.com/Verce11o/yata-tweets/internal/lib/idempotency"
+	"github.com/Verce11o/yata-tweets/internal/lib/logger"
+	"github.com/Verce11o/yata-tweets/internal/lib/ratelimit"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const openAPIPath = "/openapi.json"
+
+// call invokes a single Tweets RPC for an HTTP request.
+type call func(ctx context.Context, r *http.Request, inbound runtime.Marshaler, params map[string]string, opts ...grpc.CallOption) (proto.Message, error)
+
+type route struct {
+	method  string
+	pattern string
+	rpc     string
+	body    bool
+	call    call
+}
+
+// Gateway exposes the Tweets service over HTTP/JSON by forwarding requests to the gRPC server.
+type Gateway struct {
+	log            *zap.SugaredLogger
+	client         pb.TweetsClient
+	mux            *runtime.ServeMux
+	cfg            config.Gateway
+	routes         []route
+	openAPI        []byte
+	allowedMethods string
+	trustedProxies []*net.IPNet
+}
+
+func NewGateway(log *zap.SugaredLogger, conn grpc.ClientConnInterface, cfg config.Gateway) (*Gateway, error) {
+	g := &Gateway{
+		log:    log,
+		client: pb.NewTweetsClient(conn),
+		cfg:    cfg,
+		mux: runtime.NewServeMux(
+			runtime.WithIncomingHeaderMatcher(headerMatcher),
+			runtime.WithErrorHandler(errorHandler),
+			runtime.WithForwardResponseOption(setCreatedStatus),
+		),
+	}
+
+	for _, cidr := range cfg.TrustedProxies {
+		_, proxy, err := net.ParseCIDR(cidr)
+
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse trusted proxy: %w", err)
+		}
+
+		g.trustedProxies = append(g.trustedProxies, proxy)
+	}
+
+	g.routes = []route{
+		{method: http.MethodPost, pattern: "/v1/tweets", rpc: "CreateTweet", body: true, call: g.createTweet},
+		{method: http.MethodGet, pattern: "/v1/tweets", rpc: "GetAllTweets", call: g.getAllTweets},
+		{method: http.MethodGet, pattern: "/v1/tweets/{tweet_id}", rpc: "GetTweet", call: g.getTweet},
+		{method: http.MethodPatch, pattern: "/v1/tweets/{tweet_id}", rpc: "UpdateTweet", body: true, call: g.updateTweet},
+		{method: http.MethodDelete, pattern: "/v1/tweets/{tweet_id}", rpc: "DeleteTweet", call: g.deleteTweet},
+	}
+
+	methods := []string{http.MethodOptions}
+
+	for _, rt := range g.routes {
+		if err := g.mux.HandlePath(rt.method, rt.pattern, g.handle(rt)); err != nil {
+			return nil, fmt.Errorf("cannot register %s %s: %w", rt.method, rt.pattern, err)
+		}
+
+		if !contains(methods, rt.method) {
+			methods = append(methods, rt.method)
+		}
+	}
+
+	g.allowedMethods = strings.Join(methods, ", ")
+
+	spec, err := buildOpenAPI(g.routes)
+
+	if err != nil {
+		return nil, fmt.Errorf("cannot build openapi spec: %w", err)
+	}
+
+	g.openAPI = spec
+
+	if err := g.mux.HandlePath(http.MethodGet, openAPIPath, g.serveOpenAPI); err != nil {
+		return nil, fmt.Errorf("cannot register %s: %w", openAPIPath, err)
+	}
+
+	return g, nil
+}
+
+// Handler returns the gateway wrapped with the configured CORS policy.
+func (g *Gateway) Handler() http.Handler {
+	return cors(g.cfg.CORS, g.allowedMethods, g.mux)
+}
+
+func (g *Gateway) handle(rt route) runtime.HandlerFunc {
+	fullMethod := fmt.Sprintf("/%s/%s", pb.Tweets_ServiceDesc.ServiceName, rt.rpc)
+
+	return func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+		inbound, outbound := runtime.MarshalerForRequest(g.mux, r)
+
+		ctx, err := runtime.AnnotateContext(r.Context(), g.mux, r, fullMethod, runtime.WithHTTPPathPattern(rt.pattern))
+
+		if err != nil {
+			runtime.HTTPError(ctx, g.mux, outbound, w, r, err)
+			return
+		}
+
+		ctx = withClientIP(ctx, g.clientIP(r))
+
+		if rt.body {
+			r.Body = http.MaxBytesReader(w, r.Body, g.cfg.MaxUploadSize)
+		}
+
+		var md runtime.ServerMetadata
+
+		resp, err := rt.call(ctx, r, inbound, params, grpc.Header(&md.HeaderMD), grpc.Trailer(&md.TrailerMD))
+		ctx = runtime.NewServerMetadataContext(ctx, md)
+
+		if err != nil {
+			runtime.HTTPError(ctx, g.mux, outbound, w, r, err)
+			return
+		}
+
+		runtime.ForwardResponseMessage(ctx, g.mux, outbound, w, r, resp, g.mux.GetForwardResponseOptions()...)
+	}
+}
+
+func (g *Gateway) createTweet(ctx context.Context, r *http.Request, inbound runtime.Marshaler, _ map[string]string, opts ...grpc.CallOption) (proto.Message, error) {
+	var input pb.CreateTweetRequest
+
+	if isMultipart(r) {
+		form, err := readTweetForm(r, g.cfg.MaxUploadSize)
+
+		if err != nil {
+			return nil, err
+		}
+
+		input.Text, input.Image = form.text, form.image
+	} else if err := decodeBody(r, inbound, &input); err != nil {
+		return nil, err
+	}
+
+	return g.client.CreateTweet(ctx, &input, opts...)
+}
+
+func (g *Gateway) getTweet(ctx context.Context, _ *http.Request, _ runtime.Marshaler, params map[string]string, opts ...grpc.CallOption) (proto.Message, error) {
+	return g.client.GetTweet(ctx, &pb.GetTweetRequest{TweetId: params["tweet_id"]}, opts...)
+}
+
+func (g *Gateway) getAllTweets(ctx context.Context, r *http.Request, _ runtime.Marshaler, _ map[string]string, opts ...grpc.CallOption) (proto.Message, error) {
+	return g.client.GetAllTweets(ctx, &pb.GetAllTweetsRequest{Cursor: r.URL.Query().Get("cursor")}, opts...)
+}
+
+func (g *Gateway) updateTweet(ctx context.Context, r *http.Request, inbound runtime.Marshaler, params map[string]string, opts ...grpc.CallOption) (proto.Message, error) {
+	var input pb.UpdateTweetRequest
+
+	if isMultipart(r) {
+		form, err := readTweetForm(r, g.cfg.MaxUploadSize)
+
+		if err != nil {
+			return nil, err
+		}
+
+		input.Text, input.Image = form.text, form.image
+	} else if err := decodeBody(r, inbound, &input); err != nil {
+		return nil, err
+	}
+
+	// the path wins over whatever the body says
+	input.TweetId = params["tweet_id"]
+
+	return g.client.UpdateTweet(ctx, &input, opts...)
+}
+
+func (g *Gateway) deleteTweet(ctx context.Context, _ *http.Request, _ runtime.Marshaler, params map[string]string, opts ...grpc.CallOption) (proto.Message, error) {
+	return g.client.DeleteTweet(ctx, &pb.DeleteTweetRequest{TweetId: params["tweet_id"]}, opts...)
+}
+
+func (g *Gateway) serveOpenAPI(w http.ResponseWriter, _ *http.Request, _ map[string]string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := w.Write(g.openAPI); err != nil {
+		g.log.Errorf("cannot write openapi spec: %v", err.Error())
+	}
+}
+
+// clientIP is the address the request came from. Behind trusted proxies it is the rightmost X-Forwarded-For
+// hop that was not added by one of them, the hops left of it were sent by the client.
+func (g *Gateway) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	var hops []string
+	for _, value := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+
+	for i := len(hops) - 1; i >= 0 && g.trusted(host); i-- {
+		hop := strings.TrimSpace(hops[i])
+
+		if net.ParseIP(hop) == nil {
+			break
+		}
+
+		host = hop
+	}
+
+	return host
+}
+
+func (g *Gateway) trusted(host string) bool {
+	ip := net.ParseIP(host)
+
+	if ip == nil {
+		return false
+	}
+
+	for _, proxy := range g.trustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withClientIP passes the client address on to the gRPC server, replacing any the client sent.
+func withClientIP(ctx context.Context, ip string) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Set(ratelimit.ClientIPHeader, ip)
+
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// headerMatcher forwards the Idempotency-Key and X-Request-Id headers as is, on top of the default gateway headers.
+// Clients cannot set the client address through a Grpc-Metadata- header.
+func headerMatcher(key string) (string, bool) {
+	switch {
+	case strings.EqualFold(key, idempotency.Header):
+		return idempotency.Header, true
+	case strings.EqualFold(key, logger.RequestIDHeader):
+		return logger.RequestIDHeader, true
+	}
+
+	name, ok := runtime.DefaultHeaderMatcher(key)
+
+	if strings.EqualFold(name, ratelimit.ClientIPHeader) {
+		return "", false
+	}
+
+	return name, ok
+}
+
+// errorHandler adds a Retry-After header when the status carries RetryInfo.
+func errorHandler(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.GetRetryDelay() != nil {
+			seconds := math.Ceil(info.GetRetryDelay().AsDuration().Seconds())
+			w.Header().Set("Retry-After", strconv.Itoa(int(seconds)))
+		}
+	}
+
+	runtime.DefaultHTTPErrorHandler(ctx, mux, marshaler, w, r, err)
+}
+
+func setCreatedStatus(_ context.Context, w http.ResponseWriter, resp proto.Message) error {
+	if _, ok := resp.(*pb.CreateTweetResponse); ok {
+		w.WriteHeader(http.StatusCreated)
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
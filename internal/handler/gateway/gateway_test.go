@@ -0,0 +1,59 @@
+package gateway
+
+import (
+	"github.com/Verce11o/yata-tweets/config"
+	"github.com/Verce11o/yata-tweets/internal/lib/ratelimit"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name         string
+		proxies      []string
+		remoteAddr   string
+		forwardedFor []string
+		want         string
+	}{
+		{"direct", nil, "203.0.113.7:4000", nil, "203.0.113.7"},
+		{"header of an untrusted peer", nil, "203.0.113.7:4000", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"behind a proxy", []string{"10.0.0.0/8"}, "10.0.0.2:4000", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"spoofed hop left of the client", []string{"10.0.0.0/8"}, "10.0.0.2:4000", []string{"192.0.2.9, 198.51.100.1"}, "198.51.100.1"},
+		{"chain of proxies", []string{"10.0.0.0/8"}, "10.0.0.2:4000", []string{"192.0.2.9", "198.51.100.1, 10.0.0.3"}, "198.51.100.1"},
+		{"malformed hop", []string{"10.0.0.0/8"}, "10.0.0.2:4000", []string{"unknown"}, "10.0.0.2"},
+		{"proxy without header", []string{"10.0.0.0/8"}, "10.0.0.2:4000", nil, "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// no call is made
+			g, err := NewGateway(zap.NewNop().Sugar(), nil, config.Gateway{TrustedProxies: tt.proxies})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/v1/tweets", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwardedFor {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+
+			if got := g.clientIP(r); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeaderMatcherDropsClientIP(t *testing.T) {
+	if name, ok := headerMatcher("Grpc-Metadata-" + ratelimit.ClientIPHeader); ok {
+		t.Errorf("headerMatcher() forwards %q", name)
+	}
+
+	if name, ok := headerMatcher("Idempotency-Key"); !ok || name == "" {
+		t.Errorf("headerMatcher() drops Idempotency-Key")
+	}
+}
@@ -0,0 +1,213 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
+	statuspb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+type schema = map[string]any
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// buildOpenAPI describes the gateway routes as an OpenAPI 3 document.
+// Schemas are taken from the registered proto descriptors, so the spec always matches the service.
+func buildOpenAPI(routes []route) ([]byte, error) {
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(pb.Tweets_ServiceDesc.ServiceName))
+
+	if err != nil {
+		return nil, err
+	}
+
+	service, ok := desc.(protoreflect.ServiceDescriptor)
+
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", desc.FullName())
+	}
+
+	b := &specBuilder{schemas: schema{}}
+	errorSchema := b.message((&statuspb.Status{}).ProtoReflect().Descriptor())
+
+	paths := map[string]schema{}
+
+	for _, rt := range routes {
+		method := service.Methods().ByName(protoreflect.Name(rt.rpc))
+
+		if method == nil {
+			return nil, fmt.Errorf("%s has no method %s", service.FullName(), rt.rpc)
+		}
+
+		if paths[rt.pattern] == nil {
+			paths[rt.pattern] = schema{}
+		}
+
+		paths[rt.pattern][strings.ToLower(rt.method)] = b.operation(rt, method, errorSchema)
+	}
+
+	doc := schema{
+		"openapi": "3.0.3",
+		"info": schema{
+			"title":   string(service.FullName()),
+			"version": "v1",
+		},
+		"paths": paths,
+		"components": schema{
+			"schemas": b.schemas,
+			"securitySchemes": schema{
+				"bearer": schema{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+		"security": []schema{{"bearer": []string{}}},
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+type specBuilder struct {
+	schemas schema
+}
+
+func (b *specBuilder) operation(rt route, method protoreflect.MethodDescriptor, errorSchema schema) schema {
+	var params []schema
+
+	inPath := map[string]bool{}
+
+	for _, match := range pathParam.FindAllStringSubmatch(rt.pattern, -1) {
+		inPath[match[1]] = true
+		params = append(params, schema{"name": match[1], "in": "path", "required": true, "schema": schema{"type": "string"}})
+	}
+
+	if rt.method == http.MethodGet {
+		fields := method.Input().Fields()
+
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+
+			if !inPath[string(fd.Name())] {
+				params = append(params, schema{"name": string(fd.Name()), "in": "query", "schema": b.field(fd)})
+			}
+		}
+	}
+
+	status := "200"
+
+	if rt.rpc == "CreateTweet" {
+		status = "201"
+	}
+
+	op := schema{
+		"operationId": rt.rpc,
+		"responses": schema{
+			status:    schema{"description": "OK", "content": jsonContent(b.message(method.Output()))},
+			"default": schema{"description": "Error", "content": jsonContent(errorSchema)},
+		},
+	}
+
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	if rt.body {
+		content := jsonContent(b.message(method.Input()))
+		content["multipart/form-data"] = schema{
+			"schema": schema{
+				"type": "object",
+				"properties": schema{
+					formText:  schema{"type": "string"},
+					formImage: schema{"type": "string", "format": "binary"},
+				},
+			},
+		}
+
+		op["requestBody"] = schema{"required": true, "content": content}
+	}
+
+	return op
+}
+
+// message returns a reference to the message schema, registering it on first use.
+func (b *specBuilder) message(md protoreflect.MessageDescriptor) schema {
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		return schema{"type": "string", "format": "date-time"}
+	case "google.protobuf.Duration":
+		return schema{"type": "string"}
+	case "google.protobuf.Any":
+		return schema{"type": "object", "properties": schema{"@type": schema{"type": "string"}}, "additionalProperties": true}
+	}
+
+	name := string(md.FullName())
+
+	if _, ok := b.schemas[name]; !ok {
+		// placeholder, so recursive messages do not loop
+		b.schemas[name] = nil
+
+		properties := schema{}
+		fields := md.Fields()
+
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			properties[fd.JSONName()] = b.field(fd)
+		}
+
+		b.schemas[name] = schema{"type": "object", "properties": properties}
+	}
+
+	return schema{"$ref": "#/components/schemas/" + name}
+}
+
+func (b *specBuilder) field(fd protoreflect.FieldDescriptor) schema {
+	if fd.IsMap() {
+		return schema{"type": "object", "additionalProperties": b.value(fd.MapValue())}
+	}
+
+	if fd.IsList() {
+		return schema{"type": "array", "items": b.value(fd)}
+	}
+
+	return b.value(fd)
+}
+
+// value follows the protojson mapping, e.g. 64-bit integers and bytes are strings.
+func (b *specBuilder) value(fd protoreflect.FieldDescriptor) schema {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return schema{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return schema{"type": "integer", "format": "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return schema{"type": "integer", "format": "int64"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return schema{"type": "string", "format": "int64"}
+	case protoreflect.FloatKind:
+		return schema{"type": "number", "format": "float"}
+	case protoreflect.DoubleKind:
+		return schema{"type": "number", "format": "double"}
+	case protoreflect.BytesKind:
+		return schema{"type": "string", "format": "byte"}
+	case protoreflect.EnumKind:
+		var names []string
+
+		values := fd.Enum().Values()
+
+		for i := 0; i < values.Len(); i++ {
+			names = append(names, string(values.Get(i).Name()))
+		}
+
+		return schema{"type": "string", "enum": names}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return b.message(fd.Message())
+	default:
+		return schema{"type": "string"}
+	}
+}
+
+func jsonContent(s schema) schema {
+	return schema{"application/json": schema{"schema": s}}
+}
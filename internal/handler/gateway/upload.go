@@ -0,0 +1,90 @@
+package gateway
+
+import (
+	"errors"
+	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"mime"
+	"net/http"
+)
+
+const (
+	formText  = "text"
+	formImage = "image"
+)
+
+type tweetForm struct {
+	text  string
+	image *pb.Image
+}
+
+func isMultipart(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+// readTweetForm reads the text and the optional image of a multipart/form-data request.
+func readTweetForm(r *http.Request, maxSize int64) (*tweetForm, error) {
+	if err := r.ParseMultipartForm(maxSize); err != nil {
+		return nil, bodyError(err, "invalid multipart form")
+	}
+
+	defer r.MultipartForm.RemoveAll()
+
+	form := &tweetForm{text: r.FormValue(formText)}
+
+	file, header, err := r.FormFile(formImage)
+
+	if errors.Is(err, http.ErrMissingFile) {
+		return form, nil
+	}
+
+	if err != nil {
+		return nil, bodyError(err, "invalid image")
+	}
+
+	defer file.Close()
+
+	chunk, err := io.ReadAll(file)
+
+	if err != nil {
+		return nil, bodyError(err, "cannot read image")
+	}
+
+	contentType := header.Header.Get("Content-Type")
+
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = http.DetectContentType(chunk)
+	}
+
+	form.image = &pb.Image{Chunk: chunk, ContentType: contentType, Name: header.Filename}
+
+	return form, nil
+}
+
+func decodeBody(r *http.Request, marshaler runtime.Marshaler, input proto.Message) error {
+	if err := marshaler.NewDecoder(r.Body).Decode(input); err != nil && !errors.Is(err, io.EOF) {
+		return bodyError(err, "invalid request body")
+	}
+
+	return nil
+}
+
+// bodyError reports an oversized body as 413 and anything else as 400.
+func bodyError(err error, message string) error {
+	var maxBytesErr *http.MaxBytesError
+
+	if errors.As(err, &maxBytesErr) {
+		return &runtime.HTTPStatusError{
+			HTTPStatus: http.StatusRequestEntityTooLarge,
+			Err:        status.Error(codes.InvalidArgument, "request body is too large"),
+		}
+	}
+
+	return status.Error(codes.InvalidArgument, message)
+}
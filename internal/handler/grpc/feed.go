@@ -0,0 +1,78 @@
+package grpc
+
+import (
+	pb "github.com/Verce11o/yata-tweets/gen/go/feed"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/lib/grpc_errors"
+	"github.com/Verce11o/yata-tweets/internal/lib/logger"
+	"github.com/Verce11o/yata-tweets/internal/service"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+var feedEventTypes = map[domain.FeedEventType]pb.FeedEvent_Type{
+	domain.FeedEventCreated:   pb.FeedEvent_TYPE_CREATED,
+	domain.FeedEventUpdated:   pb.FeedEvent_TYPE_UPDATED,
+	domain.FeedEventDeleted:   pb.FeedEvent_TYPE_DELETED,
+	domain.FeedEventHeartbeat: pb.FeedEvent_TYPE_HEARTBEAT,
+}
+
+type FeedGRPC struct {
+	log     *zap.SugaredLogger
+	tracer  trace.Tracer
+	service service.Feed
+	pb.UnimplementedFeedServer
+}
+
+func NewFeedGRPC(log *zap.SugaredLogger, tracer trace.Tracer, service service.Feed) *FeedGRPC {
+	return &FeedGRPC{log: log, tracer: tracer, service: service}
+}
+
+func (f *FeedGRPC) StreamTweets(input *pb.StreamTweetsRequest, stream pb.Feed_StreamTweetsServer) error {
+	ctx, span := f.tracer.Start(stream.Context(), "GRPC.StreamTweets")
+	defer span.End()
+
+	if err := validateStreamTweets(input); err != nil {
+		return err
+	}
+
+	err := f.service.StreamTweets(ctx, input, func(event *domain.FeedEvent) error {
+		return stream.Send(newFeedEvent(event))
+	})
+
+	if err != nil && ctx.Err() == nil {
+		logger.Ctx(ctx, f.log).Errorf("StreamTweets: %v", err.Error())
+		return grpc_errors.ToGRPCError(err)
+	}
+
+	return nil
+}
+
+func newFeedEvent(event *domain.FeedEvent) *pb.FeedEvent {
+	res := &pb.FeedEvent{
+		Type:       feedEventTypes[event.Type],
+		Cursor:     event.ID,
+		OccurredAt: timestamppb.New(event.OccurredAt),
+	}
+
+	if event.Type != domain.FeedEventHeartbeat {
+		res.Tweet = &pb.FeedTweet{
+			TweetId:   event.Tweet.TweetID.String(),
+			UserId:    event.Tweet.UserID.String(),
+			Text:      event.Tweet.Text,
+			CreatedAt: newTimestamp(event.Tweet.CreatedAt),
+			UpdatedAt: newTimestamp(event.Tweet.UpdatedAt),
+		}
+	}
+
+	return res
+}
+
+func newTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
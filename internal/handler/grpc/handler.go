@@ -4,9 +4,10 @@ import (
 	"context"
 	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
 	"github.com/Verce11o/yata-tweets/internal/lib/grpc_errors"
+	"github.com/Verce11o/yata-tweets/internal/lib/idempotency"
+	"github.com/Verce11o/yata-tweets/internal/lib/logger"
 	"github.com/Verce11o/yata-tweets/internal/service"
 	"go.opentelemetry.io/otel/trace"
-	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"go.uber.org/zap"
@@ -27,11 +28,21 @@ func (t *TweetGRPC) CreateTweet(ctx context.Context, input *pb.CreateTweetReques
 	ctx, span := t.tracer.Start(ctx, "CreateTweet")
 	defer span.End()
 
-	tweetID, err := t.service.CreateTweet(ctx, input)
+	if err := validateCreateTweet(input); err != nil {
+		return nil, err
+	}
+
+	key, err := idempotency.KeyFromMetadata(ctx)
 
 	if err != nil {
-		t.log.Errorf("CreateTweet: %v", err.Error())
-		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "CreateTweet: %v", err)
+		return nil, grpc_errors.ToGRPCError(err)
+	}
+
+	tweetID, err := t.service.CreateTweet(idempotency.WithKey(ctx, key), input)
+
+	if err != nil {
+		logger.Ctx(ctx, t.log).Errorf("CreateTweet: %v", err.Error())
+		return nil, grpc_errors.ToGRPCError(err)
 	}
 
 	return &pb.CreateTweetResponse{TweetId: tweetID}, nil
@@ -41,11 +52,15 @@ func (t *TweetGRPC) GetTweet(ctx context.Context, input *pb.GetTweetRequest) (*p
 	ctx, span := t.tracer.Start(ctx, "GRPC.GetTweet")
 	defer span.End()
 
+	if err := validateGetTweet(input); err != nil {
+		return nil, err
+	}
+
 	tweet, err := t.service.GetTweet(ctx, input.GetTweetId())
 
 	if err != nil {
-		t.log.Errorf("GetTweet: %v", err.Error())
-		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "GetTweet: %v", err)
+		logger.Ctx(ctx, t.log).Errorf("GetTweet: %v", err.Error())
+		return nil, grpc_errors.ToGRPCError(err)
 	}
 
 	return &pb.Tweet{
@@ -61,11 +76,15 @@ func (t *TweetGRPC) GetAllTweets(ctx context.Context, input *pb.GetAllTweetsRequ
 	ctx, span := t.tracer.Start(ctx, "GRPC.GetAllTweets")
 	defer span.End()
 
+	if err := validateGetAllTweets(input); err != nil {
+		return nil, err
+	}
+
 	tweets, nextCursor, err := t.service.GetAllTweets(ctx, input)
 
 	if err != nil {
-		t.log.Errorf("GetAllTweets: %v", err.Error())
-		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "GetAllTweets: %v", err)
+		logger.Ctx(ctx, t.log).Errorf("GetAllTweets: %v", err.Error())
+		return nil, grpc_errors.ToGRPCError(err)
 	}
 
 	return &pb.GetAllTweetsResponse{Tweets: tweets, Cursor: nextCursor}, nil
@@ -75,11 +94,15 @@ func (t *TweetGRPC) UpdateTweet(ctx context.Context, input *pb.UpdateTweetReques
 	ctx, span := t.tracer.Start(ctx, "UpdateTweet")
 	defer span.End()
 
+	if err := validateUpdateTweet(input); err != nil {
+		return nil, err
+	}
+
 	tweet, err := t.service.UpdateTweet(ctx, input)
 
 	if err != nil {
-		t.log.Errorf("UpdateTweet: %v", err.Error())
-		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "UpdateTweet: %v", err)
+		logger.Ctx(ctx, t.log).Errorf("UpdateTweet: %v", err.Error())
+		return nil, grpc_errors.ToGRPCError(err)
 	}
 
 	return &pb.Tweet{
@@ -93,10 +116,14 @@ func (t *TweetGRPC) DeleteTweet(ctx context.Context, input *pb.DeleteTweetReques
 	ctx, span := t.tracer.Start(ctx, "DeleteTweet")
 	defer span.End()
 
+	if err := validateDeleteTweet(input); err != nil {
+		return nil, err
+	}
+
 	err := t.service.DeleteTweet(ctx, input)
 
 	if err != nil {
-		return nil, status.Errorf(grpc_errors.ParseGRPCErrStatusCode(err), "DeleteTweet: %v", err)
+		return nil, grpc_errors.ToGRPCError(err)
 	}
 
 	return &pb.DeleteTweetResponse{}, nil
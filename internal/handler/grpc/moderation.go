@@ -0,0 +1,67 @@
+package grpc
+
+import (
+	"context"
+	pb "github.com/Verce11o/yata-tweets/gen/go/moderation"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/lib/grpc_errors"
+	"github.com/Verce11o/yata-tweets/internal/lib/logger"
+	"github.com/Verce11o/yata-tweets/internal/service"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type ModerationGRPC struct {
+	log     *zap.SugaredLogger
+	tracer  trace.Tracer
+	service service.Moderation
+	pb.UnimplementedModerationServer
+}
+
+func NewModerationGRPC(log *zap.SugaredLogger, tracer trace.Tracer, service service.Moderation) *ModerationGRPC {
+	return &ModerationGRPC{log: log, tracer: tracer, service: service}
+}
+
+func (m *ModerationGRPC) HideTweet(ctx context.Context, input *pb.HideTweetRequest) (*pb.ModerationResponse, error) {
+	ctx, span := m.tracer.Start(ctx, "GRPC.HideTweet")
+	defer span.End()
+
+	if err := validateHideTweet(input); err != nil {
+		return nil, err
+	}
+
+	tweet, err := m.service.HideTweet(ctx, input)
+
+	if err != nil {
+		logger.Ctx(ctx, m.log).Errorf("HideTweet: %v", err.Error())
+		return nil, grpc_errors.ToGRPCError(err)
+	}
+
+	return newModerationResponse(tweet), nil
+}
+
+func (m *ModerationGRPC) LockReplies(ctx context.Context, input *pb.LockRepliesRequest) (*pb.ModerationResponse, error) {
+	ctx, span := m.tracer.Start(ctx, "GRPC.LockReplies")
+	defer span.End()
+
+	if err := validateLockReplies(input); err != nil {
+		return nil, err
+	}
+
+	tweet, err := m.service.LockReplies(ctx, input)
+
+	if err != nil {
+		logger.Ctx(ctx, m.log).Errorf("LockReplies: %v", err.Error())
+		return nil, grpc_errors.ToGRPCError(err)
+	}
+
+	return newModerationResponse(tweet), nil
+}
+
+func newModerationResponse(tweet *domain.Tweet) *pb.ModerationResponse {
+	return &pb.ModerationResponse{
+		TweetId:       tweet.TweetID.String(),
+		Hidden:        tweet.Hidden,
+		RepliesLocked: tweet.RepliesLocked,
+	}
+}
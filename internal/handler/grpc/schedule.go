@@ -0,0 +1,133 @@
+package grpc
+
+import (
+	"context"
+	pb "github.com/Verce11o/yata-tweets/gen/go/schedule"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/lib/grpc_errors"
+	"github.com/Verce11o/yata-tweets/internal/lib/logger"
+	"github.com/Verce11o/yata-tweets/internal/service"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var scheduleStatuses = map[domain.ScheduleStatus]pb.ScheduledTweet_Status{
+	domain.ScheduleStatusPending:   pb.ScheduledTweet_STATUS_PENDING,
+	domain.ScheduleStatusPublished: pb.ScheduledTweet_STATUS_PUBLISHED,
+	domain.ScheduleStatusCancelled: pb.ScheduledTweet_STATUS_CANCELLED,
+	domain.ScheduleStatusFailed:    pb.ScheduledTweet_STATUS_FAILED,
+}
+
+type ScheduleGRPC struct {
+	log     *zap.SugaredLogger
+	tracer  trace.Tracer
+	service service.Schedule
+	pb.UnimplementedScheduleServer
+}
+
+func NewScheduleGRPC(log *zap.SugaredLogger, tracer trace.Tracer, service service.Schedule) *ScheduleGRPC {
+	return &ScheduleGRPC{log: log, tracer: tracer, service: service}
+}
+
+func (s *ScheduleGRPC) CreateScheduledTweet(ctx context.Context, input *pb.CreateScheduledTweetRequest) (*pb.ScheduledTweet, error) {
+	ctx, span := s.tracer.Start(ctx, "GRPC.CreateScheduledTweet")
+	defer span.End()
+
+	if err := validateCreateScheduledTweet(input); err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.service.CreateScheduledTweet(ctx, input)
+
+	if err != nil {
+		logger.Ctx(ctx, s.log).Errorf("CreateScheduledTweet: %v", err.Error())
+		return nil, grpc_errors.ToGRPCError(err)
+	}
+
+	return newScheduledTweet(schedule), nil
+}
+
+func (s *ScheduleGRPC) ListScheduledTweets(ctx context.Context, input *pb.ListScheduledTweetsRequest) (*pb.ListScheduledTweetsResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "GRPC.ListScheduledTweets")
+	defer span.End()
+
+	if err := validateListScheduledTweets(input); err != nil {
+		return nil, err
+	}
+
+	schedules, nextCursor, err := s.service.ListScheduledTweets(ctx, input)
+
+	if err != nil {
+		logger.Ctx(ctx, s.log).Errorf("ListScheduledTweets: %v", err.Error())
+		return nil, grpc_errors.ToGRPCError(err)
+	}
+
+	res := &pb.ListScheduledTweetsResponse{Cursor: nextCursor}
+	for _, schedule := range schedules {
+		res.ScheduledTweets = append(res.ScheduledTweets, newScheduledTweet(schedule))
+	}
+
+	return res, nil
+}
+
+func (s *ScheduleGRPC) UpdateScheduledTweet(ctx context.Context, input *pb.UpdateScheduledTweetRequest) (*pb.ScheduledTweet, error) {
+	ctx, span := s.tracer.Start(ctx, "GRPC.UpdateScheduledTweet")
+	defer span.End()
+
+	if err := validateUpdateScheduledTweet(input); err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.service.UpdateScheduledTweet(ctx, input)
+
+	if err != nil {
+		logger.Ctx(ctx, s.log).Errorf("UpdateScheduledTweet: %v", err.Error())
+		return nil, grpc_errors.ToGRPCError(err)
+	}
+
+	return newScheduledTweet(schedule), nil
+}
+
+func (s *ScheduleGRPC) CancelScheduledTweet(ctx context.Context, input *pb.CancelScheduledTweetRequest) (*pb.ScheduledTweet, error) {
+	ctx, span := s.tracer.Start(ctx, "GRPC.CancelScheduledTweet")
+	defer span.End()
+
+	if err := validateCancelScheduledTweet(input); err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.service.CancelScheduledTweet(ctx, input)
+
+	if err != nil {
+		logger.Ctx(ctx, s.log).Errorf("CancelScheduledTweet: %v", err.Error())
+		return nil, grpc_errors.ToGRPCError(err)
+	}
+
+	return newScheduledTweet(schedule), nil
+}
+
+func newScheduledTweet(schedule *domain.ScheduledTweet) *pb.ScheduledTweet {
+	res := &pb.ScheduledTweet{
+		ScheduleId:     schedule.ScheduleID.String(),
+		UserId:         schedule.UserID.String(),
+		Text:           schedule.Text,
+		ImageName:      schedule.ImageName,
+		PublishAt:      timestamppb.New(schedule.PublishAt),
+		LocalPublishAt: schedule.LocalPublishAt().Format(service.LocalTimeLayout),
+		TimeZone:       schedule.TimeZone,
+		Status:         scheduleStatuses[schedule.Status],
+		CreatedAt:      timestamppb.New(schedule.CreatedAt),
+		UpdatedAt:      timestamppb.New(schedule.UpdatedAt),
+	}
+
+	if schedule.TweetID != nil {
+		res.TweetId = schedule.TweetID.String()
+	}
+
+	if schedule.PublishedAt != nil {
+		res.PublishedAt = timestamppb.New(*schedule.PublishedAt)
+	}
+
+	return res
+}
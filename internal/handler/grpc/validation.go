@@ -0,0 +1,229 @@
+package grpc
+
+import (
+	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
+	feedPb "github.com/Verce11o/yata-tweets/gen/go/feed"
+	moderationPb "github.com/Verce11o/yata-tweets/gen/go/moderation"
+	schedulePb "github.com/Verce11o/yata-tweets/gen/go/schedule"
+	"github.com/Verce11o/yata-tweets/internal/lib/pagination"
+	"github.com/Verce11o/yata-tweets/internal/lib/validation"
+	"github.com/Verce11o/yata-tweets/internal/service"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	maxTweetLength  = 280
+	maxTweetBytes   = 4096
+	maxReasonLength = 500
+	maxImageSize    = 5 << 20
+	maxImageName    = 255
+
+	maxStreamAuthors = 100
+	maxHashtagLength = 100
+
+	maxTimeZoneLength = 64
+)
+
+var feedCursorRegexp = regexp.MustCompile(`^\d+-\d+$`)
+
+var imageContentTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// validateCreateTweet normalizes the tweet text in place and checks the request.
+func validateCreateTweet(input *pb.CreateTweetRequest) error {
+	input.Text = validation.NormalizeText(input.GetText())
+
+	v := validation.New()
+	v.Field("user_id", input.GetUserId(), validation.Optional(validation.UUID))
+	validateContent(v, input.GetText(), input.GetImage())
+
+	return v.Err()
+}
+
+func validateGetTweet(input *pb.GetTweetRequest) error {
+	return validation.New().
+		Field("tweet_id", input.GetTweetId(), validation.Required, validation.UUID).
+		Err()
+}
+
+func validateGetAllTweets(input *pb.GetAllTweetsRequest) error {
+	return validation.New().
+		Field("cursor", input.GetCursor(), validation.Optional(validCursor)).
+		Err()
+}
+
+func validateUpdateTweet(input *pb.UpdateTweetRequest) error {
+	input.Text = validation.NormalizeText(input.GetText())
+
+	v := validation.New()
+	v.Field("tweet_id", input.GetTweetId(), validation.Required, validation.UUID)
+	v.Field("user_id", input.GetUserId(), validation.Optional(validation.UUID))
+	validateContent(v, input.GetText(), input.GetImage())
+
+	return v.Err()
+}
+
+func validateDeleteTweet(input *pb.DeleteTweetRequest) error {
+	return validation.New().
+		Field("tweet_id", input.GetTweetId(), validation.Required, validation.UUID).
+		Field("user_id", input.GetUserId(), validation.Optional(validation.UUID)).
+		Err()
+}
+
+func validateHideTweet(input *moderationPb.HideTweetRequest) error {
+	input.Reason = validation.NormalizeText(input.GetReason())
+
+	return validation.New().
+		Field("tweet_id", input.GetTweetId(), validation.Required, validation.UUID).
+		Field("reason", input.GetReason(), validation.MaxRunes(maxReasonLength)).
+		Err()
+}
+
+func validateLockReplies(input *moderationPb.LockRepliesRequest) error {
+	input.Reason = validation.NormalizeText(input.GetReason())
+
+	return validation.New().
+		Field("tweet_id", input.GetTweetId(), validation.Required, validation.UUID).
+		Field("reason", input.GetReason(), validation.MaxRunes(maxReasonLength)).
+		Err()
+}
+
+// validateCreateScheduledTweet normalizes the tweet text in place and checks the request.
+// Whether the publish time is ahead is checked by the service.
+func validateCreateScheduledTweet(input *schedulePb.CreateScheduledTweetRequest) error {
+	input.Text = validation.NormalizeText(input.GetText())
+
+	v := validation.New()
+	validateContent(v, input.GetText(), scheduledImage(input.GetImage()))
+	validatePublishTime(v, input.GetPublishAt(), input.GetTimeZone())
+
+	return v.Err()
+}
+
+func validateListScheduledTweets(input *schedulePb.ListScheduledTweetsRequest) error {
+	_, known := schedulePb.ScheduledTweet_Status_name[int32(input.GetStatus())]
+
+	return validation.New().
+		Check("status", known, "must be a known status").
+		Field("cursor", input.GetCursor(), validation.Optional(validCursor)).
+		Err()
+}
+
+func validateUpdateScheduledTweet(input *schedulePb.UpdateScheduledTweetRequest) error {
+	input.Text = validation.NormalizeText(input.GetText())
+
+	v := validation.New()
+	v.Field("schedule_id", input.GetScheduleId(), validation.Required, validation.UUID)
+
+	// the image is kept when none is sent, so the text may be empty only for a schedule that has one
+	v.Field("text", input.GetText(), validation.MaxBytes(maxTweetBytes), validation.MaxWeightedLength(maxTweetLength))
+
+	if input.GetImage() != nil {
+		validateContent(v, input.GetText(), scheduledImage(input.GetImage()))
+	}
+
+	validatePublishTime(v, input.GetPublishAt(), input.GetTimeZone())
+
+	return v.Err()
+}
+
+func validateCancelScheduledTweet(input *schedulePb.CancelScheduledTweetRequest) error {
+	return validation.New().
+		Field("schedule_id", input.GetScheduleId(), validation.Required, validation.UUID).
+		Err()
+}
+
+func validatePublishTime(v *validation.Validator, publishAt string, timeZone string) {
+	v.Field("publish_at", publishAt, validation.Required, validLocalTime)
+	v.Field("time_zone", timeZone, validation.Optional(validation.MaxBytes(maxTimeZoneLength), validTimeZone))
+}
+
+func validateStreamTweets(input *feedPb.StreamTweetsRequest) error {
+	v := validation.New()
+
+	for _, id := range input.GetAuthorIds() {
+		v.Field("author_ids", id, validation.UUID)
+	}
+
+	v.Check("author_ids", len(input.GetAuthorIds()) <= maxStreamAuthors, "must contain at most 100 authors")
+	v.Field("hashtag", strings.TrimPrefix(input.GetHashtag(), "#"), validation.Optional(validation.MaxRunes(maxHashtagLength), validHashtag))
+	v.Field("cursor", input.GetCursor(), validation.Optional(validFeedCursor))
+
+	return v.Err()
+}
+
+// validateContent requires a tweet to have text, an image or both.
+func validateContent(v *validation.Validator, text string, image *pb.Image) {
+	v.Field("text", text, validation.MaxBytes(maxTweetBytes), validation.MaxWeightedLength(maxTweetLength))
+
+	if image == nil {
+		v.Field("text", text, validation.Required)
+		return
+	}
+
+	v.Field("image.name", image.GetName(), validation.Required, validation.MaxBytes(maxImageName), safeFileName)
+	v.Field("image.content_type", image.GetContentType(), validation.Required, validation.OneOf(imageContentTypes...))
+	v.Check("image.chunk", len(image.GetChunk()) > 0, "must not be empty")
+	v.Check("image.chunk", len(image.GetChunk()) <= maxImageSize, "must be at most 5 MiB")
+}
+
+func safeFileName(value string) string {
+	if value != filepath.Base(value) || strings.ContainsAny(value, `/\`) || value == "." || value == ".." {
+		return "must be a plain file name"
+	}
+	if validation.NormalizeText(value) != value {
+		return "must not contain control characters or surrounding spaces"
+	}
+	return ""
+}
+
+func validCursor(value string) string {
+	if _, _, err := pagination.DecodeCursor(value); err != nil {
+		return "must be a cursor returned by a previous page"
+	}
+	return ""
+}
+
+func validFeedCursor(value string) string {
+	if !feedCursorRegexp.MatchString(value) {
+		return "must be a cursor returned by a previous event"
+	}
+	return ""
+}
+
+func validLocalTime(value string) string {
+	if _, err := time.Parse(service.LocalTimeLayout, value); err != nil {
+		return "must be a local date and time like " + service.LocalTimeLayout
+	}
+	return ""
+}
+
+func validTimeZone(value string) string {
+	// Local would be the zone of whichever replica publishes the tweet
+	if value == "Local" {
+		return "must be an IANA time zone name"
+	}
+	if _, err := time.LoadLocation(value); err != nil {
+		return "must be an IANA time zone name"
+	}
+	return ""
+}
+
+func scheduledImage(image *schedulePb.Image) *pb.Image {
+	if image == nil {
+		return nil
+	}
+	return &pb.Image{Chunk: image.GetChunk(), ContentType: image.GetContentType(), Name: image.GetName()}
+}
+
+func validHashtag(value string) string {
+	for _, r := range value {
+		if !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '_' {
+			return "must contain only letters, digits and underscores"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"github.com/Verce11o/yata-tweets/internal/lib/grpc_errors"
+	"sync/atomic"
+)
+
+// Principal is the authenticated caller of an RPC.
+type Principal struct {
+	UserID string
+	Roles  []string
+}
+
+type principalKey struct{}
+
+type principalHolderKey struct{}
+
+// principalHolder receives the principal from the interceptor, so that interceptors running before it,
+// such as the request logger, see who made the call once it is authenticated.
+type principalHolder struct {
+	principal atomic.Pointer[Principal]
+}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// WithPrincipalHolder prepares ctx to receive the principal of the call, it has to run before the interceptor.
+func WithPrincipalHolder(ctx context.Context) context.Context {
+	return context.WithValue(ctx, principalHolderKey{}, &principalHolder{})
+}
+
+// PrincipalFromContext returns the caller authenticated by the interceptor, if any.
+// On a context prepared with WithPrincipalHolder it is there once the interceptor has run.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	if principal, ok := ctx.Value(principalKey{}).(*Principal); ok && principal != nil {
+		return principal, true
+	}
+
+	holder, ok := ctx.Value(principalHolderKey{}).(*principalHolder)
+	if !ok {
+		return nil, false
+	}
+
+	principal := holder.principal.Load()
+	return principal, principal != nil
+}
+
+func holdPrincipal(ctx context.Context, principal *Principal) {
+	if holder, ok := ctx.Value(principalHolderKey{}).(*principalHolder); ok {
+		holder.principal.Store(principal)
+	}
+}
+
+// RequirePrincipal returns the caller or grpc_errors.ErrUnauthenticated for anonymous requests.
+func RequirePrincipal(ctx context.Context) (*Principal, error) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil, grpc_errors.ErrUnauthenticated
+	}
+	return principal, nil
+}
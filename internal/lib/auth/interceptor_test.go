@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"github.com/Verce11o/yata-tweets/config"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	verifier, err := NewVerifier(config.Auth{Secret: testSecret})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token := sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", jwt.MapClaims{"sub": "user", "exp": time.Now().Add(time.Hour).Unix()})
+
+	tests := []struct {
+		name          string
+		authorization []string
+		wantCode      codes.Code
+		wantUser      string
+	}{
+		{"anonymous", nil, codes.OK, ""},
+		{"bearer", []string{"Bearer " + token}, codes.OK, "user"},
+		{"scheme is case insensitive", []string{"bearer " + token}, codes.OK, "user"},
+		{"basic", []string{"Basic dXNlcjpwYXNz"}, codes.Unauthenticated, ""},
+		{"no scheme", []string{token}, codes.Unauthenticated, ""},
+		{"invalid token", []string{"Bearer " + token + "x"}, codes.Unauthenticated, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.authorization != nil {
+				ctx = metadata.NewIncomingContext(ctx, metadata.MD{authorizationHeader: tt.authorization})
+			}
+
+			var user string
+			handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+				if principal, ok := PrincipalFromContext(ctx); ok {
+					user = principal.UserID
+				}
+				return nil, nil
+			}
+
+			_, err := UnaryServerInterceptor(verifier)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/tweets.Tweets/CreateTweet"}, handler)
+
+			if code := status.Code(err); code != tt.wantCode || user != tt.wantUser {
+				t.Errorf("interceptor = %v as %q, want %v as %q", code, user, tt.wantCode, tt.wantUser)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/Verce11o/yata-tweets/config"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testSecret = "secret"
+
+// writeJWKS publishes the public halves of keys, by kid, and returns the path of the file.
+func writeJWKS(t *testing.T, keys map[string]*rsa.PrivateKey) string {
+	t.Helper()
+
+	var set jwks
+	for kid, key := range keys {
+		set.Keys = append(set.Keys, struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		}{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+
+	data, err := json.Marshal(set)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func generateKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	raw, err := token.SignedString(key)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return raw
+}
+
+func TestVerify(t *testing.T) {
+	primary, secondary, unknown := generateKey(t), generateKey(t), generateKey(t)
+	publicDER, err := x509.MarshalPKIXPublicKey(&primary.PublicKey)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"sub": "user",
+			"iss": "yata-auth",
+			"aud": "yata-tweets",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+	}
+	with := func(key string, value interface{}) jwt.MapClaims {
+		c := valid()
+		if value == nil {
+			delete(c, key)
+		} else {
+			c[key] = value
+		}
+		return c
+	}
+
+	hsConfig := config.Auth{Secret: testSecret, Issuer: "yata-auth", Audience: "yata-tweets"}
+	rsConfig := config.Auth{JWKSFile: writeJWKS(t, map[string]*rsa.PrivateKey{"primary": primary, "secondary": secondary}), Issuer: "yata-auth", Audience: "yata-tweets"}
+	singleKeyConfig := config.Auth{JWKSFile: writeJWKS(t, map[string]*rsa.PrivateKey{"primary": primary})}
+
+	tests := []struct {
+		name  string
+		cfg   config.Auth
+		token string
+		want  *Principal
+	}{
+		{
+			name:  "hs",
+			cfg:   hsConfig,
+			token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", valid()),
+			want:  &Principal{UserID: "user"},
+		},
+		{
+			name:  "rs with the kid of another key",
+			cfg:   rsConfig,
+			token: sign(t, jwt.SigningMethodRS256, primary, "secondary", valid()),
+		},
+		{
+			name:  "rs",
+			cfg:   rsConfig,
+			token: sign(t, jwt.SigningMethodRS256, secondary, "secondary", valid()),
+			want:  &Principal{UserID: "user"},
+		},
+		{
+			name:  "hs against rs verifier",
+			cfg:   rsConfig,
+			token: sign(t, jwt.SigningMethodHS256, publicDER, "primary", valid()),
+		},
+		{
+			name:  "rs against hs verifier",
+			cfg:   hsConfig,
+			token: sign(t, jwt.SigningMethodRS256, primary, "primary", valid()),
+		},
+		{
+			name:  "alg none",
+			cfg:   hsConfig,
+			token: sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", valid()),
+		},
+		{
+			name:  "expired",
+			cfg:   hsConfig,
+			token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", with("exp", time.Now().Add(-time.Minute).Unix())),
+		},
+		{
+			name:  "without exp",
+			cfg:   hsConfig,
+			token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", with("exp", nil)),
+		},
+		{
+			name:  "wrong issuer",
+			cfg:   hsConfig,
+			token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", with("iss", "someone")),
+		},
+		{
+			name:  "wrong audience",
+			cfg:   hsConfig,
+			token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", with("aud", "yata-users")),
+		},
+		{
+			name:  "wrong secret",
+			cfg:   hsConfig,
+			token: sign(t, jwt.SigningMethodHS256, []byte("guessed"), "", valid()),
+		},
+		{
+			name:  "unknown kid",
+			cfg:   rsConfig,
+			token: sign(t, jwt.SigningMethodRS256, unknown, "unknown", valid()),
+		},
+		{
+			name:  "no kid with several keys",
+			cfg:   rsConfig,
+			token: sign(t, jwt.SigningMethodRS256, primary, "", valid()),
+		},
+		{
+			name:  "no kid with a single key",
+			cfg:   singleKeyConfig,
+			token: sign(t, jwt.SigningMethodRS256, primary, "", valid()),
+			want:  &Principal{UserID: "user"},
+		},
+		{
+			name:  "no sub",
+			cfg:   hsConfig,
+			token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", with("sub", nil)),
+		},
+		{
+			name:  "user_id and roles",
+			cfg:   hsConfig,
+			token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", jwt.MapClaims{
+				"sub":     "user",
+				"user_id": "other",
+				"roles":   []string{"moderator"},
+				"role":    "admin",
+				"iss":     "yata-auth",
+				"aud":     "yata-tweets",
+				"exp":     time.Now().Add(time.Hour).Unix(),
+			}),
+			want:  &Principal{UserID: "other", Roles: []string{"moderator", "admin"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, err := NewVerifier(tt.cfg)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := verifier.Verify(tt.token)
+
+			if tt.want == nil {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("Verify() = %v, %v, want %v", got, err, ErrInvalidToken)
+				}
+				return
+			}
+
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Verify() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewVerifierWithoutKeys(t *testing.T) {
+	if _, err := NewVerifier(config.Auth{}); !errors.Is(err, ErrNoKeys) {
+		t.Errorf("NewVerifier() = %v, want %v", err, ErrNoKeys)
+	}
+}
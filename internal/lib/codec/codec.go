@@ -0,0 +1,115 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/klauspost/compress/s2"
+	"sort"
+)
+
+// Every encoded entry starts with a version byte. Its low bits name the codec and the schema it wrote,
+// so a schema change gets a new version instead of breaking entries written by the previous deploy.
+// The high bit marks a compressed payload.
+const (
+	VersionJSON     byte = 1
+	VersionProtobuf byte = 2
+	VersionMsgpack  byte = 3
+
+	compressedFlag byte = 0x80
+)
+
+// ErrUnknownVersion is returned for entries written by a codec or schema this build does not know,
+// callers treat them as cache misses.
+var ErrUnknownVersion = errors.New("unknown cache entry version")
+
+// Codec encodes cache entries in one format.
+type Codec interface {
+	Name() string
+	Version() byte
+	Marshal(entry *domain.CachedTweet) ([]byte, error)
+	Unmarshal(data []byte) (*domain.CachedTweet, error)
+}
+
+var codecs = []Codec{JSON{}, Protobuf{}, Msgpack{}}
+
+// ByName returns the codec called name, e.g. protobuf.
+func ByName(name string) (Codec, error) {
+	for _, codec := range codecs {
+		if codec.Name() == name {
+			return codec, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown cache codec %q, expected one of %v", name, Names())
+}
+
+func Names() []string {
+	names := make([]string, 0, len(codecs))
+	for _, codec := range codecs {
+		names = append(names, codec.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Envelope writes entries with codec and reads entries of every known codec, so that the codec
+// can be changed without flushing the cache. Payloads longer than compressAbove bytes are compressed,
+// zero disables compression.
+type Envelope struct {
+	codec         Codec
+	compressAbove int
+}
+
+func NewEnvelope(codec Codec, compressAbove int) *Envelope {
+	return &Envelope{codec: codec, compressAbove: compressAbove}
+}
+
+func (e *Envelope) Encode(entry *domain.CachedTweet) ([]byte, error) {
+	payload, err := e.codec.Marshal(entry)
+
+	if err != nil {
+		return nil, err
+	}
+
+	version := e.codec.Version()
+
+	if e.compressAbove > 0 && len(payload) > e.compressAbove {
+		payload = s2.Encode(nil, payload)
+		version |= compressedFlag
+	}
+
+	return append([]byte{version}, payload...), nil
+}
+
+func (e *Envelope) Decode(data []byte) (*domain.CachedTweet, error) {
+	if len(data) == 0 {
+		return nil, ErrUnknownVersion
+	}
+
+	version, payload := data[0], data[1:]
+
+	codec, ok := byVersion(version &^ compressedFlag)
+
+	if !ok {
+		return nil, ErrUnknownVersion
+	}
+
+	if version&compressedFlag != 0 {
+		var err error
+
+		if payload, err = s2.Decode(nil, payload); err != nil {
+			return nil, fmt.Errorf("decompress %s cache entry: %w", codec.Name(), err)
+		}
+	}
+
+	return codec.Unmarshal(payload)
+}
+
+func byVersion(version byte) (Codec, bool) {
+	for _, codec := range codecs {
+		if codec.Version() == version {
+			return codec, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,214 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/google/uuid"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sampleEntry is a cached tweet as written by GetTweet, with textSize characters of text.
+func sampleEntry(textSize int) *domain.CachedTweet {
+	now := time.Now().UTC()
+
+	return &domain.CachedTweet{
+		Tweet: &domain.Tweet{
+			TweetID:       uuid.New(),
+			UserID:        uuid.New(),
+			Text:          strings.Repeat("yata tweet ", textSize/11+1)[:textSize],
+			ImageName:     uuid.NewString() + ".png",
+			RepliesLocked: true,
+			CreatedAt:     now.Add(-time.Hour),
+			UpdatedAt:     now,
+		},
+		Delta:     3 * time.Millisecond,
+		ExpiresAt: now.Add(time.Hour),
+	}
+}
+
+func equalEntries(a *domain.CachedTweet, b *domain.CachedTweet) bool {
+	if a.Delta != b.Delta || !a.ExpiresAt.Equal(b.ExpiresAt) || (a.Tweet == nil) != (b.Tweet == nil) {
+		return false
+	}
+
+	if a.Tweet == nil {
+		return true
+	}
+
+	return a.Tweet.TweetID == b.Tweet.TweetID && a.Tweet.UserID == b.Tweet.UserID && a.Tweet.Text == b.Tweet.Text &&
+		a.Tweet.ImageName == b.Tweet.ImageName && a.Tweet.Hidden == b.Tweet.Hidden && a.Tweet.RepliesLocked == b.Tweet.RepliesLocked &&
+		a.Tweet.CreatedAt.Equal(b.Tweet.CreatedAt) && a.Tweet.UpdatedAt.Equal(b.Tweet.UpdatedAt)
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	missing := &domain.CachedTweet{Delta: time.Millisecond, ExpiresAt: time.Now().Add(30 * time.Second).UTC()}
+
+	tests := []struct {
+		name           string
+		entry          *domain.CachedTweet
+		compressAbove  int
+		wantCompressed bool
+	}{
+		{"tweet", sampleEntry(280), 0, false},
+		{"missing tweet", missing, 0, false},
+		{"compressed", sampleEntry(4000), 512, true},
+		{"below compression threshold", sampleEntry(10), 512, false},
+		{"missing tweet compressed", missing, 1, true},
+	}
+
+	for _, codec := range codecs {
+		for _, tt := range tests {
+			t.Run(codec.Name()+"/"+tt.name, func(t *testing.T) {
+				envelope := NewEnvelope(codec, tt.compressAbove)
+
+				data, err := envelope.Encode(tt.entry)
+
+				if err != nil {
+					t.Fatalf("Encode() = %v", err)
+				}
+
+				if version := data[0] &^ compressedFlag; version != codec.Version() {
+					t.Errorf("version = %d, want %d", version, codec.Version())
+				}
+
+				if compressed := data[0]&compressedFlag != 0; compressed != tt.wantCompressed {
+					t.Errorf("compressed = %v, want %v", compressed, tt.wantCompressed)
+				}
+
+				decoded, err := envelope.Decode(data)
+
+				if err != nil {
+					t.Fatalf("Decode() = %v", err)
+				}
+
+				if !equalEntries(decoded, tt.entry) {
+					t.Errorf("Decode() = %+v, want %+v", decoded, tt.entry)
+				}
+			})
+		}
+	}
+}
+
+// an entry is read whatever codec the reading replica writes with, so the codec can change during a deploy
+func TestEnvelopeDecodesEveryCodec(t *testing.T) {
+	entry := sampleEntry(280)
+
+	for _, writer := range codecs {
+		data, err := NewEnvelope(writer, 1).Encode(entry)
+
+		if err != nil {
+			t.Fatalf("%s: Encode() = %v", writer.Name(), err)
+		}
+
+		for _, reader := range codecs {
+			decoded, err := NewEnvelope(reader, 0).Decode(data)
+
+			if err != nil || !equalEntries(decoded, entry) {
+				t.Errorf("%s entry read by %s: %+v, %v", writer.Name(), reader.Name(), decoded, err)
+			}
+		}
+	}
+}
+
+func TestEnvelopeDecodeUnknown(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"unknown version", []byte{42, '{', '}'}},
+		{"unknown version compressed", []byte{42 | compressedFlag, 0}},
+		{"no version", []byte{0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewEnvelope(Protobuf{}, 0).Decode(tt.data); !errors.Is(err, ErrUnknownVersion) {
+				t.Errorf("Decode() = %v, want %v", err, ErrUnknownVersion)
+			}
+		})
+	}
+}
+
+func TestEnvelopeDecodeCorrupt(t *testing.T) {
+	for _, codec := range codecs {
+		t.Run(codec.Name(), func(t *testing.T) {
+			data := []byte{codec.Version() | compressedFlag, 0xff, 0xff, 0xff}
+
+			if _, err := NewEnvelope(codec, 0).Decode(data); err == nil || errors.Is(err, ErrUnknownVersion) {
+				t.Errorf("Decode() = %v, want a decompression error", err)
+			}
+		})
+	}
+}
+
+func TestByName(t *testing.T) {
+	for _, codec := range codecs {
+		if got, err := ByName(codec.Name()); err != nil || got.Version() != codec.Version() {
+			t.Errorf("ByName(%q) = %v, %v", codec.Name(), got, err)
+		}
+	}
+
+	if _, err := ByName("gob"); err == nil {
+		t.Error("ByName(\"gob\") did not fail")
+	}
+}
+
+// benchmarkSizes are a typical tweet and a long one, which is compressed
+var benchmarkSizes = []int{280, 4000}
+
+func BenchmarkEncode(b *testing.B) {
+	forEachEnvelope(b, func(b *testing.B, envelope *Envelope, entry *domain.CachedTweet, _ []byte) {
+		for i := 0; i < b.N; i++ {
+			if _, err := envelope.Encode(entry); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkDecode(b *testing.B) {
+	forEachEnvelope(b, func(b *testing.B, envelope *Envelope, _ *domain.CachedTweet, data []byte) {
+		for i := 0; i < b.N; i++ {
+			if _, err := envelope.Decode(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// forEachEnvelope runs a sub-benchmark per codec, text size and compression, and reports the size of the entry.
+func forEachEnvelope(b *testing.B, run func(b *testing.B, envelope *Envelope, entry *domain.CachedTweet, data []byte)) {
+	for _, codec := range codecs {
+		for _, textSize := range benchmarkSizes {
+			for _, compressed := range []bool{false, true} {
+				name := fmt.Sprintf("%s/text=%d/compressed=%t", codec.Name(), textSize, compressed)
+
+				b.Run(name, func(b *testing.B) {
+					compressAbove := 0
+					if compressed {
+						compressAbove = 1
+					}
+
+					envelope := NewEnvelope(codec, compressAbove)
+					entry := sampleEntry(textSize)
+
+					data, err := envelope.Encode(entry)
+
+					if err != nil {
+						b.Fatal(err)
+					}
+
+					b.ReportAllocs()
+					b.ReportMetric(float64(len(data)), "bytes/entry")
+					b.ResetTimer()
+
+					run(b, envelope, entry, data)
+				})
+			}
+		}
+	}
+}
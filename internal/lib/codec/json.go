@@ -0,0 +1,31 @@
+package codec
+
+import (
+	"encoding/json"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+)
+
+// JSON is the largest and slowest format, but entries can be read with redis-cli.
+type JSON struct{}
+
+func (JSON) Name() string {
+	return "json"
+}
+
+func (JSON) Version() byte {
+	return VersionJSON
+}
+
+func (JSON) Marshal(entry *domain.CachedTweet) ([]byte, error) {
+	return json.Marshal(entry)
+}
+
+func (JSON) Unmarshal(data []byte) (*domain.CachedTweet, error) {
+	var entry domain.CachedTweet
+
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
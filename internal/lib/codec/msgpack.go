@@ -0,0 +1,44 @@
+package codec
+
+import (
+	"bytes"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Msgpack encodes the json field names of domain.CachedTweet, so it follows the struct without a schema file.
+type Msgpack struct{}
+
+func (Msgpack) Name() string {
+	return "msgpack"
+}
+
+func (Msgpack) Version() byte {
+	return VersionMsgpack
+}
+
+func (Msgpack) Marshal(entry *domain.CachedTweet) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+
+	if err := enc.Encode(entry); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (Msgpack) Unmarshal(data []byte) (*domain.CachedTweet, error) {
+	var entry domain.CachedTweet
+
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+
+	if err := dec.Decode(&entry); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
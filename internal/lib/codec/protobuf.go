@@ -0,0 +1,83 @@
+package codec
+
+import (
+	cachePb "github.com/Verce11o/yata-tweets/gen/go/cache"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Protobuf is the most compact format, the schema is proto/cache/cache.proto.
+type Protobuf struct{}
+
+func (Protobuf) Name() string {
+	return "protobuf"
+}
+
+func (Protobuf) Version() byte {
+	return VersionProtobuf
+}
+
+func (Protobuf) Marshal(entry *domain.CachedTweet) ([]byte, error) {
+	msg := &cachePb.CachedTweet{
+		Delta:     durationpb.New(entry.Delta),
+		ExpiresAt: timestamppb.New(entry.ExpiresAt),
+	}
+
+	if tweet := entry.Tweet; tweet != nil {
+		msg.Tweet = &cachePb.Tweet{
+			TweetId:       tweet.TweetID[:],
+			UserId:        tweet.UserID[:],
+			Text:          tweet.Text,
+			ImageName:     tweet.ImageName,
+			Hidden:        tweet.Hidden,
+			RepliesLocked: tweet.RepliesLocked,
+			CreatedAt:     timestamppb.New(tweet.CreatedAt),
+			UpdatedAt:     timestamppb.New(tweet.UpdatedAt),
+		}
+	}
+
+	return proto.Marshal(msg)
+}
+
+func (Protobuf) Unmarshal(data []byte) (*domain.CachedTweet, error) {
+	var msg cachePb.CachedTweet
+
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+
+	entry := &domain.CachedTweet{
+		Delta:     msg.GetDelta().AsDuration(),
+		ExpiresAt: msg.GetExpiresAt().AsTime(),
+	}
+
+	if tweet := msg.GetTweet(); tweet != nil {
+		tweetID, err := uuid.FromBytes(tweet.GetTweetId())
+
+		if err != nil {
+			return nil, err
+		}
+
+		userID, err := uuid.FromBytes(tweet.GetUserId())
+
+		if err != nil {
+			return nil, err
+		}
+
+		entry.Tweet = &domain.Tweet{
+			TweetID:       tweetID,
+			UserID:        userID,
+			Text:          tweet.GetText(),
+			ImageName:     tweet.GetImageName(),
+			Hidden:        tweet.GetHidden(),
+			RepliesLocked: tweet.GetRepliesLocked(),
+			CreatedAt:     tweet.GetCreatedAt().AsTime(),
+			UpdatedAt:     tweet.GetUpdatedAt().AsTime(),
+		}
+	}
+
+	return entry, nil
+}
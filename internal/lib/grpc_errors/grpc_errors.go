@@ -4,8 +4,18 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"github.com/Verce11o/yata-tweets/internal/domain"
 	"github.com/redis/go-redis/v9"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"strings"
+)
+
+const (
+	ErrorDomain = "tweets.yata"
 )
 
 var (
@@ -13,9 +23,52 @@ var (
 	ErrNotFound         = errors.New("not found")
 	ErrPermissionDenied = errors.New("PermissionDenied")
 	ErrInvalidCursor    = errors.New("invalid pagination cursor")
+	ErrUnauthenticated  = errors.New("Unauthenticated")
+	ErrInvalidKey       = errors.New("invalid idempotency key")
+	ErrIdempotencyKey   = errors.New("idempotency key was used with a different request")
+	ErrRequestInFlight  = errors.New("request with the same idempotency key is in progress")
+	ErrSlowConsumer     = errors.New("stream consumer is too slow, reconnect with the last cursor")
+	ErrCursorExpired    = errors.New("cursor is older than the retained events")
+	ErrFeedClosed       = errors.New("feed is shutting down, reconnect with the last cursor")
+	ErrEditWindowClosed = errors.New("tweet can no longer be edited")
 )
 
+// publicErrors are the sentinels whose text is shown to clients. A new sentinel that clients
+// should understand has to be added here, its message is replaced by the status code otherwise.
+var publicErrors = []error{
+	ErrNotFound,
+	ErrPermissionDenied,
+	ErrInvalidCursor,
+	ErrUnauthenticated,
+	ErrInvalidKey,
+	ErrIdempotencyKey,
+	ErrRequestInFlight,
+	ErrSlowConsumer,
+	ErrCursorExpired,
+	ErrFeedClosed,
+	ErrEditWindowClosed,
+}
+
+var kindCodes = map[domain.ErrorKind]codes.Code{
+	domain.KindInternal:           codes.Internal,
+	domain.KindNotFound:           codes.NotFound,
+	domain.KindAlreadyExists:      codes.AlreadyExists,
+	domain.KindInvalidArgument:    codes.InvalidArgument,
+	domain.KindFailedPrecondition: codes.FailedPrecondition,
+	domain.KindPermissionDenied:   codes.PermissionDenied,
+	domain.KindUnauthenticated:    codes.Unauthenticated,
+	domain.KindAborted:            codes.Aborted,
+	domain.KindUnavailable:        codes.Unavailable,
+	domain.KindDeadlineExceeded:   codes.DeadlineExceeded,
+	domain.KindResourceExhausted:  codes.ResourceExhausted,
+}
+
 func ParseGRPCErrStatusCode(err error) codes.Code {
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		return kindCodes[domainErr.Kind]
+	}
+
 	switch {
 	case errors.Is(err, sql.ErrNoRows):
 		return codes.NotFound
@@ -29,10 +82,105 @@ func ParseGRPCErrStatusCode(err error) codes.Code {
 		return codes.NotFound
 	case errors.Is(err, ErrPermissionDenied):
 		return codes.PermissionDenied
-	case errors.Is(err, ErrInvalidCursor):
+	case errors.Is(err, ErrUnauthenticated):
+		return codes.Unauthenticated
+	case errors.Is(err, ErrIdempotencyKey), errors.Is(err, ErrEditWindowClosed):
+		return codes.FailedPrecondition
+	case errors.Is(err, ErrRequestInFlight):
+		return codes.Aborted
+	case errors.Is(err, ErrSlowConsumer):
+		return codes.ResourceExhausted
+	case errors.Is(err, ErrCursorExpired):
+		return codes.OutOfRange
+	case errors.Is(err, ErrFeedClosed):
+		return codes.Unavailable
+	case errors.Is(err, ErrInvalidCursor), errors.Is(err, ErrInvalidKey):
 		return codes.InvalidArgument
 	case errors.Is(err, redis.Nil):
 		return codes.NotFound
 	}
 	return codes.Internal
 }
+
+// ToGRPCError converts an error returned by the service into a status error for the client.
+// Statuses built upstream (e.g. validation errors) are passed through. Messages of internal
+// errors are replaced, so driver and infrastructure details never reach clients.
+func ToGRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcErr) {
+		return grpcErr.GRPCStatus().Err()
+	}
+
+	code := ParseGRPCErrStatusCode(err)
+
+	var domainErr *domain.Error
+	if !errors.As(err, &domainErr) {
+		domainErr = &domain.Error{Reason: reasonFromCode(code), Message: publicMessage(code, err)}
+	}
+
+	st := status.New(code, domainErr.Message)
+
+	var details []protoadapt.MessageV1
+
+	details = append(details, &errdetails.ErrorInfo{
+		Reason: domainErr.Reason,
+		Domain: ErrorDomain,
+	})
+
+	if domainErr.Resource != "" {
+		details = append(details, &errdetails.ResourceInfo{
+			ResourceType: domainErr.Resource,
+			ResourceName: domainErr.ResourceID,
+		})
+	}
+
+	if domainErr.RetryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{
+			RetryDelay: durationpb.New(domainErr.RetryAfter),
+		})
+	}
+
+	withDetails, detailsErr := st.WithDetails(details...)
+	if detailsErr != nil {
+		return st.Err()
+	}
+
+	return withDetails.Err()
+}
+
+// publicMessage returns the text of errors that are known to be safe to show.
+func publicMessage(code codes.Code, err error) string {
+	switch code {
+	case codes.Internal, codes.Unknown:
+		return "internal error"
+	case codes.Canceled:
+		return "request canceled"
+	case codes.DeadlineExceeded:
+		return "request timed out"
+	}
+
+	for _, sentinel := range publicErrors {
+		if errors.Is(err, sentinel) {
+			return sentinel.Error()
+		}
+	}
+
+	return strings.ToLower(strings.ReplaceAll(code.String(), "_", " "))
+}
+
+func reasonFromCode(code codes.Code) string {
+	var b strings.Builder
+
+	for i, r := range code.String() {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+	}
+
+	return strings.ToUpper(b.String())
+}
@@ -0,0 +1,46 @@
+package grpc_errors
+
+import (
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestToGRPCErrorPublicMessages(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		code    codes.Code
+		message string
+	}{
+		{"not found", ErrNotFound, codes.NotFound, "not found"},
+		{"edit window", ErrEditWindowClosed, codes.FailedPrecondition, "tweet can no longer be edited"},
+		{"wrapped sentinel", fmt.Errorf("update: %w", ErrEditWindowClosed), codes.FailedPrecondition, "tweet can no longer be edited"},
+		{"idempotency key", ErrIdempotencyKey, codes.FailedPrecondition, "idempotency key was used with a different request"},
+		{"internal", ErrAddMinio, codes.Internal, "internal error"},
+		{"unknown error", fmt.Errorf("dial tcp 10.0.0.1:5432: connection refused"), codes.Internal, "internal error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(ToGRPCError(tt.err))
+
+			if st.Code() != tt.code || st.Message() != tt.message {
+				t.Errorf("got %s %q, want %s %q", st.Code(), st.Message(), tt.code, tt.message)
+			}
+		})
+	}
+}
+
+// every sentinel that is not an internal error is meant for clients
+func TestPublicErrorsCoverSentinels(t *testing.T) {
+	sentinels := []error{ErrNotFound, ErrPermissionDenied, ErrInvalidCursor, ErrUnauthenticated, ErrInvalidKey, ErrIdempotencyKey,
+		ErrRequestInFlight, ErrSlowConsumer, ErrCursorExpired, ErrFeedClosed, ErrEditWindowClosed}
+
+	for _, sentinel := range sentinels {
+		if got := publicMessage(ParseGRPCErrStatusCode(sentinel), sentinel); got != sentinel.Error() {
+			t.Errorf("%v is shown as %q", sentinel, got)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package healthcheck
+
+import (
+	"context"
+	"github.com/Verce11o/yata-tweets/config"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"sync"
+	"time"
+)
+
+// Check reports whether a single dependency is usable.
+type Check func(ctx context.Context) error
+
+type dependency struct {
+	name  string
+	check Check
+}
+
+// Checker periodically runs dependency checks and publishes the results through the grpc.health.v1 service.
+// Every dependency is exposed as its own sub-service, while the overall status ("") and
+// the listed services are SERVING only when all dependencies are healthy.
+type Checker struct {
+	log          *zap.SugaredLogger
+	server       *health.Server
+	cfg          config.Health
+	services     []string
+	dependencies []dependency
+	failing      map[string]bool
+	mu           sync.Mutex
+}
+
+func NewChecker(log *zap.SugaredLogger, server *health.Server, cfg config.Health, services ...string) *Checker {
+	c := &Checker{
+		log:      log,
+		server:   server,
+		cfg:      cfg,
+		services: append([]string{""}, services...),
+		failing:  make(map[string]bool),
+	}
+
+	// nothing is checked yet, so don't report ready until the first round passes
+	for _, service := range c.services {
+		server.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+
+	return c
+}
+
+// Add registers a dependency. It must be called before Run.
+func (c *Checker) Add(name string, check Check) {
+	c.dependencies = append(c.dependencies, dependency{name: name, check: check})
+	c.server.SetServingStatus(name, healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+// Run checks all dependencies immediately and then on every interval until ctx is done.
+func (c *Checker) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.checkAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Shutdown marks every service NOT_SERVING and ignores further check results.
+func (c *Checker) Shutdown() {
+	c.server.Shutdown()
+}
+
+func (c *Checker) checkAll(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	healthy := true
+
+	for _, dep := range c.dependencies {
+		wg.Add(1)
+
+		go func(dep dependency) {
+			defer wg.Done()
+
+			if !c.checkOne(ctx, dep) {
+				c.mu.Lock()
+				healthy = false
+				c.mu.Unlock()
+			}
+		}(dep)
+	}
+
+	wg.Wait()
+
+	status := healthpb.HealthCheckResponse_SERVING
+
+	if !healthy {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	for _, service := range c.services {
+		c.server.SetServingStatus(service, status)
+	}
+}
+
+func (c *Checker) checkOne(ctx context.Context, dep dependency) bool {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	err := dep.check(ctx)
+
+	c.mu.Lock()
+	wasFailing := c.failing[dep.name]
+	c.failing[dep.name] = err != nil
+	c.mu.Unlock()
+
+	if err != nil {
+		// log transitions only, a dead dependency would flood the log otherwise
+		if !wasFailing {
+			c.log.Errorf("health check %s failed: %v", dep.name, err.Error())
+		}
+
+		c.server.SetServingStatus(dep.name, healthpb.HealthCheckResponse_NOT_SERVING)
+
+		return false
+	}
+
+	if wasFailing {
+		c.log.Infof("health check %s recovered", dep.name)
+	}
+
+	c.server.SetServingStatus(dep.name, healthpb.HealthCheckResponse_SERVING)
+
+	return true
+}
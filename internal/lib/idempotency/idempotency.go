@@ -0,0 +1,66 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/Verce11o/yata-tweets/internal/lib/grpc_errors"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"unicode"
+)
+
+const (
+	Header       = "idempotency-key"
+	maxKeyLength = 255
+)
+
+type keyCtx struct{}
+
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyCtx{}, key)
+}
+
+func KeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(keyCtx{}).(string)
+	return key
+}
+
+// KeyFromMetadata returns the Idempotency-Key sent by the client, or an empty string if there is none.
+func KeyFromMetadata(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", nil
+	}
+
+	values := md.Get(Header)
+	if len(values) == 0 {
+		return "", nil
+	}
+
+	key := values[0]
+
+	if key == "" || len(key) > maxKeyLength {
+		return "", grpc_errors.ErrInvalidKey
+	}
+
+	for _, r := range key {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return "", grpc_errors.ErrInvalidKey
+		}
+	}
+
+	return key, nil
+}
+
+// HashRequest fingerprints the request so that key reuse with a different payload can be detected.
+func HashRequest(request proto.Message) (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
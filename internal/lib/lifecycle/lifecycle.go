@@ -0,0 +1,91 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.uber.org/zap"
+	"time"
+)
+
+// Phase groups shutdown hooks. Phases run in the order they are declared.
+type Phase int
+
+const (
+	// PhaseStopTraffic stops accepting new requests.
+	PhaseStopTraffic Phase = iota
+	// PhaseDrain waits for in-flight requests to finish.
+	PhaseDrain
+	// PhaseFlush flushes buffered messages and telemetry.
+	PhaseFlush
+	// PhaseClose closes clients and connections.
+	PhaseClose
+
+	phases
+)
+
+// StopFunc stops a single resource. It should give up when ctx is done.
+type StopFunc func(ctx context.Context) error
+
+type hook struct {
+	name string
+	stop StopFunc
+}
+
+// Manager shuts resources down in phases. Inside a phase, hooks run in registration order.
+type Manager struct {
+	log          *zap.SugaredLogger
+	phaseTimeout time.Duration
+	hooks        [phases][]hook
+}
+
+// NewManager gives every phase phaseTimeout, so a drain that runs out of time still leaves
+// the later phases their own time to flush and close.
+func NewManager(log *zap.SugaredLogger, phaseTimeout time.Duration) *Manager {
+	return &Manager{log: log, phaseTimeout: phaseTimeout}
+}
+
+func (m *Manager) Add(phase Phase, name string, stop StopFunc) {
+	m.hooks[phase] = append(m.hooks[phase], hook{name: name, stop: stop})
+}
+
+// Close registers a resource whose Close method does not take a context.
+func (m *Manager) Close(name string, closeFn func() error) {
+	m.Add(PhaseClose, name, func(context.Context) error {
+		return closeFn()
+	})
+}
+
+// Shutdown runs every hook, even after an earlier phase timed out, so that clients still get closed.
+// Only the values of ctx are passed on, each phase gets a fresh deadline.
+// Errors are logged as they happen and returned joined together.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	for phase := range m.hooks {
+		errs = append(errs, m.runPhase(ctx, Phase(phase))...)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (m *Manager) runPhase(ctx context.Context, phase Phase) []error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.phaseTimeout)
+	defer cancel()
+
+	var errs []error
+
+	for _, h := range m.hooks[phase] {
+		start := time.Now()
+
+		if err := h.stop(ctx); err != nil {
+			m.log.Errorf("cannot stop %s: %v", h.name, err.Error())
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+			continue
+		}
+
+		m.log.Infof("stopped %s in %s", h.name, time.Since(start))
+	}
+
+	return errs
+}
@@ -0,0 +1,70 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"go.uber.org/zap"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestShutdownOrder(t *testing.T) {
+	m := NewManager(zap.NewNop().Sugar(), time.Second)
+
+	var stopped []string
+	stop := func(name string) StopFunc {
+		return func(context.Context) error {
+			stopped = append(stopped, name)
+			return nil
+		}
+	}
+
+	m.Close("postgres", func() error { return stop("postgres")(context.Background()) })
+	m.Add(PhaseFlush, "publisher", stop("publisher"))
+	m.Add(PhaseDrain, "grpc server", stop("grpc server"))
+	m.Add(PhaseDrain, "scheduler", stop("scheduler"))
+	m.Add(PhaseStopTraffic, "health checker", stop("health checker"))
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() = %v", err)
+	}
+
+	want := []string{"health checker", "grpc server", "scheduler", "publisher", "postgres"}
+	if !reflect.DeepEqual(stopped, want) {
+		t.Errorf("stopped %v, want %v", stopped, want)
+	}
+}
+
+func TestShutdownPhaseTimeout(t *testing.T) {
+	m := NewManager(zap.NewNop().Sugar(), 50*time.Millisecond)
+
+	// the drain uses up its whole phase
+	m.Add(PhaseDrain, "grpc server", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	var flushErr error
+	m.Add(PhaseFlush, "publisher", func(ctx context.Context) error {
+		flushErr = ctx.Err()
+		return nil
+	})
+
+	closeFailed := errors.New("connection reset")
+	m.Close("rabbitmq", func() error { return closeFailed })
+
+	// the parent expiring does not cut the phases short either
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := m.Shutdown(ctx)
+
+	if flushErr != nil {
+		t.Errorf("flush phase started with a done context: %v", flushErr)
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, closeFailed) {
+		t.Errorf("Shutdown() = %v, want the drain timeout and the close error", err)
+	}
+}
@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"context"
+	"github.com/Verce11o/yata-tweets/internal/lib/auth"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+type requestIDCtx struct{}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDCtx{}, requestID)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDCtx{}).(string)
+	return requestID
+}
+
+// Ctx returns log annotated with what ctx knows about the current request:
+// trace_id and span_id of the active span, request_id, the RPC method and the authenticated user.
+func Ctx(ctx context.Context, log *zap.SugaredLogger) *zap.SugaredLogger {
+	var fields []interface{}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		fields = append(fields, "trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+	}
+
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		fields = append(fields, "request_id", requestID)
+	}
+
+	if method, ok := grpc.Method(ctx); ok {
+		fields = append(fields, "grpc.method", method)
+	}
+
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		fields = append(fields, "user_id", principal.UserID)
+	}
+
+	if len(fields) == 0 {
+		return log
+	}
+
+	return log.With(fields...)
+}
@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"context"
+	"github.com/Verce11o/yata-tweets/internal/lib/auth"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+const (
+	RequestIDHeader = "x-request-id"
+	maxRequestID    = 128
+)
+
+// UnaryServerInterceptor assigns a request id and logs every finished RPC with its status code and latency.
+// It has to run before the auth interceptor, so that rejected calls are logged too, and logs the user it authenticated.
+func UnaryServerInterceptor(log *zap.SugaredLogger) grpc.UnaryServerInterceptor {
+	log = withoutStacktrace(log)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = auth.WithPrincipalHolder(withRequestID(ctx))
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+		logRPC(ctx, log, start, err)
+
+		return resp, err
+	}
+}
+
+func StreamServerInterceptor(log *zap.SugaredLogger) grpc.StreamServerInterceptor {
+	log = withoutStacktrace(log)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := auth.WithPrincipalHolder(withRequestID(ss.Context()))
+		start := time.Now()
+
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		logRPC(ctx, log, start, err)
+
+		return err
+	}
+}
+
+// withRequestID keeps the request id sent by the client, or generates one, and echoes it in the response header.
+func withRequestID(ctx context.Context) context.Context {
+	var requestID string
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIDHeader); len(values) > 0 && len(values[0]) <= maxRequestID {
+			requestID = values[0]
+		}
+	}
+
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, requestID))
+
+	return WithRequestID(ctx, requestID)
+}
+
+func logRPC(ctx context.Context, log *zap.SugaredLogger, start time.Time, err error) {
+	code := status.Code(err)
+	l := Ctx(ctx, log).With("grpc.code", code.String(), "latency", time.Since(start))
+
+	switch code {
+	case codes.OK, codes.Canceled, codes.NotFound, codes.InvalidArgument, codes.AlreadyExists, codes.Unauthenticated:
+		l.Info("finished rpc")
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable, codes.DeadlineExceeded, codes.Unimplemented:
+		l.Errorw("finished rpc", "error", status.Convert(err).Message())
+	default:
+		l.Warnw("finished rpc", "error", status.Convert(err).Message())
+	}
+}
+
+// withoutStacktrace drops stack traces from RPC logs, they would only ever point at the interceptor.
+func withoutStacktrace(log *zap.SugaredLogger) *zap.SugaredLogger {
+	return log.WithOptions(zap.AddStacktrace(zapcore.DPanicLevel))
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
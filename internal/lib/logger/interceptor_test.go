@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"context"
+	"github.com/Verce11o/yata-tweets/config"
+	"github.com/Verce11o/yata-tweets/internal/lib/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"testing"
+	"time"
+)
+
+func TestUnaryServerInterceptorLogsAuthentication(t *testing.T) {
+	verifier, err := auth.NewVerifier(config.Auth{Secret: "secret"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user", "exp": time.Now().Add(time.Hour).Unix()}).SignedString([]byte("secret"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantCode      codes.Code
+		wantUser      interface{}
+	}{
+		{"authenticated", "Bearer " + token, codes.OK, "user"},
+		{"rejected", "Bearer forged", codes.Unauthenticated, nil},
+		{"anonymous", "", codes.OK, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zap.InfoLevel)
+			logging := UnaryServerInterceptor(zap.New(core).Sugar())
+			authenticating := auth.UnaryServerInterceptor(verifier)
+			info := &grpc.UnaryServerInfo{FullMethod: "/tweets.Tweets/CreateTweet"}
+
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDHeader, "request"))
+			if tt.authorization != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(RequestIDHeader, "request", "authorization", tt.authorization))
+			}
+
+			_, _ = logging(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return authenticating(ctx, req, info, func(context.Context, interface{}) (interface{}, error) {
+					return nil, nil
+				})
+			})
+
+			entries := logs.FilterMessage("finished rpc").All()
+
+			if len(entries) != 1 {
+				t.Fatalf("%d rpcs logged, want 1", len(entries))
+			}
+
+			fields := entries[0].ContextMap()
+
+			if fields["grpc.code"] != tt.wantCode.String() || fields["request_id"] != "request" || fields["user_id"] != tt.wantUser {
+				t.Errorf("logged %v, want code %v and user %v", fields, tt.wantCode, tt.wantUser)
+			}
+		})
+	}
+}
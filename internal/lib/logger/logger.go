@@ -1,31 +1,59 @@
 package logger
 
 import (
+	"github.com/Verce11o/yata-tweets/config"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+const (
+	EncodingJSON    = "json"
+	EncodingConsole = "console"
+)
+
 type Logger struct {
 	log *zap.SugaredLogger
 }
 
-func NewLogger() *zap.SugaredLogger {
+// NewLogger builds the logger described by cfg. The returned level can be changed at runtime.
+func NewLogger(cfg config.Logger) (*zap.SugaredLogger, zap.AtomicLevel) {
+	level, err := zap.ParseAtomicLevel(cfg.Level)
+
+	if err != nil {
+		panic(err)
+	}
+
+	encodeLevel := zapcore.LowercaseLevelEncoder
+
+	if cfg.Encoding == EncodingConsole {
+		encodeLevel = zapcore.CapitalColorLevelEncoder
+	}
+
+	var sampling *zap.SamplingConfig
+
+	if cfg.Sampling.Initial > 0 {
+		sampling = &zap.SamplingConfig{Initial: cfg.Sampling.Initial, Thereafter: cfg.Sampling.Thereafter}
+	}
+
 	logger, err := zap.Config{
-		Level:             zap.NewAtomicLevelAt(zapcore.DebugLevel),
+		Level:             level,
 		DisableStacktrace: false,
-		Sampling:          nil,
-		Encoding:          "console",
+		Sampling:          sampling,
+		Encoding:          cfg.Encoding,
 		EncoderConfig: zapcore.EncoderConfig{
 			MessageKey: "message",
 
 			LevelKey:    "level",
-			EncodeLevel: zapcore.CapitalColorLevelEncoder,
+			EncodeLevel: encodeLevel,
 
 			TimeKey:    "time",
 			EncodeTime: zapcore.ISO8601TimeEncoder,
 
 			CallerKey:    "caller",
 			EncodeCaller: zapcore.ShortCallerEncoder,
+
+			StacktraceKey:  "stacktrace",
+			EncodeDuration: zapcore.MillisDurationEncoder,
 		},
 
 		OutputPaths:      []string{"stderr"},
@@ -36,5 +64,5 @@ func NewLogger() *zap.SugaredLogger {
 		panic(err)
 	}
 
-	return logger.Sugar()
+	return logger.Sugar(), level
 }
@@ -0,0 +1,157 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+// op is an Add when ttl is set and a Get otherwise, after advancing the clock by after.
+type op struct {
+	after time.Duration
+	key   string
+	value int
+	ttl   time.Duration
+	// want is the value a Get should find, zero for a miss
+	want int
+}
+
+func TestCache(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		ops       []op
+		wantStats Stats
+	}{
+		{
+			name: "hit and miss",
+			size: 2,
+			ops: []op{
+				{key: "a", value: 1, ttl: time.Minute},
+				{key: "a", want: 1},
+				{key: "b"},
+			},
+			wantStats: Stats{Hits: 1, Misses: 1, Entries: 1},
+		},
+		{
+			name: "least recently added is evicted",
+			size: 2,
+			ops: []op{
+				{key: "a", value: 1, ttl: time.Minute},
+				{key: "b", value: 2, ttl: time.Minute},
+				{key: "c", value: 3, ttl: time.Minute},
+				{key: "a"},
+				{key: "b", want: 2},
+				{key: "c", want: 3},
+			},
+			wantStats: Stats{Hits: 2, Misses: 1, Evictions: 1, Entries: 2},
+		},
+		{
+			name: "get keeps an entry",
+			size: 2,
+			ops: []op{
+				{key: "a", value: 1, ttl: time.Minute},
+				{key: "b", value: 2, ttl: time.Minute},
+				{key: "a", want: 1},
+				{key: "c", value: 3, ttl: time.Minute},
+				{key: "a", want: 1},
+				{key: "b"},
+			},
+			wantStats: Stats{Hits: 2, Misses: 1, Evictions: 1, Entries: 2},
+		},
+		{
+			name: "replacing does not evict",
+			size: 2,
+			ops: []op{
+				{key: "a", value: 1, ttl: time.Minute},
+				{key: "b", value: 2, ttl: time.Minute},
+				{key: "a", value: 10, ttl: time.Minute},
+				{key: "a", want: 10},
+				{key: "b", want: 2},
+			},
+			wantStats: Stats{Hits: 2, Entries: 2},
+		},
+		{
+			name: "expired",
+			size: 2,
+			ops: []op{
+				{key: "a", value: 1, ttl: time.Minute},
+				{after: 59 * time.Second, key: "a", want: 1},
+				{after: 2 * time.Second, key: "a"},
+			},
+			wantStats: Stats{Hits: 1, Misses: 1},
+		},
+		{
+			name: "replacing renews the ttl",
+			size: 2,
+			ops: []op{
+				{key: "a", value: 1, ttl: time.Minute},
+				{after: 50 * time.Second, key: "a", value: 2, ttl: time.Minute},
+				{after: 50 * time.Second, key: "a", want: 2},
+			},
+			wantStats: Stats{Hits: 1, Entries: 1},
+		},
+		{
+			name: "negative ttl removes",
+			size: 2,
+			ops: []op{
+				{key: "a", value: 1, ttl: time.Minute},
+				{key: "a", value: 2, ttl: -1},
+				{key: "a"},
+			},
+			wantStats: Stats{Misses: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+			cache := New[string, int](tt.size)
+			cache.now = func() time.Time { return now }
+
+			for i, o := range tt.ops {
+				now = now.Add(o.after)
+
+				if o.ttl != 0 {
+					cache.Add(o.key, o.value, o.ttl)
+					continue
+				}
+
+				got, ok := cache.Get(o.key)
+
+				if ok != (o.want != 0) || got != o.want {
+					t.Fatalf("op %d: Get(%s) = %d, %v, want %d", i, o.key, got, ok, o.want)
+				}
+			}
+
+			if stats := cache.Stats(); stats != tt.wantStats {
+				t.Errorf("Stats() = %+v, want %+v", stats, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestCacheRemoveAndPurge(t *testing.T) {
+	cache := New[string, int](4)
+	cache.Add("a", 1, time.Minute)
+	cache.Add("b", 2, time.Minute)
+
+	cache.Remove("a")
+	cache.Remove("missing")
+
+	if _, ok := cache.Get("a"); ok {
+		t.Error("removed entry was found")
+	}
+
+	cache.Purge()
+
+	if _, ok := cache.Get("b"); ok || cache.Stats().Entries != 0 {
+		t.Errorf("purged cache has %d entries", cache.Stats().Entries)
+	}
+
+	// the cache is still usable after a purge
+	cache.Add("c", 3, time.Minute)
+
+	if v, ok := cache.Get("c"); !ok || v != 3 {
+		t.Errorf("Get(c) = %d, %v after purge", v, ok)
+	}
+}
@@ -2,31 +2,40 @@ package rabbitmq
 
 import (
 	"context"
+	"errors"
 	"github.com/Verce11o/yata-tweets/config"
 	"github.com/google/uuid"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"sync"
 	"time"
 )
 
+var ErrPublisherClosed = errors.New("publisher is closed")
+
 type TweetPublisher struct {
 	AmqpConn *amqp.Connection
 	log      *zap.SugaredLogger
 	trace    trace.Tracer
 	cfg      config.RabbitMQ
+
+	mu       sync.Mutex
+	ch       *amqp.Channel
+	closed   bool
+	inflight sync.WaitGroup
 }
 
 func NewTweetPublisher(amqpConn *amqp.Connection, log *zap.SugaredLogger, trace trace.Tracer, cfg config.RabbitMQ) *TweetPublisher {
 	return &TweetPublisher{AmqpConn: amqpConn, log: log, trace: trace, cfg: cfg}
 }
 
-func (c *TweetPublisher) createChannel(exchangeName string, queueName string, bindingKey string) *amqp.Channel {
+func (c *TweetPublisher) createChannel(exchangeName string, queueName string, bindingKey string) (*amqp.Channel, error) {
 
 	ch, err := c.AmqpConn.Channel()
 
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	err = ch.ExchangeDeclare(
@@ -40,7 +49,8 @@ func (c *TweetPublisher) createChannel(exchangeName string, queueName string, bi
 	)
 
 	if err != nil {
-		panic(err)
+		ch.Close()
+		return nil, err
 	}
 
 	queue, err := ch.QueueDeclare(
@@ -53,7 +63,8 @@ func (c *TweetPublisher) createChannel(exchangeName string, queueName string, bi
 	)
 
 	if err != nil {
-		panic(err)
+		ch.Close()
+		return nil, err
 	}
 
 	err = ch.QueueBind(
@@ -65,16 +76,50 @@ func (c *TweetPublisher) createChannel(exchangeName string, queueName string, bi
 	)
 
 	if err != nil {
-		panic(err)
+		ch.Close()
+		return nil, err
 	}
 
-	return ch
+	return ch, nil
 
 }
 
+// channel returns the shared channel, reopening it if the broker closed it. c.mu must be held.
+func (c *TweetPublisher) channel() (*amqp.Channel, error) {
+	if c.ch != nil && !c.ch.IsClosed() {
+		return c.ch, nil
+	}
+
+	ch, err := c.createChannel(c.cfg.ExchangeName, c.cfg.QueueName, c.cfg.BindingKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.ch = ch
+
+	return ch, nil
+}
+
 func (c *TweetPublisher) Publish(ctx context.Context, message []byte) error {
+	c.mu.Lock()
 
-	ch := c.createChannel(c.cfg.ExchangeName, c.cfg.QueueName, c.cfg.BindingKey)
+	if c.closed {
+		c.mu.Unlock()
+		return ErrPublisherClosed
+	}
+
+	ch, err := c.channel()
+
+	if err != nil {
+		c.mu.Unlock()
+		return err
+	}
+
+	c.inflight.Add(1)
+	c.mu.Unlock()
+
+	defer c.inflight.Done()
 
 	if err := ch.PublishWithContext(
 		ctx,
@@ -96,3 +141,32 @@ func (c *TweetPublisher) Publish(ctx context.Context, message []byte) error {
 	return nil
 
 }
+
+// Close rejects new messages, waits for in-flight publishes to finish and closes the channel.
+func (c *TweetPublisher) Close(ctx context.Context) error {
+	c.mu.Lock()
+	c.closed = true
+	ch := c.ch
+	c.mu.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		c.inflight.Wait()
+		close(done)
+	}()
+
+	var err error
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if ch != nil && !ch.IsClosed() {
+		return errors.Join(err, ch.Close())
+	}
+
+	return err
+}
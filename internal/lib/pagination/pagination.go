@@ -12,7 +12,7 @@ import (
 func DecodeCursor(encodedCursor string) (time.Time, uuid.UUID, error) {
 	byt, err := base64.StdEncoding.DecodeString(encodedCursor)
 	if err != nil {
-		return time.Time{}, [16]byte{}, err
+		return time.Time{}, [16]byte{}, fmt.Errorf("%w: %v", grpc_errors.ErrInvalidCursor, err)
 	}
 
 	arrStr := strings.Split(string(byt), ",")
@@ -23,12 +23,12 @@ func DecodeCursor(encodedCursor string) (time.Time, uuid.UUID, error) {
 
 	res, err := time.Parse(time.RFC3339Nano, arrStr[0])
 	if err != nil {
-		return time.Time{}, [16]byte{}, err
+		return time.Time{}, [16]byte{}, fmt.Errorf("%w: %v", grpc_errors.ErrInvalidCursor, err)
 	}
 
 	tweetID, err := uuid.Parse(arrStr[1])
 	if err != nil {
-		return time.Time{}, [16]byte{}, err
+		return time.Time{}, [16]byte{}, fmt.Errorf("%w: %v", grpc_errors.ErrInvalidCursor, err)
 	}
 
 	return res, tweetID, nil
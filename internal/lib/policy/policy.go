@@ -0,0 +1,129 @@
+package policy
+
+import (
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/lib/auth"
+	"github.com/Verce11o/yata-tweets/internal/lib/grpc_errors"
+)
+
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+type Permission string
+
+const (
+	PermissionDeleteAnyTweet Permission = "tweet:delete:any"
+	PermissionHideTweet      Permission = "tweet:hide"
+	PermissionLockReplies    Permission = "tweet:lock_replies"
+	PermissionViewHidden     Permission = "tweet:view_hidden"
+)
+
+type Action string
+
+const (
+	ActionUpdateTweet   Action = "tweet.update"
+	ActionDeleteTweet   Action = "tweet.delete"
+	ActionHideTweet     Action = "tweet.hide"
+	ActionUnhideTweet   Action = "tweet.unhide"
+	ActionLockReplies   Action = "tweet.lock_replies"
+	ActionUnlockReplies Action = "tweet.unlock_replies"
+	ActionViewTweet     Action = "tweet.view"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleUser: nil,
+	RoleModerator: {
+		PermissionDeleteAnyTweet,
+		PermissionHideTweet,
+		PermissionLockReplies,
+		PermissionViewHidden,
+	},
+	RoleAdmin: {
+		PermissionDeleteAnyTweet,
+		PermissionHideTweet,
+		PermissionLockReplies,
+		PermissionViewHidden,
+	},
+}
+
+// Decision is the outcome of an authorization check.
+// Privileged is set when access was granted by a role rather than by ownership;
+// such actions have to be recorded in the audit log.
+type Decision struct {
+	Privileged bool
+}
+
+// Has reports whether any of the principal roles grants the permission.
+func Has(principal *auth.Principal, permission Permission) bool {
+	if principal == nil {
+		return false
+	}
+
+	for _, role := range principal.Roles {
+		for _, p := range rolePermissions[Role(role)] {
+			if p == permission {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Authorize decides whether the principal may perform the action on the tweet.
+func Authorize(principal *auth.Principal, action Action, tweet *domain.Tweet) (Decision, error) {
+	if action == ActionViewTweet {
+		return authorizeView(principal, tweet)
+	}
+
+	if principal == nil {
+		return Decision{}, grpc_errors.ErrUnauthenticated
+	}
+
+	owner := tweet.UserID.String() == principal.UserID
+
+	switch action {
+	case ActionUpdateTweet:
+		if owner {
+			return Decision{}, nil
+		}
+	case ActionDeleteTweet:
+		if owner {
+			return Decision{}, nil
+		}
+		if Has(principal, PermissionDeleteAnyTweet) {
+			return Decision{Privileged: true}, nil
+		}
+	case ActionHideTweet, ActionUnhideTweet:
+		if Has(principal, PermissionHideTweet) {
+			return Decision{Privileged: true}, nil
+		}
+	case ActionLockReplies, ActionUnlockReplies:
+		if owner {
+			return Decision{}, nil
+		}
+		if Has(principal, PermissionLockReplies) {
+			return Decision{Privileged: true}, nil
+		}
+	}
+
+	return Decision{}, grpc_errors.ErrPermissionDenied
+}
+
+func authorizeView(principal *auth.Principal, tweet *domain.Tweet) (Decision, error) {
+	if !tweet.Hidden {
+		return Decision{}, nil
+	}
+
+	if principal != nil && (tweet.UserID.String() == principal.UserID || Has(principal, PermissionViewHidden)) {
+		return Decision{}, nil
+	}
+
+	// hidden tweets must look like they do not exist
+	return Decision{}, grpc_errors.ErrNotFound
+}
@@ -0,0 +1,70 @@
+package policy
+
+import (
+	"errors"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/lib/auth"
+	"github.com/Verce11o/yata-tweets/internal/lib/grpc_errors"
+	"github.com/google/uuid"
+	"testing"
+)
+
+func TestAuthorize(t *testing.T) {
+	ownerID := uuid.New()
+
+	owner := &auth.Principal{UserID: ownerID.String(), Roles: []string{string(RoleUser)}}
+	stranger := &auth.Principal{UserID: uuid.NewString(), Roles: []string{string(RoleUser)}}
+	moderator := &auth.Principal{UserID: uuid.NewString(), Roles: []string{string(RoleUser), string(RoleModerator)}}
+	admin := &auth.Principal{UserID: uuid.NewString(), Roles: []string{string(RoleAdmin)}}
+	unknownRole := &auth.Principal{UserID: uuid.NewString(), Roles: []string{"superuser"}}
+
+	tweet := &domain.Tweet{TweetID: uuid.New(), UserID: ownerID}
+	hidden := &domain.Tweet{TweetID: uuid.New(), UserID: ownerID, Hidden: true}
+
+	tests := []struct {
+		name           string
+		principal      *auth.Principal
+		action         Action
+		tweet          *domain.Tweet
+		wantPrivileged bool
+		wantErr        error
+	}{
+		{"owner updates", owner, ActionUpdateTweet, tweet, false, nil},
+		{"moderator cannot update", moderator, ActionUpdateTweet, tweet, false, grpc_errors.ErrPermissionDenied},
+		{"anonymous update", nil, ActionUpdateTweet, tweet, false, grpc_errors.ErrUnauthenticated},
+
+		{"owner deletes", owner, ActionDeleteTweet, tweet, false, nil},
+		{"stranger deletes", stranger, ActionDeleteTweet, tweet, false, grpc_errors.ErrPermissionDenied},
+		{"moderator deletes", moderator, ActionDeleteTweet, tweet, true, nil},
+		{"admin deletes", admin, ActionDeleteTweet, tweet, true, nil},
+		{"unknown role deletes", unknownRole, ActionDeleteTweet, tweet, false, grpc_errors.ErrPermissionDenied},
+
+		{"owner cannot hide", owner, ActionHideTweet, tweet, false, grpc_errors.ErrPermissionDenied},
+		{"moderator hides", moderator, ActionHideTweet, tweet, true, nil},
+		{"moderator unhides", moderator, ActionUnhideTweet, hidden, true, nil},
+
+		{"owner locks replies", owner, ActionLockReplies, tweet, false, nil},
+		{"stranger locks replies", stranger, ActionLockReplies, tweet, false, grpc_errors.ErrPermissionDenied},
+		{"admin unlocks replies", admin, ActionUnlockReplies, tweet, true, nil},
+
+		{"anonymous views", nil, ActionViewTweet, tweet, false, nil},
+		{"anonymous views hidden", nil, ActionViewTweet, hidden, false, grpc_errors.ErrNotFound},
+		{"stranger views hidden", stranger, ActionViewTweet, hidden, false, grpc_errors.ErrNotFound},
+		{"owner views hidden", owner, ActionViewTweet, hidden, false, nil},
+		{"moderator views hidden", moderator, ActionViewTweet, hidden, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := Authorize(tt.principal, tt.action, tt.tweet)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authorize() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if decision.Privileged != tt.wantPrivileged {
+				t.Errorf("Authorize() privileged = %v, want %v", decision.Privileged, tt.wantPrivileged)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package ratelimit
+
+import (
+	"context"
+	"go.uber.org/zap"
+)
+
+// FallbackLimiter uses the primary limiter and switches to the fallback one
+// for requests where the primary fails, e.g. while Redis is unreachable.
+type FallbackLimiter struct {
+	primary  Limiter
+	fallback Limiter
+	log      *zap.SugaredLogger
+}
+
+func NewFallbackLimiter(primary Limiter, fallback Limiter, log *zap.SugaredLogger) *FallbackLimiter {
+	return &FallbackLimiter{primary: primary, fallback: fallback, log: log}
+}
+
+func (f *FallbackLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	res, err := f.primary.Allow(ctx, key, limit)
+
+	if err == nil {
+		return res, nil
+	}
+
+	f.log.Warnf("rate limiter unavailable, using fallback: %v", err)
+
+	return f.fallback.Allow(ctx, key, limit)
+}
@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"github.com/Verce11o/yata-tweets/internal/lib/auth"
+	"github.com/Verce11o/yata-tweets/internal/lib/settings"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"net"
+	"path"
+)
+
+const (
+	keyPrefix = "ratelimit"
+	// ClientIPHeader carries the address of an HTTP client, the gateway sets it on the calls it forwards.
+	// It is only honoured on loopback connections, the gateway's own, and removed from any other call.
+	ClientIPHeader      = "x-yata-client-ip"
+	rateLimitedResponse = "rate limit exceeded"
+)
+
+// UnaryServerInterceptor enforces per-user and per-IP quotas of the methods listed in rateLimit.methods.
+// Methods are referenced by their short name, e.g. CreateTweet. It has to run after the auth interceptor.
+// Quotas are read from the current settings, so they can be changed at runtime.
+func UnaryServerInterceptor(limiter Limiter, settings *settings.Store, log *zap.SugaredLogger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ip, ctx := clientIP(ctx)
+		method := path.Base(info.FullMethod)
+		cfg := settings.Get().RateLimit
+
+		q, ok := cfg.Methods[method]
+		if !cfg.Enabled || !ok {
+			return handler(ctx, req)
+		}
+
+		perUser, perIP := NewLimit(q.PerUser), NewLimit(q.PerIP)
+
+		if principal, ok := auth.PrincipalFromContext(ctx); ok && perUser.Enabled() {
+			if err := check(ctx, limiter, fmt.Sprintf("%s:%s:user:%s", keyPrefix, method, principal.UserID), perUser, log); err != nil {
+				return nil, err
+			}
+		}
+
+		if ip != "" && perIP.Enabled() {
+			if err := check(ctx, limiter, fmt.Sprintf("%s:%s:ip:%s", keyPrefix, method, ip), perIP, log); err != nil {
+				return nil, err
+			}
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func check(ctx context.Context, limiter Limiter, key string, limit Limit, log *zap.SugaredLogger) error {
+	res, err := limiter.Allow(ctx, key, limit)
+
+	if err != nil {
+		// fail open, losing the limiter must not take the service down
+		log.Errorf("cannot check rate limit for %s: %v", key, err)
+		return nil
+	}
+
+	if res.Allowed {
+		return nil
+	}
+
+	st, err := status.New(codes.ResourceExhausted, rateLimitedResponse).WithDetails(&errdetails.RetryInfo{
+		RetryDelay: durationpb.New(res.RetryAfter),
+	})
+
+	if err != nil {
+		return status.Error(codes.ResourceExhausted, rateLimitedResponse)
+	}
+
+	return st.Err()
+}
+
+// clientIP returns the address of the client and ctx without a ClientIPHeader it must not trust.
+func clientIP(ctx context.Context) (string, context.Context) {
+	host := peerHost(ctx)
+	md, ok := metadata.FromIncomingContext(ctx)
+
+	if !ok || len(md.Get(ClientIPHeader)) == 0 {
+		return host, ctx
+	}
+
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		values := md.Get(ClientIPHeader)
+		return values[len(values)-1], ctx
+	}
+
+	md = md.Copy()
+	md.Delete(ClientIPHeader)
+
+	return host, metadata.NewIncomingContext(ctx, md)
+}
+
+func peerHost(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"context"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name     string
+		peer     string
+		header   []string
+		want     string
+		stripped bool
+	}{
+		{"direct", "203.0.113.7", nil, "203.0.113.7", false},
+		{"gateway", "127.0.0.1", []string{"198.51.100.1"}, "198.51.100.1", false},
+		{"gateway over ipv6", "::1", []string{"198.51.100.1"}, "198.51.100.1", false},
+		{"gateway without header", "127.0.0.1", nil, "127.0.0.1", false},
+		{"spoofed by a remote caller", "203.0.113.7", []string{"198.51.100.1"}, "203.0.113.7", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(tt.peer), Port: 50000}})
+			md := metadata.MD{"authorization": []string{"Bearer token"}}
+			if tt.header != nil {
+				md.Set(ClientIPHeader, tt.header...)
+			}
+			ctx = metadata.NewIncomingContext(ctx, md)
+
+			got, ctx := clientIP(ctx)
+
+			if got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+
+			forwarded, _ := metadata.FromIncomingContext(ctx)
+
+			if stripped := tt.header != nil && len(forwarded.Get(ClientIPHeader)) == 0; stripped != tt.stripped {
+				t.Errorf("header stripped = %v, want %v", stripped, tt.stripped)
+			}
+
+			if len(forwarded.Get("authorization")) != 1 {
+				t.Errorf("other metadata was dropped: %v", forwarded)
+			}
+		})
+	}
+}
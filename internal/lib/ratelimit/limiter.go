@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/Verce11o/yata-tweets/config"
+	"time"
+)
+
+// Limit allows Rate requests per Period with bursts of up to Burst requests.
+type Limit struct {
+	Rate   int
+	Period time.Duration
+	Burst  int
+}
+
+func NewLimit(cfg config.Limit) Limit {
+	return Limit{Rate: cfg.Rate, Period: cfg.Period, Burst: cfg.Burst}
+}
+
+// Enabled reports whether the limit is configured.
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Period > 0
+}
+
+// interval is the GCRA emission interval, the time it takes to earn one request back.
+func (l Limit) interval() time.Duration {
+	return l.Period / time.Duration(l.Rate)
+}
+
+// tolerance is how far ahead of the current time the theoretical arrival time may run.
+func (l Limit) tolerance() time.Duration {
+	burst := l.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	return l.interval() * time.Duration(burst-1)
+}
+
+type Result struct {
+	Allowed    bool
+	RetryAfter time.Duration
+}
+
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
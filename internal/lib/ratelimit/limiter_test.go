@@ -0,0 +1,140 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"testing"
+	"time"
+)
+
+// step advances the clock by after and then asks for one request.
+type step struct {
+	after      time.Duration
+	allowed    bool
+	retryAfter time.Duration
+}
+
+var gcraTests = []struct {
+	name  string
+	limit Limit
+	steps []step
+}{
+	{
+		name:  "burst",
+		limit: Limit{Rate: 2, Period: time.Second, Burst: 2},
+		steps: []step{
+			{0, true, 0},
+			{0, true, 0},
+			{0, false, 500 * time.Millisecond},
+			{200 * time.Millisecond, false, 300 * time.Millisecond},
+			{300 * time.Millisecond, true, 0},
+			{0, false, 500 * time.Millisecond},
+		},
+	},
+	{
+		name:  "no burst",
+		limit: Limit{Rate: 1, Period: time.Second},
+		steps: []step{
+			{0, true, 0},
+			{0, false, time.Second},
+			{time.Second, true, 0},
+		},
+	},
+	{
+		name:  "idle time is not saved up beyond the burst",
+		limit: Limit{Rate: 1, Period: time.Second, Burst: 2},
+		steps: []step{
+			{0, true, 0},
+			{time.Hour, true, 0},
+			{0, true, 0},
+			{0, false, time.Second},
+		},
+	},
+	{
+		// an emission interval below a millisecond must not round down to unlimited
+		name:  "fast rate",
+		limit: Limit{Rate: 5000, Period: time.Second},
+		steps: []step{
+			{0, true, 0},
+			{0, false, 200 * time.Microsecond},
+			{100 * time.Microsecond, false, 100 * time.Microsecond},
+			{100 * time.Microsecond, true, 0},
+			{0, false, 200 * time.Microsecond},
+		},
+	},
+}
+
+// limiterFactory returns a limiter and a function advancing the clock it reads.
+type limiterFactory func(t *testing.T, start time.Time) (Limiter, func(time.Duration))
+
+var limiters = map[string]limiterFactory{
+	"memory": func(t *testing.T, start time.Time) (Limiter, func(time.Duration)) {
+		now := start
+		limiter := NewMemoryLimiter()
+		limiter.now = func() time.Time { return now }
+
+		return limiter, func(d time.Duration) { now = now.Add(d) }
+	},
+	"redis": func(t *testing.T, start time.Time) (Limiter, func(time.Duration)) {
+		server := miniredis.RunT(t)
+		server.SetTime(start)
+
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { _ = client.Close() })
+
+		now := start
+
+		return NewRedisLimiter(client), func(d time.Duration) {
+			now = now.Add(d)
+			server.SetTime(now)
+			server.FastForward(d)
+		}
+	},
+}
+
+func TestGCRA(t *testing.T) {
+	start := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	for name, newLimiter := range limiters {
+		for _, tt := range gcraTests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				limiter, advance := newLimiter(t, start)
+
+				for i, s := range tt.steps {
+					advance(s.after)
+
+					res, err := limiter.Allow(context.Background(), "user:1", tt.limit)
+
+					if err != nil {
+						t.Fatalf("step %d: Allow() = %v", i, err)
+					}
+
+					if res.Allowed != s.allowed || res.RetryAfter != s.retryAfter {
+						t.Fatalf("step %d: Allow() = %+v, want allowed %v retry after %s", i, res, s.allowed, s.retryAfter)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestGCRAKeys(t *testing.T) {
+	limit := Limit{Rate: 1, Period: time.Minute}
+
+	for name, newLimiter := range limiters {
+		t.Run(name, func(t *testing.T) {
+			limiter, _ := newLimiter(t, time.Now())
+
+			for _, key := range []string{"user:1", "user:2", "ip:10.0.0.1"} {
+				if res, err := limiter.Allow(context.Background(), key, limit); err != nil || !res.Allowed {
+					t.Errorf("first request of %s: %+v, %v", key, res, err)
+				}
+			}
+
+			if res, _ := limiter.Allow(context.Background(), "user:1", limit); res.Allowed {
+				t.Error("second request of user:1 was allowed")
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	cleanupEvery = 1024
+)
+
+// MemoryLimiter is a process-local GCRA limiter.
+// Limits are enforced per replica, so it is only used while Redis is unavailable.
+type MemoryLimiter struct {
+	mu    sync.Mutex
+	tats  map[string]time.Time
+	calls int
+	now   func() time.Time
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{tats: make(map[string]time.Time), now: time.Now}
+}
+
+func (m *MemoryLimiter) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	m.calls++
+	if m.calls%cleanupEvery == 0 {
+		m.cleanup(now)
+	}
+
+	tat, ok := m.tats[key]
+	if !ok || tat.Before(now) {
+		tat = now
+	}
+
+	if diff := tat.Sub(now); diff > limit.tolerance() {
+		return Result{RetryAfter: diff - limit.tolerance()}, nil
+	}
+
+	m.tats[key] = tat.Add(limit.interval())
+
+	return Result{Allowed: true}, nil
+}
+
+// cleanup drops keys whose theoretical arrival time has passed, they behave the same as missing keys.
+func (m *MemoryLimiter) cleanup(now time.Time) {
+	for key, tat := range m.tats {
+		if tat.Before(now) {
+			delete(m.tats, key)
+		}
+	}
+}
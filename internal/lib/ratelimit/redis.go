@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// gcraScript implements the generic cell rate algorithm.
+// The theoretical arrival time is stored in microseconds, milliseconds would round the emission interval
+// of rates above 1000/s down to zero and allow everything. The Redis clock is used so that every replica
+// agrees on the current time.
+var gcraScript = redis.NewScript(`
+local interval = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+
+local diff = tat - now
+if diff > tolerance then
+	return {0, diff - tolerance}
+end
+
+tat = tat + interval
+-- Lua would write the 16 digit number with 14 significant ones
+redis.call("SET", KEYS[1], string.format("%d", tat), "PX", math.ceil((tat - now) / 1000))
+return {1, 0}
+`)
+
+type RedisLimiter struct {
+	client redis.UniversalClient
+}
+
+func NewRedisLimiter(client redis.UniversalClient) *RedisLimiter {
+	return &RedisLimiter{client: client}
+}
+
+func (r *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	res, err := gcraScript.Run(ctx, r.client, []string{key}, limit.interval().Microseconds(), limit.tolerance().Microseconds()).Int64Slice()
+
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{Allowed: res[0] == 1, RetryAfter: time.Duration(res[1]) * time.Microsecond}, nil
+}
@@ -0,0 +1,150 @@
+package settings
+
+import (
+	"fmt"
+	"github.com/Verce11o/yata-tweets/config"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Change is a single setting that differs between two snapshots.
+type Change struct {
+	Name string
+	Old  string
+	New  string
+}
+
+// Diff lists the settings that differ between old and next, named by their yaml path.
+func Diff(old *Snapshot, next *Snapshot) []Change {
+	return diff(reflect.ValueOf(*old), reflect.ValueOf(*next), "")
+}
+
+func diff(old reflect.Value, next reflect.Value, path string) []Change {
+	var changes []Change
+
+	for i := 0; i < old.NumField(); i++ {
+		field := old.Type().Field(i)
+		name := fieldName(path, field)
+
+		if field.Type.Kind() == reflect.Struct {
+			changes = append(changes, diff(old.Field(i), next.Field(i), name)...)
+			continue
+		}
+
+		if reflect.DeepEqual(old.Field(i).Interface(), next.Field(i).Interface()) {
+			continue
+		}
+
+		changes = append(changes, Change{
+			Name: name,
+			Old:  fmt.Sprintf("%v", old.Field(i).Interface()),
+			New:  fmt.Sprintf("%v", next.Field(i).Interface()),
+		})
+	}
+
+	return changes
+}
+
+// override sets the settings named by the keys of values, e.g. pageSize or reload.interval.
+func override(settings *config.Settings, values map[string]string) []string {
+	var problems []string
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := values[key]
+		field, ok := lookup(reflect.ValueOf(settings).Elem(), key)
+
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: unknown setting", key))
+			continue
+		}
+
+		if err := set(field, value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+		}
+	}
+
+	return problems
+}
+
+func lookup(v reflect.Value, key string) (reflect.Value, bool) {
+	first, rest, nested := strings.Cut(key, ".")
+
+	for i := 0; i < v.NumField(); i++ {
+		if fieldName("", v.Type().Field(i)) != first {
+			continue
+		}
+
+		if nested {
+			if v.Field(i).Kind() != reflect.Struct {
+				return reflect.Value{}, false
+			}
+			return lookup(v.Field(i), rest)
+		}
+
+		if v.Field(i).Kind() == reflect.Struct {
+			return reflect.Value{}, false
+		}
+
+		return v.Field(i), true
+	}
+
+	return reflect.Value{}, false
+}
+
+func set(field reflect.Value, value string) error {
+	switch {
+	case field.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+	case field.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case field.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
+
+func fieldName(path string, field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+
+	if name == "" {
+		name = field.Name
+	}
+
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
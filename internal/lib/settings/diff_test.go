@@ -0,0 +1,123 @@
+package settings
+
+import (
+	"github.com/Verce11o/yata-tweets/config"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOverride(t *testing.T) {
+	base := config.Settings{PageSize: 10, EditWindow: time.Hour, Reload: config.SettingsReload{Interval: 30 * time.Second}}
+
+	tests := []struct {
+		name         string
+		values       map[string]string
+		want         func(s *config.Settings)
+		wantProblems []string
+	}{
+		{
+			name:   "int",
+			values: map[string]string{"pageSize": "20"},
+			want:   func(s *config.Settings) { s.PageSize = 20 },
+		},
+		{
+			name:   "duration and float",
+			values: map[string]string{"editWindow": "15m", "tweetCacheJitter": "0.25"},
+			want: func(s *config.Settings) {
+				s.EditWindow = 15 * time.Minute
+				s.TweetCacheJitter = 0.25
+			},
+		},
+		{
+			name:   "nested",
+			values: map[string]string{"reload.interval": "1m", "reload.redisKey": "other"},
+			want: func(s *config.Settings) {
+				s.Reload.Interval = time.Minute
+				s.Reload.RedisKey = "other"
+			},
+		},
+		{
+			name:         "unknown",
+			values:       map[string]string{"pagesize": "20", "reload.period": "1m", "pageSize.value": "1"},
+			wantProblems: []string{"pageSize.value: unknown setting", "pagesize: unknown setting", "reload.period: unknown setting"},
+		},
+		{
+			name:         "bad duration",
+			values:       map[string]string{"editWindow": "15"},
+			wantProblems: []string{`editWindow: time: missing unit in duration "15"`},
+		},
+		{
+			name:         "bad int",
+			values:       map[string]string{"pageSize": "ten"},
+			wantProblems: []string{`pageSize: strconv.Atoi: parsing "ten": invalid syntax`},
+		},
+		{
+			name:         "struct",
+			values:       map[string]string{"reload": "1m"},
+			wantProblems: []string{"reload: unknown setting"},
+		},
+		{
+			name:         "problems and valid values",
+			values:       map[string]string{"pageSize": "20", "editWindow": "later"},
+			want:         func(s *config.Settings) { s.PageSize = 20 },
+			wantProblems: []string{`editWindow: time: invalid duration "later"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := base
+			problems := override(&got, tt.values)
+
+			want := base
+			if tt.want != nil {
+				tt.want(&want)
+			}
+
+			if !reflect.DeepEqual(problems, tt.wantProblems) {
+				t.Errorf("override() problems = %q, want %q", problems, tt.wantProblems)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("override() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := &Snapshot{Settings: config.Settings{PageSize: 10, EditWindow: time.Hour}}
+
+	tests := []struct {
+		name   string
+		change func(next *Snapshot)
+		want   []Change
+	}{
+		{"nothing", func(*Snapshot) {}, nil},
+		{"one field", func(next *Snapshot) { next.PageSize = 20 }, []Change{{Name: "settings.pageSize", Old: "10", New: "20"}}},
+		{"nested", func(next *Snapshot) { next.Reload.RedisKey = "settings" }, []Change{{Name: "settings.reload.redisKey", Old: "", New: "settings"}}},
+		{
+			name: "several",
+			change: func(next *Snapshot) {
+				next.EditWindow = 0
+				next.RateLimit.Enabled = true
+			},
+			want: []Change{
+				{Name: "settings.editWindow", Old: "1h0m0s", New: "0s"},
+				{Name: "rateLimit.enabled", Old: "false", New: "true"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := *old
+			tt.change(&next)
+
+			if got := Diff(old, &next); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package settings
+
+import (
+	"context"
+	"fmt"
+	"github.com/Verce11o/yata-tweets/config"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"sync/atomic"
+	"time"
+)
+
+// Snapshot is an immutable view of the settings that can change at runtime.
+// Services get the current one per request and must not modify it.
+type Snapshot struct {
+	config.Settings `yaml:"settings"`
+	RateLimit       config.RateLimit `yaml:"rateLimit"`
+}
+
+// Store holds the current Snapshot and replaces it when the config file or the Redis hash changes.
+type Store struct {
+	log     *zap.SugaredLogger
+	path    string
+	client  redis.UniversalClient
+	current atomic.Pointer[Snapshot]
+}
+
+// NewStore starts from cfg, which was loaded from path. client is only used when settings.reload.redisKey is set.
+func NewStore(log *zap.SugaredLogger, cfg *config.Config, path string, client redis.UniversalClient) *Store {
+	s := &Store{log: log, path: path, client: client}
+	s.current.Store(&Snapshot{Settings: cfg.Settings, RateLimit: cfg.RateLimit})
+	return s
+}
+
+func (s *Store) Get() *Snapshot {
+	return s.current.Load()
+}
+
+// Run reloads the settings every settings.reload.interval until ctx is done.
+// Invalid changes are logged and ignored, the previous snapshot stays in use.
+func (s *Store) Run(ctx context.Context) {
+	interval := s.Get().Reload.Interval
+
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Reload(ctx); err != nil {
+			s.log.Errorf("cannot reload settings: %v", err)
+		}
+
+		next := s.Get().Reload.Interval
+
+		if next <= 0 {
+			s.log.Infof("settings reload disabled")
+			return
+		}
+
+		if next != interval {
+			interval = next
+			ticker.Reset(interval)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reload reads the config file and the Redis hash and swaps in the result if it is valid.
+func (s *Store) Reload(ctx context.Context) error {
+	cfg, err := config.Load(s.path)
+
+	if err != nil {
+		return err
+	}
+
+	next := &Snapshot{Settings: cfg.Settings, RateLimit: cfg.RateLimit}
+
+	if key := next.Reload.RedisKey; key != "" && s.client != nil {
+		values, err := s.client.HGetAll(ctx, key).Result()
+
+		if err != nil {
+			return fmt.Errorf("read settings hash %s: %w", key, err)
+		}
+
+		if problems := override(&next.Settings, values); len(problems) > 0 {
+			return &config.ValidationError{Problems: problems}
+		}
+
+		if problems := next.Settings.Validate(); len(problems) > 0 {
+			return &config.ValidationError{Problems: problems}
+		}
+	}
+
+	s.apply(next)
+
+	return nil
+}
+
+func (s *Store) apply(next *Snapshot) {
+	changes := Diff(s.Get(), next)
+
+	if len(changes) == 0 {
+		return
+	}
+
+	s.current.Store(next)
+
+	for _, change := range changes {
+		s.log.Infow("applied setting", "setting", change.Name, "old", change.Old, "new", change.New)
+	}
+}
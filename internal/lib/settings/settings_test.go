@@ -0,0 +1,91 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"github.com/Verce11o/yata-tweets/config"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var examplePath = filepath.Join("..", "..", "..", "cmd", "config_example.yml")
+
+func TestReload(t *testing.T) {
+	tests := []struct {
+		name         string
+		hash         map[string]string
+		wantPageSize int
+		wantWindow   time.Duration
+		wantErr      bool
+	}{
+		{"no overrides", nil, 10, 0, false},
+		{"overrides", map[string]string{"pageSize": "25", "editWindow": "15m"}, 25, 15 * time.Minute, false},
+		{"unknown setting", map[string]string{"pageSize": "25", "pageLimit": "5"}, 10, 0, true},
+		{"bad value", map[string]string{"editWindow": "soon"}, 10, 0, true},
+		{"invalid setting", map[string]string{"pageSize": "0"}, 10, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SETTINGS_REDIS_KEY", "settings")
+			t.Setenv("SETTINGS_PAGE_SIZE", "10")
+			t.Setenv("SETTINGS_EDIT_WINDOW", "0s")
+
+			server := miniredis.RunT(t)
+			client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+			t.Cleanup(func() { _ = client.Close() })
+
+			for field, value := range tt.hash {
+				server.HSet("settings", field, value)
+			}
+
+			cfg, err := config.Load(examplePath)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			store := NewStore(zap.NewNop().Sugar(), cfg, examplePath, client)
+			err = store.Reload(context.Background())
+
+			var invalid *config.ValidationError
+			if tt.wantErr != errors.As(err, &invalid) {
+				t.Fatalf("Reload() = %v, want a validation error: %v", err, tt.wantErr)
+			}
+
+			if got := store.Get(); got.PageSize != tt.wantPageSize || got.EditWindow != tt.wantWindow {
+				t.Errorf("page size %d and edit window %s, want %d and %s", got.PageSize, got.EditWindow, tt.wantPageSize, tt.wantWindow)
+			}
+		})
+	}
+}
+
+func TestReloadKeepsSnapshotWhenRedisFails(t *testing.T) {
+	t.Setenv("SETTINGS_REDIS_KEY", "settings")
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	cfg, err := config.Load(examplePath)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewStore(zap.NewNop().Sugar(), cfg, examplePath, client)
+	before := store.Get()
+	server.Close()
+
+	if err := store.Reload(context.Background()); err == nil {
+		t.Fatal("Reload() succeeded without Redis")
+	}
+
+	if store.Get() != before {
+		t.Error("the snapshot was replaced")
+	}
+}
@@ -0,0 +1,84 @@
+package validation
+
+import (
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	// weights follow the twitter-text v3 configuration
+	lightWeight      = 1
+	defaultWeight    = 2
+	urlWeightedChars = 23
+)
+
+// lightRanges are counted as a single character, everything else counts as two (e.g. CJK, emoji).
+var lightRanges = []struct{ from, to rune }{
+	{0x0000, 0x10FF},
+	{0x2000, 0x200D},
+	{0x2010, 0x201F},
+	{0x2032, 0x2037},
+}
+
+var urlRegexp = regexp.MustCompile(`(?i)\bhttps?://[^\s]+`)
+
+// NormalizeText converts the text to NFC and strips control characters except newlines,
+// including bidirectional overrides that can be used to spoof the rendered text.
+func NormalizeText(text string) string {
+	text = strings.ToValidUTF8(text, "")
+
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case r == '\n':
+			return r
+		case r == '\t':
+			return ' '
+		case unicode.IsControl(r), isBidiControl(r):
+			return -1
+		}
+		return r
+	}, text)
+
+	return strings.TrimSpace(norm.NFC.String(text))
+}
+
+// WeightedLength counts grapheme clusters the way Twitter does:
+// URLs have a fixed length, clusters starting outside the light ranges count twice.
+func WeightedLength(text string) int {
+	length := 0
+	prev := 0
+
+	for _, loc := range urlRegexp.FindAllStringIndex(text, -1) {
+		length += clustersLength(text[prev:loc[0]]) + urlWeightedChars
+		prev = loc[1]
+	}
+
+	return length + clustersLength(text[prev:])
+}
+
+func clustersLength(text string) int {
+	length := 0
+
+	gr := uniseg.NewGraphemes(text)
+	for gr.Next() {
+		length += clusterWeight(gr.Runes()[0])
+	}
+
+	return length
+}
+
+func clusterWeight(r rune) int {
+	for _, rng := range lightRanges {
+		if r >= rng.from && r <= rng.to {
+			return lightWeight
+		}
+	}
+	return defaultWeight
+}
+
+func isBidiControl(r rune) bool {
+	return (r >= 0x202A && r <= 0x202E) || (r >= 0x2066 && r <= 0x2069)
+}
@@ -0,0 +1,69 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWeightedLength(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"empty", "", 0},
+		{"ascii", "hello world", 11},
+		{"latin with accents", "café", 4},
+		{"decomposed accent is one cluster", "cafe\u0301", 4},
+		{"cyrillic", "привет", 6},
+		{"general punctuation", "a—b“c”", 6},
+		{"ellipsis is not light", "…", 2},
+		{"cjk", "日本語", 6},
+		{"hangul", "한국", 4},
+		{"emoji", "😀", 2},
+		{"emoji with skin tone", "👍🏽", 2},
+		{"family emoji", "👨‍👩‍👧", 2},
+		{"flag", "🇩🇪", 2},
+		{"url", "https://example.com/a/very/long/path/that/is/longer/than/twenty/three", 23},
+		{"short url", "http://a.io", 23},
+		{"url in text", "see https://example.com now", 4 + 23 + 4},
+		{"two urls", "https://a.io https://b.io", 23 + 1 + 23},
+		{"mixed", "hi 😀 日本", 3 + 2 + 1 + 4},
+		{"280 ascii", strings.Repeat("a", 280), 280},
+		{"140 cjk", strings.Repeat("字", 140), 280},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WeightedLength(tt.text); got != tt.want {
+				t.Errorf("WeightedLength(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain", "hello", "hello"},
+		{"trimmed", "  hello \n", "hello"},
+		{"newlines are kept", "a\nb", "a\nb"},
+		{"tabs become spaces", "a\tb", "a b"},
+		{"control characters", "a\x00b\x1bc", "abc"},
+		{"bidi override", "abc‮gnp.exe", "abcgnp.exe"},
+		{"bidi isolate", "⁦abc⁩", "abc"},
+		{"nfc", "cafe\u0301", "caf\u00e9"},
+		{"invalid utf-8", "a\xffb", "ab"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeText(tt.text); got != tt.want {
+				t.Errorf("NormalizeText(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package validation
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+	"unicode/utf8"
+)
+
+// Rule checks a single field value and returns a description of the violation, or an empty string.
+type Rule func(value string) string
+
+type Violation struct {
+	Field       string
+	Description string
+}
+
+// Error holds every violation found in a request.
+// It converts to a codes.InvalidArgument status with errdetails.BadRequest attached.
+type Error struct {
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	parts := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		parts = append(parts, fmt.Sprintf("%s: %s", v.Field, v.Description))
+	}
+	return "invalid request: " + strings.Join(parts, "; ")
+}
+
+func (e *Error) GRPCStatus() *status.Status {
+	badRequest := &errdetails.BadRequest{}
+
+	for _, v := range e.Violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Description,
+		})
+	}
+
+	st, err := status.New(codes.InvalidArgument, e.Error()).WithDetails(badRequest)
+	if err != nil {
+		return status.New(codes.InvalidArgument, e.Error())
+	}
+
+	return st
+}
+
+// Validator collects violations of declared field rules.
+type Validator struct {
+	violations []Violation
+}
+
+func New() *Validator {
+	return &Validator{}
+}
+
+// Field applies rules to the value in order and records the first violation.
+func (v *Validator) Field(name string, value string, rules ...Rule) *Validator {
+	for _, rule := range rules {
+		if description := rule(value); description != "" {
+			v.Violate(name, description)
+			break
+		}
+	}
+	return v
+}
+
+// Check records a violation when ok is false.
+func (v *Validator) Check(name string, ok bool, description string) *Validator {
+	if !ok {
+		v.Violate(name, description)
+	}
+	return v
+}
+
+func (v *Validator) Violate(name string, description string) {
+	v.violations = append(v.violations, Violation{Field: name, Description: description})
+}
+
+// Err returns an *Error if any rule was violated.
+func (v *Validator) Err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return &Error{Violations: v.violations}
+}
+
+func Required(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return "must not be empty"
+	}
+	return ""
+}
+
+// Optional skips the remaining rules for empty values.
+func Optional(rules ...Rule) Rule {
+	return func(value string) string {
+		if value == "" {
+			return ""
+		}
+		for _, rule := range rules {
+			if description := rule(value); description != "" {
+				return description
+			}
+		}
+		return ""
+	}
+}
+
+func UUID(value string) string {
+	if _, err := uuid.Parse(value); err != nil {
+		return "must be a valid UUID"
+	}
+	return ""
+}
+
+func MaxBytes(n int) Rule {
+	return func(value string) string {
+		if len(value) > n {
+			return fmt.Sprintf("must be at most %d bytes", n)
+		}
+		return ""
+	}
+}
+
+func MaxRunes(n int) Rule {
+	return func(value string) string {
+		if utf8.RuneCountInString(value) > n {
+			return fmt.Sprintf("must be at most %d characters", n)
+		}
+		return ""
+	}
+}
+
+func MaxWeightedLength(n int) Rule {
+	return func(value string) string {
+		if WeightedLength(value) > n {
+			return fmt.Sprintf("must be at most %d characters", n)
+		}
+		return ""
+	}
+}
+
+func OneOf(allowed ...string) Rule {
+	return func(value string) string {
+		for _, a := range allowed {
+			if value == a {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of %s", strings.Join(allowed, ", "))
+	}
+}
+
+func ValidUTF8(value string) string {
+	if !utf8.ValidString(value) {
+		return "must be valid UTF-8"
+	}
+	return ""
+}
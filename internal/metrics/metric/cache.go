@@ -0,0 +1,61 @@
+package metric
+
+import (
+	"github.com/Verce11o/yata-tweets/internal/lib/lru"
+	"github.com/prometheus/client_golang/prometheus"
+	"sync"
+)
+
+const cacheRequestsHelp = "Cache lookups by cache, tier and result (hit, miss, negative, error)."
+
+// localCacheCollector reads the stats of in-memory caches when scraped. Their lookups are
+// exposed as cache_requests_total too, next to the ones counted by InstrumentCache.
+type localCacheCollector struct {
+	requests  *prometheus.Desc
+	evictions *prometheus.Desc
+	entries   *prometheus.Desc
+
+	mu     sync.Mutex
+	caches map[string]func() lru.Stats
+}
+
+func newLocalCacheCollector() *localCacheCollector {
+	return &localCacheCollector{
+		requests: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "cache_requests_total"),
+			cacheRequestsHelp, []string{"cache", "tier", "result"}, nil),
+		evictions: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "cache_evictions_total"),
+			"Entries evicted from in-memory caches because they were full.", []string{"cache", "tier"}, nil),
+		entries: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "cache_entries"),
+			"Entries held by in-memory caches.", []string{"cache", "tier"}, nil),
+		caches: make(map[string]func() lru.Stats),
+	}
+}
+
+// RegisterLocalCache exposes the stats of the in-memory tier of the tweet cache.
+func (m *Metrics) RegisterLocalCache(stats func() lru.Stats) {
+	m.localCaches.mu.Lock()
+	defer m.localCaches.mu.Unlock()
+
+	m.localCaches.caches[tweetCache] = stats
+}
+
+// Describe only sends the descriptors that cache_requests_total does not share with the counters
+// of InstrumentCache, a registry rejects the same descriptor from two collectors.
+func (c *localCacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.evictions
+	ch <- c.entries
+}
+
+func (c *localCacheCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for cache, statsFn := range c.caches {
+		stats := statsFn()
+
+		ch <- prometheus.MustNewConstMetric(c.requests, prometheus.CounterValue, float64(stats.Hits), cache, tierLocal, resultHit)
+		ch <- prometheus.MustNewConstMetric(c.requests, prometheus.CounterValue, float64(stats.Misses), cache, tierLocal, resultMiss)
+		ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions), cache, tierLocal)
+		ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(stats.Entries), cache, tierLocal)
+	}
+}
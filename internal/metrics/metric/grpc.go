@@ -0,0 +1,57 @@
+package metric
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"strings"
+	"time"
+)
+
+func (m *Metrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		done := m.startRPC(info.FullMethod)
+
+		resp, err := handler(ctx, req)
+		done(err)
+
+		return resp, err
+	}
+}
+
+func (m *Metrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		done := m.startRPC(info.FullMethod)
+
+		err := handler(srv, ss)
+		done(err)
+
+		return err
+	}
+}
+
+// startRPC records an RPC as in flight and returns a function that records its outcome.
+func (m *Metrics) startRPC(fullMethod string) func(err error) {
+	service, method := splitMethod(fullMethod)
+	start := time.Now()
+
+	inFlight := m.rpcInFlight.WithLabelValues(service, method)
+	inFlight.Inc()
+
+	return func(err error) {
+		inFlight.Dec()
+		m.rpcHandled.WithLabelValues(service, method, status.Code(err).String()).Inc()
+		m.rpcDuration.WithLabelValues(service, method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// splitMethod splits "/package.Service/Method" into its service and method.
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+
+	return "unknown", fullMethod
+}
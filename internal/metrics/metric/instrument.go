@@ -0,0 +1,176 @@
+package metric
+
+import (
+	"context"
+	"errors"
+	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/lib/grpc_errors"
+	"github.com/Verce11o/yata-tweets/internal/lib/notification"
+	"github.com/Verce11o/yata-tweets/internal/repository"
+	"time"
+)
+
+const (
+	tweetCache = "tweet"
+	tierRedis  = "redis"
+	tierLocal  = "local"
+)
+
+// The wrappers below embed the wrapped interface and only override the methods they measure.
+
+type cache struct {
+	repository.RedisRepository
+	m *Metrics
+}
+
+// InstrumentCache counts hits and misses of GetTweetByIDCtx in Redis. Cached lookups of missing tweets count as negative.
+func (m *Metrics) InstrumentCache(repo repository.RedisRepository) repository.RedisRepository {
+	return &cache{RedisRepository: repo, m: m}
+}
+
+func (c *cache) GetTweetByIDCtx(ctx context.Context, key string) (*domain.CachedTweet, error) {
+	entry, err := c.RedisRepository.GetTweetByIDCtx(ctx, key)
+
+	res := resultHit
+
+	switch {
+	case errors.Is(err, grpc_errors.ErrNotFound):
+		res = resultMiss
+	case err != nil:
+		res = resultError
+	case entry.Tweet == nil:
+		res = resultNegative
+	}
+
+	c.m.cacheRequests.WithLabelValues(tweetCache, tierRedis, res).Inc()
+
+	return entry, err
+}
+
+func (c *cache) GetTweetsByIDs(ctx context.Context, tweetIDs []string) ([]*domain.CachedTweet, error) {
+	entries, err := c.RedisRepository.GetTweetsByIDs(ctx, tweetIDs)
+
+	if err != nil {
+		c.m.cacheRequests.WithLabelValues(tweetCache, tierRedis, resultError).Add(float64(len(tweetIDs)))
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		res := resultHit
+
+		switch {
+		case entry == nil:
+			res = resultMiss
+		case entry.Tweet == nil:
+			res = resultNegative
+		}
+
+		c.m.cacheRequests.WithLabelValues(tweetCache, tierRedis, res).Inc()
+	}
+
+	return entries, nil
+}
+
+type storage struct {
+	repository.StorageRepository
+	m *Metrics
+}
+
+// InstrumentStorage measures image upload sizes and latencies.
+func (m *Metrics) InstrumentStorage(repo repository.StorageRepository) repository.StorageRepository {
+	return &storage{StorageRepository: repo, m: m}
+}
+
+func (s *storage) AddTweetImage(ctx context.Context, image *pb.Image, fileName string) error {
+	start := time.Now()
+
+	err := s.StorageRepository.AddTweetImage(ctx, image, fileName)
+	s.observeUpload(image, start, err)
+
+	return err
+}
+
+func (s *storage) UpdateTweetImage(ctx context.Context, oldName string, newName string, image *pb.Image) error {
+	start := time.Now()
+
+	err := s.StorageRepository.UpdateTweetImage(ctx, oldName, newName, image)
+	s.observeUpload(image, start, err)
+
+	return err
+}
+
+func (s *storage) observeUpload(image *pb.Image, start time.Time, err error) {
+	s.m.uploadDuration.WithLabelValues(result(err)).Observe(time.Since(start).Seconds())
+
+	if err == nil {
+		s.m.uploadSize.Observe(float64(len(image.GetChunk())))
+	}
+}
+
+type publisher struct {
+	notification.TweetPublisher
+	m *Metrics
+}
+
+// InstrumentPublisher counts successful and failed publishes.
+func (m *Metrics) InstrumentPublisher(p notification.TweetPublisher) notification.TweetPublisher {
+	return &publisher{TweetPublisher: p, m: m}
+}
+
+func (p *publisher) Publish(ctx context.Context, message []byte) error {
+	err := p.TweetPublisher.Publish(ctx, message)
+	p.m.publishes.WithLabelValues(result(err)).Inc()
+
+	return err
+}
+
+type tweets struct {
+	repository.PostgresRepository
+	m *Metrics
+}
+
+// InstrumentTweets counts created and deleted tweets.
+func (m *Metrics) InstrumentTweets(repo repository.PostgresRepository) repository.PostgresRepository {
+	return &tweets{PostgresRepository: repo, m: m}
+}
+
+func (t *tweets) CreateTweet(ctx context.Context, userID string, input *pb.CreateTweetRequest, imageName string) (*domain.Tweet, error) {
+	tweet, err := t.PostgresRepository.CreateTweet(ctx, userID, input, imageName)
+
+	if err == nil {
+		t.m.tweetsCreated.Inc()
+	}
+
+	return tweet, err
+}
+
+func (t *tweets) DeleteTweet(ctx context.Context, tweetID string, audit *domain.AuditRecord) error {
+	err := t.PostgresRepository.DeleteTweet(ctx, tweetID, audit)
+
+	if err == nil {
+		t.m.tweetsDeleted.Inc()
+	}
+
+	return err
+}
+
+type schedules struct {
+	repository.ScheduleRepository
+	m *Metrics
+}
+
+// InstrumentSchedules counts the tweets created by publishing scheduled tweets with the other created tweets.
+func (m *Metrics) InstrumentSchedules(repo repository.ScheduleRepository) repository.ScheduleRepository {
+	return &schedules{ScheduleRepository: repo, m: m}
+}
+
+func (s *schedules) PublishDueTweet(ctx context.Context) (*domain.ScheduledTweet, *domain.Tweet, error) {
+	schedule, tweet, err := s.ScheduleRepository.PublishDueTweet(ctx)
+
+	if err == nil && tweet != nil {
+		s.m.tweetsCreated.Inc()
+	}
+
+	return schedule, tweet, err
+}
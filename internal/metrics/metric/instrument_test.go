@@ -0,0 +1,70 @@
+package metric
+
+import (
+	"context"
+	"errors"
+	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/repository"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"testing"
+)
+
+type tweetsStub struct {
+	repository.PostgresRepository
+	err error
+}
+
+func (s tweetsStub) CreateTweet(context.Context, string, *pb.CreateTweetRequest, string) (*domain.Tweet, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &domain.Tweet{}, nil
+}
+
+type schedulesStub struct {
+	repository.ScheduleRepository
+	tweet *domain.Tweet
+	err   error
+}
+
+func (s schedulesStub) PublishDueTweet(context.Context) (*domain.ScheduledTweet, *domain.Tweet, error) {
+	return &domain.ScheduledTweet{}, s.tweet, s.err
+}
+
+func TestTweetsCreated(t *testing.T) {
+	failure := errors.New("connection refused")
+
+	tests := []struct {
+		name    string
+		create  func(m *Metrics)
+		created float64
+	}{
+		{"created", func(m *Metrics) {
+			_, _ = m.InstrumentTweets(tweetsStub{}).CreateTweet(context.Background(), "", nil, "")
+		}, 1},
+		{"creation failed", func(m *Metrics) {
+			_, _ = m.InstrumentTweets(tweetsStub{err: failure}).CreateTweet(context.Background(), "", nil, "")
+		}, 0},
+		{"scheduled tweet published", func(m *Metrics) {
+			_, _, _ = m.InstrumentSchedules(schedulesStub{tweet: &domain.Tweet{}}).PublishDueTweet(context.Background())
+		}, 1},
+		{"nothing due", func(m *Metrics) {
+			_, _, _ = m.InstrumentSchedules(schedulesStub{}).PublishDueTweet(context.Background())
+		}, 0},
+		{"publishing failed", func(m *Metrics) {
+			_, _, _ = m.InstrumentSchedules(schedulesStub{err: failure}).PublishDueTweet(context.Background())
+		}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMetrics()
+			tt.create(m)
+
+			if got := testutil.ToFloat64(m.tweetsCreated); got != tt.created {
+				t.Errorf("tweets created = %v, want %v", got, tt.created)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package metric
+
+import (
+	"database/sql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+const namespace = "yata_tweets"
+
+const (
+	resultSuccess  = "success"
+	resultFailure  = "failure"
+	resultHit      = "hit"
+	resultMiss     = "miss"
+	resultNegative = "negative"
+	resultError    = "error"
+)
+
+// Metrics holds every Prometheus collector of the service on its own registry.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	rpcHandled  *prometheus.CounterVec
+	rpcDuration *prometheus.HistogramVec
+	rpcInFlight *prometheus.GaugeVec
+
+	cacheRequests *prometheus.CounterVec
+	localCaches   *localCacheCollector
+
+	uploadSize     prometheus.Histogram
+	uploadDuration *prometheus.HistogramVec
+
+	publishes *prometheus.CounterVec
+
+	tweetsCreated prometheus.Counter
+	tweetsDeleted prometheus.Counter
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		rpcHandled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "grpc_server_handled_total",
+			Help:      "RPCs completed on the server, by method and status code.",
+		}, []string{"grpc_service", "grpc_method", "grpc_code"}),
+		rpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "grpc_server_handling_seconds",
+			Help:      "Time to handle an RPC, for streams the lifetime of the stream.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"grpc_service", "grpc_method"}),
+		rpcInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "grpc_server_in_flight",
+			Help:      "RPCs currently being handled.",
+		}, []string{"grpc_service", "grpc_method"}),
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_requests_total",
+			Help:      cacheRequestsHelp,
+		}, []string{"cache", "tier", "result"}),
+		localCaches: newLocalCacheCollector(),
+		uploadSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "storage_upload_bytes",
+			Help:      "Size of uploaded tweet images.",
+			Buckets:   prometheus.ExponentialBuckets(16<<10, 2, 10),
+		}),
+		uploadDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "storage_upload_seconds",
+			Help:      "Time to upload a tweet image.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"result"}),
+		publishes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "amqp_publish_total",
+			Help:      "Messages published to RabbitMQ by result.",
+		}, []string{"result"}),
+		tweetsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tweets_created_total",
+			Help:      "Tweets created.",
+		}),
+		tweetsDeleted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tweets_deleted_total",
+			Help:      "Tweets deleted.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.rpcHandled,
+		m.rpcDuration,
+		m.rpcInFlight,
+		m.cacheRequests,
+		m.localCaches,
+		m.uploadSize,
+		m.uploadDuration,
+		m.publishes,
+		m.tweetsCreated,
+		m.tweetsDeleted,
+	)
+
+	return m
+}
+
+// RegisterDB exposes connection pool stats of db, as reported by sql.DB.Stats.
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+func result(err error) string {
+	if err != nil {
+		return resultFailure
+	}
+
+	return resultSuccess
+}
@@ -0,0 +1,44 @@
+package metric
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+const (
+	outboxScrapeTimeout = 2 * time.Second
+)
+
+// outboxCollector asks for the lag of the outbox when scraped.
+type outboxCollector struct {
+	lag  *prometheus.Desc
+	read func(ctx context.Context) (time.Duration, error)
+}
+
+// RegisterOutbox exposes outbox_lag_seconds, the age of the oldest event that is due but not sent yet.
+// read is called on every scrape, the gauge is left out of a scrape it fails in.
+func (m *Metrics) RegisterOutbox(read func(ctx context.Context) (time.Duration, error)) {
+	m.registry.MustRegister(&outboxCollector{
+		lag: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "outbox_lag_seconds"),
+			"Age of the oldest event that is due but not sent yet, zero when none is waiting.", nil, nil),
+		read: read,
+	})
+}
+
+func (c *outboxCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.lag
+}
+
+func (c *outboxCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), outboxScrapeTimeout)
+	defer cancel()
+
+	lag, err := c.read(ctx)
+
+	if err != nil {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.lag, prometheus.GaugeValue, lag.Seconds())
+}
@@ -0,0 +1,42 @@
+package metric
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOutboxLag(t *testing.T) {
+	tests := []struct {
+		name string
+		lag  time.Duration
+		err  error
+		want string
+	}{
+		{"waiting", 90 * time.Second, nil, "yata_tweets_outbox_lag_seconds 90\n"},
+		{"nothing due", 0, nil, "yata_tweets_outbox_lag_seconds 0\n"},
+		{"read fails", 0, errors.New("connection refused"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMetrics()
+			m.RegisterOutbox(func(context.Context) (time.Duration, error) {
+				return tt.lag, tt.err
+			})
+
+			want := ""
+			if tt.want != "" {
+				want = "# HELP yata_tweets_outbox_lag_seconds Age of the oldest event that is due but not sent yet, zero when none is waiting.\n" +
+					"# TYPE yata_tweets_outbox_lag_seconds gauge\n" + tt.want
+			}
+
+			if err := testutil.GatherAndCompare(m.registry, strings.NewReader(want), "yata_tweets_outbox_lag_seconds"); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
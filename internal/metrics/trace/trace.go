@@ -2,65 +2,168 @@ package trace
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/Verce11o/yata-tweets/config"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
 	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/credentials"
 	"log"
+	"os"
 )
 
-type JaegerTracing struct {
+const (
+	ExporterOTLPGRPC = "otlpgrpc"
+	ExporterOTLPHTTP = "otlphttp"
+	ExporterStdout   = "stdout"
+	ExporterNone     = "none"
+)
+
+type Tracing struct {
 	Exporter tracesdk.SpanExporter
 	Provider *tracesdk.TracerProvider
 	Tracer   trace.Tracer
 }
 
-func NewJaegerExporter(ctx context.Context) (tracesdk.SpanExporter, error) {
-	return otlptracegrpc.New(ctx, otlptracegrpc.WithInsecure())
+// NewExporter builds the span exporter selected by cfg.Exporter. It returns nil for ExporterNone.
+func NewExporter(ctx context.Context, cfg config.Tracing) (tracesdk.SpanExporter, error) {
+	switch cfg.Exporter {
+	case ExporterOTLPGRPC, "":
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithHeaders(cfg.Headers)}
+
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		} else if cfg.CAFile != "" {
+			creds, err := credentials.NewClientTLSFromFile(cfg.CAFile, "")
+
+			if err != nil {
+				return nil, err
+			}
+
+			opts = append(opts, otlptracegrpc.WithTLSCredentials(creds))
+		}
+
+		return otlptracegrpc.New(ctx, opts...)
+	case ExporterOTLPHTTP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithHeaders(cfg.Headers)}
+
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		} else if cfg.CAFile != "" {
+			tlsCfg, err := newTLSConfig(cfg.CAFile)
+
+			if err != nil {
+				return nil, err
+			}
+
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsCfg))
+		}
+
+		return otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterNone:
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("unknown trace exporter: %s", cfg.Exporter)
 }
 
-func NewTraceProvider(exp tracesdk.SpanExporter, ServiceName string) (*tracesdk.TracerProvider, error) {
-	r, err := resource.Merge(
+// NewResource describes this process: service name and version, deployment environment and instance id.
+// The instance id falls back to the host name, which is the pod name on Kubernetes.
+func NewResource(cfg config.Tracing) (*resource.Resource, error) {
+	instanceID := cfg.InstanceID
+
+	if instanceID == "" {
+		instanceID, _ = os.Hostname()
+	}
+
+	return resource.Merge(
 		resource.Default(),
 		resource.NewWithAttributes(
 			semconv.SchemaURL,
-			semconv.ServiceNameKey.String(ServiceName),
+			semconv.ServiceName(cfg.ServiceName),
+			semconv.ServiceVersion(cfg.Version),
+			semconv.ServiceInstanceID(instanceID),
+			semconv.DeploymentEnvironment(cfg.Environment),
 		),
 	)
+}
+
+// NewTraceProvider samples a cfg.SampleRatio share of new traces and follows the parent's decision otherwise.
+func NewTraceProvider(exp tracesdk.SpanExporter, cfg config.Tracing) (*tracesdk.TracerProvider, error) {
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, fmt.Errorf("sample ratio must be between 0 and 1, got %v", cfg.SampleRatio)
+	}
+
+	r, err := NewResource(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	return tracesdk.NewTracerProvider(
-		tracesdk.WithBatcher(exp),
-		tracesdk.WithResource(r),
-	), nil
+	sampler := tracesdk.ParentBased(tracesdk.TraceIDRatioBased(cfg.SampleRatio))
+
+	opts := []tracesdk.TracerProviderOption{tracesdk.WithResource(r)}
+
+	if exp != nil {
+		opts = append(opts, tracesdk.WithBatcher(exp), tracesdk.WithSampler(sampler))
+	} else {
+		// nothing would be exported anyway, so don't record at all
+		opts = append(opts, tracesdk.WithSampler(tracesdk.NeverSample()))
+	}
+
+	return tracesdk.NewTracerProvider(opts...), nil
 }
 
-func InitTracer(serviceName string) *JaegerTracing {
-	exporter, err := NewJaegerExporter(context.Background())
+func InitTracer(cfg config.Tracing) *Tracing {
+	exporter, err := NewExporter(context.Background(), cfg)
 	if err != nil {
 		log.Fatalf("initialize tracer exporter: %v", err)
 	}
 
-	tp, err := NewTraceProvider(exporter, serviceName)
+	tp, err := NewTraceProvider(exporter, cfg)
 	if err != nil {
 		log.Fatalf("initialize tracer provider: %v", err)
 	}
 
 	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 
-	tracer, err := tp.Tracer("main tracer"), nil
+	return &Tracing{
+		Exporter: exporter,
+		Provider: tp,
+		Tracer:   tp.Tracer("main tracer"),
+	}
+}
+
+func newTLSConfig(caFile string) (*tls.Config, error) {
+	pem, err := os.ReadFile(caFile)
 
 	if err != nil {
-		log.Fatalf("error while init tracer: %v", err)
+		return nil, err
 	}
 
-	return &JaegerTracing{
-		Exporter: exporter,
-		Provider: tp,
-		Tracer:   tracer,
+	pool := x509.NewCertPool()
+
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
 	}
+
+	return &tls.Config{RootCAs: pool}, nil
 }
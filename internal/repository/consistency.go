@@ -0,0 +1,17 @@
+package repository
+
+import "context"
+
+type readPrimaryKey struct{}
+
+// ReadPrimary makes the reads done with the returned context go to the primary. Results that are
+// shared through a cache must not come from a lagging replica, they would outlive the lag.
+func ReadPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readPrimaryKey{}, true)
+}
+
+// ReadsPrimary reports whether ctx was returned by ReadPrimary.
+func ReadsPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(readPrimaryKey{}).(bool)
+	return primary
+}
@@ -0,0 +1,209 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/lib/grpc_errors"
+	"go.opentelemetry.io/otel/trace"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	metaDir   = ".meta"
+	tmpPrefix = ".tmp-"
+	dirPerm   = 0o755
+	filePerm  = 0o644
+)
+
+var ErrInvalidFileName = errors.New("invalid file name")
+
+// TweetLocal stores tweet images on the local filesystem.
+// It is meant for development setups where running MinIO is not desirable.
+type TweetLocal struct {
+	root   string
+	tracer trace.Tracer
+}
+
+func NewTweetLocal(root string, tracer trace.Tracer) *TweetLocal {
+	return &TweetLocal{root: root, tracer: tracer}
+}
+
+// EnsureBucket creates the storage directory if it does not exist yet.
+func (t *TweetLocal) EnsureBucket(ctx context.Context) error {
+	_, span := t.tracer.Start(ctx, "tweetLocal.EnsureBucket")
+	defer span.End()
+
+	return os.MkdirAll(filepath.Join(t.root, metaDir), dirPerm)
+}
+
+func (t *TweetLocal) AddTweetImage(ctx context.Context, image *pb.Image, fileName string) error {
+	_, span := t.tracer.Start(ctx, "tweetLocal.AddImage")
+	defer span.End()
+
+	path, metaPath, err := t.paths(fileName)
+
+	if err != nil {
+		return err
+	}
+
+	if err := writeFile(path, image.GetChunk()); err != nil {
+		return err
+	}
+
+	return writeFile(metaPath, []byte(image.GetContentType()))
+}
+
+func (t *TweetLocal) GetTweetImage(ctx context.Context, fileName string) (*pb.Image, error) {
+	_, span := t.tracer.Start(ctx, "tweetLocal.GetTweetImage")
+	defer span.End()
+
+	path, metaPath, err := t.paths(fileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	chunk, err := os.ReadFile(path)
+
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, grpc_errors.ErrNotFound
+		}
+		return nil, err
+	}
+
+	contentType, err := os.ReadFile(metaPath)
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return &pb.Image{Chunk: chunk, ContentType: string(contentType), Name: fileName}, nil
+}
+
+func (t *TweetLocal) UpdateTweetImage(ctx context.Context, oldName string, newName string, image *pb.Image) error {
+	ctx, span := t.tracer.Start(ctx, "tweetLocal.UpdateTweetImage")
+	defer span.End()
+
+	if oldName != "" {
+		if err := t.DeleteFile(ctx, oldName); err != nil {
+			return err
+		}
+	}
+
+	return t.AddTweetImage(ctx, image, newName)
+}
+
+func (t *TweetLocal) DeleteFile(ctx context.Context, fileName string) error {
+	_, span := t.tracer.Start(ctx, "tweetLocal.DeleteFile")
+	defer span.End()
+
+	path, metaPath, err := t.paths(fileName)
+
+	if err != nil {
+		return err
+	}
+
+	// removing a missing object is not an error, same as in S3
+	for _, p := range []string{path, metaPath} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Ping checks that the storage directory is still there.
+func (t *TweetLocal) Ping(ctx context.Context) error {
+	_, span := t.tracer.Start(ctx, "tweetLocal.Ping")
+	defer span.End()
+
+	info, err := os.Stat(t.root)
+
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", t.root)
+	}
+
+	return nil
+}
+
+func (t *TweetLocal) ListFiles(ctx context.Context) ([]domain.StoredFile, error) {
+	_, span := t.tracer.Start(ctx, "tweetLocal.ListFiles")
+	defer span.End()
+
+	entries, err := os.ReadDir(t.root)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var files []domain.StoredFile
+
+	for _, entry := range entries {
+		// skip content types and writes in progress
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), tmpPrefix) {
+			continue
+		}
+
+		info, err := entry.Info()
+
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		files = append(files, domain.StoredFile{Name: entry.Name(), Size: info.Size(), ModifiedAt: info.ModTime()})
+	}
+
+	return files, nil
+}
+
+func (t *TweetLocal) paths(fileName string) (string, string, error) {
+	switch fileName {
+	case "", ".", "..", metaDir:
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidFileName, fileName)
+	}
+	if fileName != filepath.Base(fileName) {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidFileName, fileName)
+	}
+	return filepath.Join(t.root, fileName), filepath.Join(t.root, metaDir, fileName), nil
+}
+
+// writeFile writes data to a temporary file first, so readers never observe a partially written image.
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), tmpPrefix+"*")
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := os.Chmod(tmp.Name(), filePerm); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,50 @@
+package local
+
+import (
+	"context"
+	"errors"
+	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
+	"github.com/Verce11o/yata-tweets/internal/repository"
+	"github.com/Verce11o/yata-tweets/internal/repository/storage/storagetest"
+	"go.opentelemetry.io/otel/trace/noop"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestStorage(t *testing.T) *TweetLocal {
+	t.Helper()
+
+	storage := NewTweetLocal(t.TempDir(), noop.NewTracerProvider().Tracer(""))
+
+	if err := storage.EnsureBucket(context.Background()); err != nil {
+		t.Fatalf("EnsureBucket: %v", err)
+	}
+
+	return storage
+}
+
+func TestTweetLocal(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) repository.StorageRepository {
+		return newTestStorage(t)
+	})
+}
+
+func TestTweetLocalRejectsPaths(t *testing.T) {
+	storage := newTestStorage(t)
+
+	for _, name := range []string{"", ".", "..", metaDir, "../cat.png", "images/cat.png", "/etc/passwd"} {
+		t.Run(name, func(t *testing.T) {
+			err := storage.AddTweetImage(context.Background(), &pb.Image{Chunk: []byte("cat"), Name: name}, name)
+
+			if !errors.Is(err, ErrInvalidFileName) {
+				t.Errorf("AddTweetImage(%q) error = %v, want %v", name, err, ErrInvalidFileName)
+			}
+		})
+	}
+
+	// nothing was written outside the root
+	if _, err := os.Stat(filepath.Join(filepath.Dir(storage.root), "cat.png")); !os.IsNotExist(err) {
+		t.Errorf("cat.png was written next to the root: %v", err)
+	}
+}
@@ -3,24 +3,68 @@ package minio
 import (
 	"bytes"
 	"context"
+	"fmt"
 	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/lib/grpc_errors"
 	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
 	"go.opentelemetry.io/otel/trace"
-	"time"
+	"io"
+	"net/http"
 )
 
 const (
-	userTweetsName  = "user-tweets"
-	imageExpireTime = time.Hour * 24
+	abortMultipartDays = 1
 )
 
 type TweetMinio struct {
 	minio  *minio.Client
+	bucket string
 	tracer trace.Tracer
 }
 
-func NewTweetMinio(minio *minio.Client, tracer trace.Tracer) *TweetMinio {
-	return &TweetMinio{minio: minio, tracer: tracer}
+func NewTweetMinio(minio *minio.Client, bucket string, tracer trace.Tracer) *TweetMinio {
+	return &TweetMinio{minio: minio, bucket: bucket, tracer: tracer}
+}
+
+// EnsureBucket creates the bucket if it does not exist yet and applies lifecycle rules to it.
+// Objects are expired after expireDays, zero disables expiration and removes the rules.
+func (t *TweetMinio) EnsureBucket(ctx context.Context, expireDays int) error {
+	ctx, span := t.tracer.Start(ctx, "tweetMinio.EnsureBucket")
+	defer span.End()
+
+	exists, err := t.minio.BucketExists(ctx, t.bucket)
+
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		if err := t.minio.MakeBucket(ctx, t.bucket, minio.MakeBucketOptions{}); err != nil {
+			return err
+		}
+	}
+
+	cfg := lifecycle.NewConfiguration()
+
+	if expireDays > 0 {
+		cfg.Rules = []lifecycle.Rule{
+			{
+				ID:         "expire-images",
+				Status:     "Enabled",
+				Expiration: lifecycle.Expiration{Days: lifecycle.ExpirationDays(expireDays)},
+				// S3 keeps the parts of abandoned uploads until this action removes them. It rides on the
+				// expire rule because MinIO rejects a rule that contains only this action.
+				AbortIncompleteMultipartUpload: lifecycle.AbortIncompleteMultipartUpload{
+					DaysAfterInitiation: lifecycle.ExpirationDays(abortMultipartDays),
+				},
+			},
+		}
+	}
+
+	// an empty configuration removes the rules
+	return t.minio.SetBucketLifecycle(ctx, t.bucket, cfg)
 }
 
 func (t *TweetMinio) AddTweetImage(ctx context.Context, image *pb.Image, fileName string) error {
@@ -31,7 +75,7 @@ func (t *TweetMinio) AddTweetImage(ctx context.Context, image *pb.Image, fileNam
 
 	_, err := t.minio.PutObject(
 		ctx,
-		userTweetsName,
+		t.bucket,
 		fileName,
 		reader,
 		reader.Size(),
@@ -44,17 +88,45 @@ func (t *TweetMinio) AddTweetImage(ctx context.Context, image *pb.Image, fileNam
 	return nil
 }
 
+func (t *TweetMinio) GetTweetImage(ctx context.Context, fileName string) (*pb.Image, error) {
+	ctx, span := t.tracer.Start(ctx, "tweetMinio.GetTweetImage")
+	defer span.End()
+
+	object, err := t.minio.GetObject(ctx, t.bucket, fileName, minio.GetObjectOptions{})
+
+	if err != nil {
+		return nil, t.parseError(err)
+	}
+	defer object.Close()
+
+	info, err := object.Stat()
+
+	if err != nil {
+		return nil, t.parseError(err)
+	}
+
+	chunk, err := io.ReadAll(object)
+
+	if err != nil {
+		return nil, t.parseError(err)
+	}
+
+	return &pb.Image{Chunk: chunk, ContentType: info.ContentType, Name: fileName}, nil
+}
+
 func (t *TweetMinio) UpdateTweetImage(ctx context.Context, oldName string, newName string, image *pb.Image) error {
 	ctx, span := t.tracer.Start(ctx, "tweetMinio.UpdateCommentImage")
 	defer span.End()
 
-	err := t.DeleteFile(ctx, oldName)
+	if oldName != "" {
+		err := t.DeleteFile(ctx, oldName)
 
-	if err != nil {
-		return err
+		if err != nil {
+			return err
+		}
 	}
 
-	err = t.AddTweetImage(ctx, image, newName)
+	err := t.AddTweetImage(ctx, image, newName)
 
 	if err != nil {
 		return err
@@ -67,9 +139,51 @@ func (t *TweetMinio) DeleteFile(ctx context.Context, fileName string) error {
 	ctx, span := t.tracer.Start(ctx, "tweetMinio.DeleteFile")
 	defer span.End()
 
-	if err := t.minio.RemoveObject(ctx, userTweetsName, fileName, minio.RemoveObjectOptions{}); err != nil {
+	if err := t.minio.RemoveObject(ctx, t.bucket, fileName, minio.RemoveObjectOptions{}); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// Ping checks that MinIO is reachable and the bucket exists.
+func (t *TweetMinio) Ping(ctx context.Context) error {
+	ctx, span := t.tracer.Start(ctx, "tweetMinio.Ping")
+	defer span.End()
+
+	exists, err := t.minio.BucketExists(ctx, t.bucket)
+
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return fmt.Errorf("bucket %s does not exist", t.bucket)
+	}
+
+	return nil
+}
+
+func (t *TweetMinio) ListFiles(ctx context.Context) ([]domain.StoredFile, error) {
+	ctx, span := t.tracer.Start(ctx, "tweetMinio.ListFiles")
+	defer span.End()
+
+	var files []domain.StoredFile
+
+	for object := range t.minio.ListObjects(ctx, t.bucket, minio.ListObjectsOptions{Recursive: true}) {
+		if object.Err != nil {
+			return nil, object.Err
+		}
+
+		files = append(files, domain.StoredFile{Name: object.Key, Size: object.Size, ModifiedAt: object.LastModified})
+	}
+
+	return files, nil
+}
+
+func (t *TweetMinio) parseError(err error) error {
+	if minio.ToErrorResponse(err).StatusCode == http.StatusNotFound {
+		return grpc_errors.ErrNotFound
+	}
+	return err
+}
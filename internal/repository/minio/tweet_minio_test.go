@@ -0,0 +1,83 @@
+package minio
+
+import (
+	"context"
+	"github.com/Verce11o/yata-tweets/internal/repository"
+	"github.com/Verce11o/yata-tweets/internal/repository/storage/storagetest"
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"go.opentelemetry.io/otel/trace/noop"
+	"os"
+	"strings"
+	"testing"
+)
+
+func getenv(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// TestTweetMinio runs against the MinIO at YATA_TEST_MINIO_ENDPOINT, every subtest in a bucket of its own.
+func TestTweetMinio(t *testing.T) {
+	endpoint := os.Getenv("YATA_TEST_MINIO_ENDPOINT")
+
+	if endpoint == "" {
+		t.Skip("YATA_TEST_MINIO_ENDPOINT is not set")
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds: credentials.NewStaticV4(getenv("YATA_TEST_MINIO_ACCESS_KEY", "minioadmin"), getenv("YATA_TEST_MINIO_SECRET_KEY", "minioadmin"), ""),
+	})
+
+	if err != nil {
+		t.Fatalf("cannot create minio client: %v", err)
+	}
+
+	storagetest.Run(t, func(t *testing.T) repository.StorageRepository {
+		return newTestStorage(t, client, 0)
+	})
+
+	t.Run("EnsureBucketExpiring", func(t *testing.T) {
+		storage := newTestStorage(t, client, 7)
+
+		rules, err := client.GetBucketLifecycle(context.Background(), storage.bucket)
+
+		if err != nil || len(rules.Rules) != 1 || rules.Rules[0].Expiration.Days != 7 {
+			t.Fatalf("GetBucketLifecycle() = %+v, %v, want images expiring after 7 days", rules, err)
+		}
+
+		// turning expiration off again removes the rule
+		if err := storage.EnsureBucket(context.Background(), 0); err != nil {
+			t.Fatalf("EnsureBucket: %v", err)
+		}
+
+		if rules, err := client.GetBucketLifecycle(context.Background(), storage.bucket); err == nil && len(rules.Rules) > 0 {
+			t.Errorf("GetBucketLifecycle() = %+v, want no rules", rules)
+		}
+	})
+}
+
+// newTestStorage creates a bucket of its own and removes it after the test.
+func newTestStorage(t *testing.T, client *minio.Client, expireDays int) *TweetMinio {
+	t.Helper()
+
+	bucket := "yata-test-" + strings.ReplaceAll(uuid.NewString(), "-", "")[:16]
+	storage := NewTweetMinio(client, bucket, noop.NewTracerProvider().Tracer(""))
+
+	t.Cleanup(func() {
+		ctx := context.Background()
+		for object := range client.ListObjects(ctx, bucket, minio.ListObjectsOptions{Recursive: true}) {
+			_ = client.RemoveObject(ctx, bucket, object.Key, minio.RemoveObjectOptions{})
+		}
+		_ = client.RemoveBucket(ctx, bucket)
+	})
+
+	if err := storage.EnsureBucket(context.Background(), expireDays); err != nil {
+		t.Fatalf("EnsureBucket: %v", err)
+	}
+
+	return storage
+}
@@ -0,0 +1,22 @@
+package postgres
+
+import (
+	"context"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	auditResource = "audit record"
+)
+
+// createAuditRecord stores record in the transaction of the action it describes,
+// so the action is only committed together with its audit.
+func createAuditRecord(ctx context.Context, tx *sqlx.Tx, record *domain.AuditRecord) error {
+	q := `INSERT INTO audit_log (actor_id, actor_roles, action, tweet_id, owner_id, reason)
+		VALUES (:actor_id, :actor_roles, :action, :tweet_id, :owner_id, :reason)`
+
+	_, err := tx.NamedExecContext(ctx, q, record)
+
+	return classifyError(err, auditResource, "")
+}
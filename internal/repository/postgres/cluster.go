@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"github.com/Verce11o/yata-tweets/internal/lib/auth"
+	"github.com/Verce11o/yata-tweets/internal/repository"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+	"sync/atomic"
+)
+
+// Cluster routes the read paths to replicas and everything else to the primary.
+// A user who wrote recently reads from the primary, so they see their own writes despite replication lag.
+type Cluster struct {
+	log      *zap.SugaredLogger
+	primary  *DB
+	replicas []*DB
+	writes   repository.RecentWritesRepository
+	next     atomic.Uint64
+}
+
+// NewCluster wraps the pools opened by NewPostgres and NewReplicas. writes is only used when there are replicas.
+func NewCluster(log *zap.SugaredLogger, primary *sqlx.DB, replicas []*sqlx.DB, writes repository.RecentWritesRepository, cacheSize int) *Cluster {
+	c := &Cluster{log: log, primary: NewDB(primary, cacheSize), writes: writes}
+
+	for _, replica := range replicas {
+		c.replicas = append(c.replicas, NewDB(replica, cacheSize))
+	}
+
+	return c
+}
+
+func (c *Cluster) Primary() *DB {
+	return c.primary
+}
+
+// Replicas returns the replica pools, e.g. to check their health.
+func (c *Cluster) Replicas() []*sqlx.DB {
+	replicas := make([]*sqlx.DB, 0, len(c.replicas))
+	for _, replica := range c.replicas {
+		replicas = append(replicas, replica.DB)
+	}
+	return replicas
+}
+
+// Reader picks the replicas in turn, or the primary when the current user wrote recently
+// or the read fills a cache, see repository.ReadPrimary.
+func (c *Cluster) Reader(ctx context.Context) *DB {
+	if len(c.replicas) == 0 || repository.ReadsPrimary(ctx) {
+		return c.primary
+	}
+
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		recent, err := c.writes.WroteRecently(ctx, principal.UserID)
+
+		if err != nil {
+			// not knowing is treated as a recent write, the primary is always up to date
+			c.log.Errorf("cannot check recent writes of user %s: %v", principal.UserID, err)
+			return c.primary
+		}
+
+		if recent {
+			return c.primary
+		}
+	}
+
+	return c.replicas[c.next.Add(1)%uint64(len(c.replicas))]
+}
+
+// Wrote records a write of the current user, to be called after it was committed.
+func (c *Cluster) Wrote(ctx context.Context) {
+	if len(c.replicas) == 0 {
+		return
+	}
+
+	principal, ok := auth.PrincipalFromContext(ctx)
+
+	if !ok {
+		return
+	}
+
+	if err := c.writes.MarkWrite(ctx, principal.UserID); err != nil {
+		c.log.Errorf("cannot mark write of user %s: %v", principal.UserID, err)
+	}
+}
+
+// Close closes the cached statements and the replica pools. The primary pool is closed by its owner.
+func (c *Cluster) Close() error {
+	errs := []error{c.primary.stmts.Close()}
+
+	for _, replica := range c.replicas {
+		errs = append(errs, replica.stmts.Close(), replica.DB.Close())
+	}
+
+	return errors.Join(errs...)
+}
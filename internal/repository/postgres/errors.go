@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/lib/pq"
+	"net"
+	"time"
+)
+
+const (
+	dependencyName = "postgres"
+	retryAfter     = 100 * time.Millisecond
+
+	uniqueViolation      = "23505"
+	foreignKeyViolation  = "23503"
+	invalidText          = "22P02"
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+	queryCanceled        = "57014"
+	adminShutdown        = "57P01"
+	cannotConnectNow     = "57P03"
+	connectionException  = "08"
+)
+
+// classifyError turns database driver errors into domain errors.
+// resource and id describe the row the query was looking for.
+func classifyError(err error, resource string, id string) error {
+	if err == nil {
+		return nil
+	}
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return domain.NotFound(resource, id, err)
+	case errors.Is(err, context.Canceled):
+		return err
+	case errors.Is(err, context.DeadlineExceeded):
+		return domain.Timeout(dependencyName, err)
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, sql.ErrConnDone):
+		return domain.Unavailable(dependencyName, retryAfter, err)
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code == uniqueViolation:
+			return domain.AlreadyExists(resource, id, err)
+		case pqErr.Code == foreignKeyViolation:
+			return domain.FailedPrecondition(resource, id, "referenced resource does not exist", err)
+		case pqErr.Code == invalidText:
+			return domain.InvalidArgument(resource, "malformed identifier", err)
+		case pqErr.Code == serializationFailure, pqErr.Code == deadlockDetected:
+			return domain.Conflict(resource, retryAfter, err)
+		case pqErr.Code == queryCanceled:
+			return domain.Timeout(dependencyName, err)
+		case pqErr.Code == adminShutdown, pqErr.Code == cannotConnectNow, pqErr.Code.Class() == connectionException:
+			return domain.Unavailable(dependencyName, retryAfter, err)
+		}
+		return domain.Internal(err)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return domain.Timeout(dependencyName, err)
+		}
+		return domain.Unavailable(dependencyName, retryAfter, err)
+	}
+
+	return domain.Internal(err)
+}
@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/lib/pq"
+	"testing"
+)
+
+type netError struct {
+	timeout bool
+}
+
+func (e netError) Error() string   { return "read tcp 10.0.0.1:5432: i/o error" }
+func (e netError) Timeout() bool   { return e.timeout }
+func (e netError) Temporary() bool { return false }
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind domain.ErrorKind
+	}{
+		{"no rows", sql.ErrNoRows, domain.KindNotFound},
+		{"wrapped no rows", fmt.Errorf("get tweet: %w", sql.ErrNoRows), domain.KindNotFound},
+		{"deadline", context.DeadlineExceeded, domain.KindDeadlineExceeded},
+		{"bad connection", driver.ErrBadConn, domain.KindUnavailable},
+		{"connection done", sql.ErrConnDone, domain.KindUnavailable},
+		{"unique violation", &pq.Error{Code: uniqueViolation}, domain.KindAlreadyExists},
+		{"foreign key violation", &pq.Error{Code: foreignKeyViolation}, domain.KindFailedPrecondition},
+		{"malformed uuid", &pq.Error{Code: invalidText}, domain.KindInvalidArgument},
+		{"serialization failure", &pq.Error{Code: serializationFailure}, domain.KindAborted},
+		{"deadlock", &pq.Error{Code: deadlockDetected}, domain.KindAborted},
+		{"statement timeout", &pq.Error{Code: queryCanceled}, domain.KindDeadlineExceeded},
+		{"admin shutdown", &pq.Error{Code: adminShutdown}, domain.KindUnavailable},
+		{"starting up", &pq.Error{Code: cannotConnectNow}, domain.KindUnavailable},
+		{"connection failure", &pq.Error{Code: "08006"}, domain.KindUnavailable},
+		{"other pq error", &pq.Error{Code: "42P01"}, domain.KindInternal},
+		{"network timeout", netError{timeout: true}, domain.KindDeadlineExceeded},
+		{"network failure", netError{}, domain.KindUnavailable},
+		{"unknown", errors.New("something broke"), domain.KindInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyError(tt.err, tweetResource, "42")
+
+			if !domain.IsKind(err, tt.kind) {
+				t.Fatalf("classifyError(%v) = %#v, want kind %d", tt.err, err, tt.kind)
+			}
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("classifyError(%v) does not wrap the cause", tt.err)
+			}
+		})
+	}
+}
+
+func TestClassifyErrorPassesThrough(t *testing.T) {
+	if err := classifyError(nil, tweetResource, ""); err != nil {
+		t.Errorf("classifyError(nil) = %v", err)
+	}
+
+	// a cancelled request is not a failure of the database
+	if err := classifyError(context.Canceled, tweetResource, ""); err != context.Canceled {
+		t.Errorf("classifyError(context.Canceled) = %v", err)
+	}
+
+	var notFound *domain.Error
+	if err := classifyError(sql.ErrNoRows, tweetResource, "42"); !errors.As(err, &notFound) || notFound.ResourceID != "42" || notFound.Reason != "TWEET_NOT_FOUND" {
+		t.Errorf("classifyError(sql.ErrNoRows) = %#v", err)
+	}
+}
@@ -0,0 +1,20 @@
+package postgres
+
+import (
+	"database/sql"
+	"github.com/Verce11o/yata-tweets/migrations"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// NewMigrator applies the migrations embedded in the binary.
+// Every operation holds a Postgres advisory lock, so replicas starting together don't race.
+func NewMigrator(db *sql.DB) (*goose.Provider, error) {
+	locker, err := lock.NewPostgresSessionLocker()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return goose.NewProvider(goose.DialectPostgres, db, migrations.FS, goose.WithSessionLocker(locker))
+}
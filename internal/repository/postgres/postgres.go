@@ -6,19 +6,75 @@ import (
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"log"
+	"net"
+	"net/url"
 )
 
+// NewPostgres connects to the primary.
 func NewPostgres(cfg *config.Config) *sqlx.DB {
-	db, err := sqlx.Open("postgres", fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		cfg.Postgres.User, cfg.Postgres.Password, cfg.Postgres.Host, cfg.Postgres.Port, cfg.Postgres.Name))
+	db, err := open(cfg.Postgres, net.JoinHostPort(cfg.Postgres.Host, cfg.Postgres.Port))
 
 	if err != nil {
 		log.Fatal("Error connecting to database: ", err)
 	}
 
+	return db
+}
+
+// NewReplicas connects to every replica in cfg.Postgres.Replicas.Hosts.
+func NewReplicas(cfg *config.Config) []*sqlx.DB {
+	replicas := make([]*sqlx.DB, 0, len(cfg.Postgres.Replicas.Hosts))
+
+	for _, host := range cfg.Postgres.Replicas.Hosts {
+		db, err := open(cfg.Postgres, host)
+
+		if err != nil {
+			log.Fatalf("Error connecting to replica %s: %v", host, err)
+		}
+
+		replicas = append(replicas, db)
+	}
+
+	return replicas
+}
+
+func open(cfg config.PostgresConfig, host string) (*sqlx.DB, error) {
+	db, err := sqlx.Open("postgres", dsn(cfg, host))
+
+	if err != nil {
+		return nil, err
+	}
+
+	db.SetMaxOpenConns(cfg.Pool.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.Pool.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.Pool.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.Pool.ConnMaxIdleTime)
+
 	if err := db.Ping(); err != nil {
-		log.Fatal("Error connecting to database: ", err)
+		db.Close()
+		return nil, err
 	}
 
-	return db
+	return db, nil
+}
+
+func dsn(cfg config.PostgresConfig, host string) string {
+	query := url.Values{}
+	query.Set("sslmode", cfg.SSLMode)
+
+	for key, value := range map[string]string{"sslrootcert": cfg.SSLRootCert, "sslcert": cfg.SSLCert, "sslkey": cfg.SSLKey} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     host,
+		Path:     fmt.Sprintf("/%s", cfg.Name),
+		RawQuery: query.Encode(),
+	}
+
+	return u.String()
 }
@@ -0,0 +1,262 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/lib/pagination"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+const (
+	scheduleResource = "scheduled tweet"
+)
+
+// SchedulePostgres works on the primary only, a schedule must not be published from a stale read.
+type SchedulePostgres struct {
+	db     *sqlx.DB
+	tracer trace.Tracer
+}
+
+func NewSchedulePostgres(db *sqlx.DB, tracer trace.Tracer) *SchedulePostgres {
+	return &SchedulePostgres{db: db, tracer: tracer}
+}
+
+func (s *SchedulePostgres) CreateScheduledTweet(ctx context.Context, schedule *domain.ScheduledTweet) (*domain.ScheduledTweet, error) {
+	ctx, span := s.tracer.Start(ctx, "schedulePostgres.CreateScheduledTweet")
+	defer span.End()
+
+	var created domain.ScheduledTweet
+
+	q := `INSERT INTO scheduled_tweets (user_id, text, image_name, publish_at, time_zone)
+		VALUES ($1, $2, $3, $4, $5) RETURNING *`
+
+	err := s.db.QueryRowxContext(ctx, q, schedule.UserID, schedule.Text, schedule.ImageName, schedule.PublishAt, schedule.TimeZone).StructScan(&created)
+
+	if err != nil {
+		return nil, classifyError(err, scheduleResource, "")
+	}
+
+	return &created, nil
+}
+
+func (s *SchedulePostgres) GetScheduledTweet(ctx context.Context, userID string, scheduleID string) (*domain.ScheduledTweet, error) {
+	ctx, span := s.tracer.Start(ctx, "schedulePostgres.GetScheduledTweet")
+	defer span.End()
+
+	var schedule domain.ScheduledTweet
+
+	q := "SELECT * FROM scheduled_tweets WHERE schedule_id = $1 AND user_id = $2"
+
+	if err := s.db.QueryRowxContext(ctx, q, scheduleID, userID).StructScan(&schedule); err != nil {
+		return nil, classifyError(err, scheduleResource, scheduleID)
+	}
+
+	return &schedule, nil
+}
+
+// ListScheduledTweets pages through the schedules of a user with the given status, in publishing order.
+func (s *SchedulePostgres) ListScheduledTweets(ctx context.Context, userID string, status domain.ScheduleStatus, cursor string, limit int) ([]*domain.ScheduledTweet, string, error) {
+	ctx, span := s.tracer.Start(ctx, "schedulePostgres.ListScheduledTweets")
+	defer span.End()
+
+	var publishAt time.Time
+	var scheduleID uuid.UUID
+	var err error
+
+	if cursor != "" {
+		publishAt, scheduleID, err = pagination.DecodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	q := `SELECT * FROM scheduled_tweets
+		WHERE user_id = $1 AND status = $2 AND (publish_at, schedule_id) > ($3, $4)
+		ORDER BY publish_at, schedule_id LIMIT $5`
+
+	var schedules []*domain.ScheduledTweet
+
+	if err := s.db.SelectContext(ctx, &schedules, q, userID, status, publishAt, scheduleID, limit); err != nil {
+		return nil, "", classifyError(err, scheduleResource, "")
+	}
+
+	var nextCursor string
+	if len(schedules) == limit {
+		last := schedules[len(schedules)-1]
+		nextCursor = pagination.EncodeCursor(last.PublishAt, last.ScheduleID.String())
+	}
+
+	return schedules, nextCursor, nil
+}
+
+func (s *SchedulePostgres) UpdateScheduledTweet(ctx context.Context, schedule *domain.ScheduledTweet) (*domain.ScheduledTweet, error) {
+	ctx, span := s.tracer.Start(ctx, "schedulePostgres.UpdateScheduledTweet")
+	defer span.End()
+
+	var updated domain.ScheduledTweet
+
+	// waits for the scheduler when it is publishing the row, and then matches nothing
+	q := `UPDATE scheduled_tweets SET text = $1, image_name = $2, publish_at = $3, time_zone = $4,
+			attempts = 0, last_error = '', next_attempt_at = NULL, updated_at = NOW()
+		WHERE schedule_id = $5 AND user_id = $6 AND status = 'pending' RETURNING *`
+
+	err := s.db.QueryRowxContext(ctx, q, schedule.Text, schedule.ImageName, schedule.PublishAt, schedule.TimeZone, schedule.ScheduleID, schedule.UserID).StructScan(&updated)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, s.notPending(ctx, schedule.UserID.String(), schedule.ScheduleID.String())
+	}
+
+	if err != nil {
+		return nil, classifyError(err, scheduleResource, schedule.ScheduleID.String())
+	}
+
+	return &updated, nil
+}
+
+func (s *SchedulePostgres) CancelScheduledTweet(ctx context.Context, userID string, scheduleID string) (*domain.ScheduledTweet, error) {
+	ctx, span := s.tracer.Start(ctx, "schedulePostgres.CancelScheduledTweet")
+	defer span.End()
+
+	var cancelled domain.ScheduledTweet
+
+	q := `UPDATE scheduled_tweets SET status = 'cancelled', updated_at = NOW()
+		WHERE schedule_id = $1 AND user_id = $2 AND status = 'pending' RETURNING *`
+
+	err := s.db.QueryRowxContext(ctx, q, scheduleID, userID).StructScan(&cancelled)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, s.notPending(ctx, userID, scheduleID)
+	}
+
+	if err != nil {
+		return nil, classifyError(err, scheduleResource, scheduleID)
+	}
+
+	return &cancelled, nil
+}
+
+// PublishDueTweet creates the tweet of one due schedule and marks the schedule published in the same
+// transaction. The schedule is locked with SKIP LOCKED, so replicas publishing at the same time pick
+// different schedules and each one is published once. Schedules that failed are left alone until their
+// next attempt. It returns nil when nothing is due.
+func (s *SchedulePostgres) PublishDueTweet(ctx context.Context) (*domain.ScheduledTweet, *domain.Tweet, error) {
+	ctx, span := s.tracer.Start(ctx, "schedulePostgres.PublishDueTweet")
+	defer span.End()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+
+	if err != nil {
+		return nil, nil, classifyError(err, scheduleResource, "")
+	}
+	defer tx.Rollback() // a no-op once committed
+
+	var schedule domain.ScheduledTweet
+
+	q := `SELECT * FROM scheduled_tweets
+		WHERE status = 'pending' AND publish_at <= NOW() AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
+		ORDER BY attempts, publish_at LIMIT 1 FOR UPDATE SKIP LOCKED`
+
+	err = tx.QueryRowxContext(ctx, q).StructScan(&schedule)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, nil
+	}
+
+	if err != nil {
+		return nil, nil, classifyError(err, scheduleResource, "")
+	}
+
+	var tweet domain.Tweet
+
+	q = "INSERT INTO tweets (user_id, text, image_name) VALUES ($1, $2, $3) RETURNING " + tweetColumns
+
+	if err := tx.QueryRowxContext(ctx, q, schedule.UserID, schedule.Text, schedule.ImageName).StructScan(&tweet); err != nil {
+		return &schedule, nil, classifyError(err, tweetResource, "")
+	}
+
+	q = `UPDATE scheduled_tweets SET status = 'published', tweet_id = $1, published_at = $2, updated_at = NOW()
+		WHERE schedule_id = $3 RETURNING *`
+
+	if err := tx.QueryRowxContext(ctx, q, tweet.TweetID, tweet.CreatedAt, schedule.ScheduleID).StructScan(&schedule); err != nil {
+		return &schedule, nil, classifyError(err, scheduleResource, schedule.ScheduleID.String())
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &schedule, nil, classifyError(err, scheduleResource, schedule.ScheduleID.String())
+	}
+
+	return &schedule, &tweet, nil
+}
+
+// RecordPublishFailure counts a failed attempt and gives up on the schedule after maxAttempts.
+// Until then the next attempt is put off by backoff, doubled with every attempt up to maxBackoff.
+func (s *SchedulePostgres) RecordPublishFailure(ctx context.Context, scheduleID string, cause string, maxAttempts int, backoff time.Duration, maxBackoff time.Duration) (*domain.ScheduledTweet, error) {
+	ctx, span := s.tracer.Start(ctx, "schedulePostgres.RecordPublishFailure")
+	defer span.End()
+
+	var schedule domain.ScheduledTweet
+
+	q := `UPDATE scheduled_tweets
+		SET attempts = attempts + 1, last_error = $1, updated_at = NOW(),
+			status = CASE WHEN attempts + 1 >= $2 THEN 'failed' ELSE status END,
+			next_attempt_at = NOW() + LEAST($3 * POWER(2, attempts), $4) * INTERVAL '1 second'
+		WHERE schedule_id = $5 AND status = 'pending' RETURNING *`
+
+	err := s.db.QueryRowxContext(ctx, q, cause, maxAttempts, backoff.Seconds(), maxBackoff.Seconds(), scheduleID).StructScan(&schedule)
+
+	if err != nil {
+		return nil, classifyError(err, scheduleResource, scheduleID)
+	}
+
+	return &schedule, nil
+}
+
+// GetPublishLag measures the lag on the clock of the database, the one publish_at is compared to.
+func (s *SchedulePostgres) GetPublishLag(ctx context.Context) (time.Duration, error) {
+	ctx, span := s.tracer.Start(ctx, "schedulePostgres.GetPublishLag")
+	defer span.End()
+
+	var seconds float64
+
+	q := `SELECT COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(publish_at)), 0)::float8
+		FROM scheduled_tweets WHERE status = 'pending' AND publish_at <= NOW()`
+
+	if err := s.db.QueryRowxContext(ctx, q).Scan(&seconds); err != nil {
+		return 0, classifyError(err, scheduleResource, "")
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// ListPendingImageNames returns the images uploaded for tweets that are not published yet.
+func (s *SchedulePostgres) ListPendingImageNames(ctx context.Context) ([]string, error) {
+	ctx, span := s.tracer.Start(ctx, "schedulePostgres.ListPendingImageNames")
+	defer span.End()
+
+	var names []string
+
+	q := "SELECT image_name FROM scheduled_tweets WHERE status = 'pending' AND image_name <> ''"
+
+	if err := s.db.SelectContext(ctx, &names, q); err != nil {
+		return nil, classifyError(err, scheduleResource, "")
+	}
+
+	return names, nil
+}
+
+// notPending tells a schedule that does not exist apart from one that can no longer be changed.
+func (s *SchedulePostgres) notPending(ctx context.Context, userID string, scheduleID string) error {
+	schedule, err := s.GetScheduledTweet(ctx, userID, scheduleID)
+
+	if err != nil {
+		return err
+	}
+
+	return domain.FailedPrecondition(scheduleResource, scheduleID, fmt.Sprintf("scheduled tweet is already %s", schedule.Status), nil)
+}
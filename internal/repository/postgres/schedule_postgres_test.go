@@ -0,0 +1,177 @@
+package postgres
+
+import (
+	"context"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel/trace/noop"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testDB connects to YATA_TEST_POSTGRES_DSN and migrates it. The tests publish every due schedule
+// they find, so the database must not be one anything else relies on.
+func testDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	dsn := os.Getenv("YATA_TEST_POSTGRES_DSN")
+
+	if dsn == "" {
+		t.Skip("YATA_TEST_POSTGRES_DSN is not set")
+	}
+
+	db, err := sqlx.Connect("postgres", dsn)
+
+	if err != nil {
+		t.Fatalf("cannot connect to postgres: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	migrator, err := NewMigrator(db.DB)
+
+	if err != nil {
+		t.Fatalf("cannot init migrations: %v", err)
+	}
+
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("cannot migrate: %v", err)
+	}
+
+	return db
+}
+
+// scheduleDue creates count schedules of a new user that are due already and removes them after the test.
+func scheduleDue(t *testing.T, repo *SchedulePostgres, db *sqlx.DB, count int) (uuid.UUID, map[uuid.UUID]bool) {
+	t.Helper()
+
+	userID := uuid.New()
+	ids := make(map[uuid.UUID]bool, count)
+
+	t.Cleanup(func() {
+		_, _ = db.Exec("DELETE FROM scheduled_tweets WHERE user_id = $1", userID)
+		_, _ = db.Exec("DELETE FROM tweets WHERE user_id = $1", userID)
+	})
+
+	for i := 0; i < count; i++ {
+		schedule, err := repo.CreateScheduledTweet(context.Background(), &domain.ScheduledTweet{
+			UserID:    userID,
+			Text:      "scheduled",
+			PublishAt: time.Now().Add(-time.Minute),
+			TimeZone:  "UTC",
+		})
+
+		if err != nil {
+			t.Fatalf("CreateScheduledTweet() = %v", err)
+		}
+
+		ids[schedule.ScheduleID] = true
+	}
+
+	return userID, ids
+}
+
+func TestPublishDueTweetConcurrently(t *testing.T) {
+	db := testDB(t)
+	repo := NewSchedulePostgres(db, noop.NewTracerProvider().Tracer(""))
+
+	userID, ids := scheduleDue(t, repo, db, 50)
+
+	var mu sync.Mutex
+	published := make(map[uuid.UUID]int)
+	var wg sync.WaitGroup
+
+	for replica := 0; replica < 2; replica++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				schedule, tweet, err := repo.PublishDueTweet(context.Background())
+
+				if err != nil {
+					t.Errorf("PublishDueTweet() = %v", err)
+					return
+				}
+
+				if schedule == nil {
+					return
+				}
+
+				if tweet == nil || schedule.TweetID == nil || *schedule.TweetID != tweet.TweetID {
+					t.Errorf("schedule %s was published without its tweet", schedule.ScheduleID)
+				}
+
+				mu.Lock()
+				published[schedule.ScheduleID]++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for id := range ids {
+		if published[id] != 1 {
+			t.Errorf("schedule %s was published %d times", id, published[id])
+		}
+	}
+
+	var tweets int
+
+	if err := db.Get(&tweets, "SELECT COUNT(*) FROM tweets WHERE user_id = $1", userID); err != nil {
+		t.Fatal(err)
+	}
+
+	if tweets != len(ids) {
+		t.Errorf("%d tweets were created for %d schedules", tweets, len(ids))
+	}
+}
+
+func TestPublishDueTweetSkipsFailedUntilNextAttempt(t *testing.T) {
+	db := testDB(t)
+	repo := NewSchedulePostgres(db, noop.NewTracerProvider().Tracer(""))
+
+	_, ids := scheduleDue(t, repo, db, 2)
+
+	var failedID uuid.UUID
+	for id := range ids {
+		failedID = id
+		break
+	}
+
+	failed, err := repo.RecordPublishFailure(context.Background(), failedID.String(), "storage is down", 5, time.Hour, time.Hour)
+
+	if err != nil {
+		t.Fatalf("RecordPublishFailure() = %v", err)
+	}
+
+	if failed.Status != domain.ScheduleStatusPending || failed.Attempts != 1 || failed.NextAttemptAt == nil || !failed.NextAttemptAt.After(time.Now()) {
+		t.Fatalf("failed schedule is %s after %d attempts, next attempt at %v", failed.Status, failed.Attempts, failed.NextAttemptAt)
+	}
+
+	for {
+		schedule, _, err := repo.PublishDueTweet(context.Background())
+
+		if err != nil {
+			t.Fatalf("PublishDueTweet() = %v", err)
+		}
+
+		if schedule == nil {
+			break
+		}
+
+		if schedule.ScheduleID == failedID {
+			t.Fatalf("schedule %s was published before its next attempt", failedID)
+		}
+
+		delete(ids, schedule.ScheduleID)
+	}
+
+	if len(ids) != 1 || !ids[failedID] {
+		t.Errorf("schedules left unpublished: %v, want only %s", ids, failedID)
+	}
+}
@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"sync"
+)
+
+// DB is a connection pool with a cache of its prepared statements.
+type DB struct {
+	*sqlx.DB
+	stmts *stmtCache
+}
+
+func NewDB(db *sqlx.DB, cacheSize int) *DB {
+	return &DB{DB: db, stmts: newStmtCache(db, cacheSize)}
+}
+
+// Prepared returns the prepared statement for query, preparing it on first use.
+// release has to be called once the statement and the rows it returned are no longer used.
+func (d *DB) Prepared(ctx context.Context, query string) (*sqlx.Stmt, func(), error) {
+	return d.stmts.get(ctx, query)
+}
+
+// stmtCache keeps up to size statements and closes the least recently used one when it is full.
+type stmtCache struct {
+	db   *sqlx.DB
+	size int
+
+	mu    sync.Mutex
+	order *list.List
+	items map[string]*list.Element
+}
+
+type cachedStmt struct {
+	query string
+	stmt  *sqlx.Stmt
+	// held for reading while the statement is in use, an evicted statement is closed once it is free
+	inUse sync.RWMutex
+}
+
+func newStmtCache(db *sqlx.DB, size int) *stmtCache {
+	return &stmtCache{db: db, size: size, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *stmtCache) get(ctx context.Context, query string) (*sqlx.Stmt, func(), error) {
+	c.mu.Lock()
+
+	if el, ok := c.items[query]; ok {
+		c.order.MoveToFront(el)
+		entry := el.Value.(*cachedStmt)
+		entry.inUse.RLock()
+		c.mu.Unlock()
+		return entry.stmt, entry.inUse.RUnlock, nil
+	}
+
+	c.mu.Unlock()
+
+	// prepare without the lock, a concurrent caller may prepare the same query and one of them is dropped
+	stmt, err := c.db.PreparexContext(ctx, query)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[query]; ok {
+		_ = stmt.Close()
+		c.order.MoveToFront(el)
+		entry := el.Value.(*cachedStmt)
+		entry.inUse.RLock()
+		return entry.stmt, entry.inUse.RUnlock, nil
+	}
+
+	entry := &cachedStmt{query: query, stmt: stmt}
+	entry.inUse.RLock()
+	c.items[query] = c.order.PushFront(entry)
+
+	for c.order.Len() > c.size {
+		c.evict(c.order.Back())
+	}
+
+	return stmt, entry.inUse.RUnlock, nil
+}
+
+func (c *stmtCache) evict(el *list.Element) {
+	entry := c.order.Remove(el).(*cachedStmt)
+	delete(c.items, entry.query)
+
+	go func() {
+		entry.inUse.Lock()
+		defer entry.inUse.Unlock()
+		_ = entry.stmt.Close()
+	}()
+}
+
+// Close closes every cached statement, waiting for the ones in use to be released.
+func (c *stmtCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+
+	for _, el := range c.items {
+		entry := el.Value.(*cachedStmt)
+
+		entry.inUse.Lock()
+		errs = append(errs, entry.stmt.Close())
+		entry.inUse.Unlock()
+	}
+
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+
+	return errors.Join(errs...)
+}
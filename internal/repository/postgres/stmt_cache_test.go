@@ -0,0 +1,196 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errPrepare = errors.New("syntax error")
+
+// stmtDriver records the statements prepared and closed through it. Statements cannot be executed.
+type stmtDriver struct {
+	mu       sync.Mutex
+	prepared map[string]int
+	closed   map[string]int
+}
+
+func (d *stmtDriver) Connect(context.Context) (driver.Conn, error) { return stmtConn{d}, nil }
+func (d *stmtDriver) Driver() driver.Driver                        { return nil }
+
+func (d *stmtDriver) count(counts map[string]int, query string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return counts[query]
+}
+
+type stmtConn struct {
+	d *stmtDriver
+}
+
+func (c stmtConn) Prepare(query string) (driver.Stmt, error) {
+	if query == "invalid" {
+		return nil, errPrepare
+	}
+
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.d.prepared[query]++
+
+	return &stmt{d: c.d, query: query}, nil
+}
+
+func (c stmtConn) Close() error              { return nil }
+func (c stmtConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type stmt struct {
+	d     *stmtDriver
+	query string
+}
+
+func (s *stmt) Close() error {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.closed[s.query]++
+	return nil
+}
+
+func (s *stmt) NumInput() int                              { return -1 }
+func (s *stmt) Exec([]driver.Value) (driver.Result, error) { return nil, errors.New("not supported") }
+func (s *stmt) Query([]driver.Value) (driver.Rows, error)  { return nil, errors.New("not supported") }
+
+func newTestStmtCache(t *testing.T, size int) (*stmtCache, *stmtDriver) {
+	t.Helper()
+
+	d := &stmtDriver{prepared: make(map[string]int), closed: make(map[string]int)}
+	db := sqlx.NewDb(sql.OpenDB(d), "postgres")
+	t.Cleanup(func() { _ = db.Close() })
+
+	return newStmtCache(db, size), d
+}
+
+// eventually waits for an evicted statement, they are closed in the background.
+func eventually(t *testing.T, cond func() bool) bool {
+	t.Helper()
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if cond() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestStmtCache(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		// queries are prepared in order, each one released right away
+		queries      []string
+		wantPrepared map[string]int
+		wantClosed   []string
+	}{
+		{"reused", 2, []string{"q1", "q1", "q1"}, map[string]int{"q1": 1}, nil},
+		{"least recently used is closed", 2, []string{"q1", "q2", "q3"}, map[string]int{"q1": 1, "q2": 1, "q3": 1}, []string{"q1"}},
+		{"use keeps a statement", 2, []string{"q1", "q2", "q1", "q3"}, map[string]int{"q1": 1, "q2": 1, "q3": 1}, []string{"q2"}},
+		{"evicted is prepared again", 1, []string{"q1", "q2", "q1"}, map[string]int{"q1": 2, "q2": 1}, []string{"q1", "q2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache, d := newTestStmtCache(t, tt.size)
+
+			for _, query := range tt.queries {
+				_, release, err := cache.get(context.Background(), query)
+
+				if err != nil {
+					t.Fatalf("get(%s) = %v", query, err)
+				}
+
+				release()
+			}
+
+			for query, want := range tt.wantPrepared {
+				if got := d.count(d.prepared, query); got != want {
+					t.Errorf("%s prepared %d times, want %d", query, got, want)
+				}
+			}
+
+			for _, query := range tt.wantClosed {
+				if !eventually(t, func() bool { return d.count(d.closed, query) > 0 }) {
+					t.Errorf("%s was not closed", query)
+				}
+			}
+
+			if got := cache.order.Len(); got > tt.size {
+				t.Errorf("%d statements cached, size is %d", got, tt.size)
+			}
+		})
+	}
+}
+
+func TestStmtCacheClosesEvictedOnceReleased(t *testing.T) {
+	cache, d := newTestStmtCache(t, 1)
+
+	_, release, err := cache.get(context.Background(), "q1")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, releaseQ2, err := cache.get(context.Background(), "q2"); err != nil {
+		t.Fatal(err)
+	} else {
+		releaseQ2()
+	}
+
+	if eventually(t, func() bool { return d.count(d.closed, "q1") > 0 }) {
+		t.Fatal("q1 was closed while in use")
+	}
+
+	release()
+
+	if !eventually(t, func() bool { return d.count(d.closed, "q1") > 0 }) {
+		t.Error("q1 was not closed after it was released")
+	}
+}
+
+func TestStmtCachePrepareError(t *testing.T) {
+	cache, _ := newTestStmtCache(t, 2)
+
+	if _, _, err := cache.get(context.Background(), "invalid"); !errors.Is(err, errPrepare) {
+		t.Fatalf("get(invalid) = %v, want %v", err, errPrepare)
+	}
+
+	if cache.order.Len() != 0 {
+		t.Errorf("a failed statement was cached")
+	}
+}
+
+func TestStmtCacheClose(t *testing.T) {
+	cache, d := newTestStmtCache(t, 4)
+
+	for _, query := range []string{"q1", "q2"} {
+		_, release, err := cache.get(context.Background(), query)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		release()
+	}
+
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+
+	if d.count(d.closed, "q1") != 1 || d.count(d.closed, "q2") != 1 || cache.order.Len() != 0 {
+		t.Errorf("Close() left statements open: %v", d.closed)
+	}
+}
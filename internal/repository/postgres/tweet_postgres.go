@@ -6,47 +6,57 @@ import (
 	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
 	"github.com/Verce11o/yata-tweets/internal/domain"
 	"github.com/Verce11o/yata-tweets/internal/lib/pagination"
+	"github.com/Verce11o/yata-tweets/internal/lib/settings"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"time"
 )
 
 const (
-	paginationLimit = 10
+	tweetResource = "tweet"
+	// tweetColumns are listed instead of *, prepared statements are cached and Postgres rejects
+	// a cached statement whose result columns changed, e.g. after a migration added one.
+	tweetColumns = "tweet_id, user_id, text, image_name, hidden, replies_locked, created_at, updated_at"
 )
 
+// TweetPostgres reads tweets from replicas when the cluster has any, see Cluster.Reader.
 type TweetPostgres struct {
-	db     *sqlx.DB
-	tracer trace.Tracer
+	db       *Cluster
+	tracer   trace.Tracer
+	settings *settings.Store
 }
 
-func NewTweetPostgres(db *sqlx.DB, tracer trace.Tracer) *TweetPostgres {
-	return &TweetPostgres{db: db, tracer: tracer}
+func NewTweetPostgres(db *Cluster, tracer trace.Tracer, settings *settings.Store) *TweetPostgres {
+	return &TweetPostgres{db: db, tracer: tracer, settings: settings}
 }
 
-func (t *TweetPostgres) CreateTweet(ctx context.Context, input *pb.CreateTweetRequest, imageName string) (string, error) {
+func (t *TweetPostgres) CreateTweet(ctx context.Context, userID string, input *pb.CreateTweetRequest, imageName string) (*domain.Tweet, error) {
 	ctx, span := t.tracer.Start(ctx, "tweetPostgres.CreateTweet")
 	defer span.End()
 
-	var tweetID string
+	var tweet domain.Tweet
 
-	q := "INSERT INTO tweets (user_id, text, image_name) VALUES ($1, $2, $3) RETURNING tweet_id"
+	q := "INSERT INTO tweets (user_id, text, image_name) VALUES ($1, $2, $3) RETURNING " + tweetColumns
 
-	stmt, err := t.db.PreparexContext(ctx, q)
+	stmt, release, err := t.db.Primary().Prepared(ctx, q)
 
 	if err != nil {
-		return "", err
+		return nil, classifyError(err, tweetResource, "")
 	}
+	defer release()
 
-	err = stmt.QueryRowxContext(ctx, input.GetUserId(), input.GetText(), imageName).Scan(&tweetID)
+	err = stmt.QueryRowxContext(ctx, userID, input.GetText(), imageName).StructScan(&tweet)
 
 	if err != nil {
-		return "", err
+		return nil, classifyError(err, tweetResource, "")
 	}
 
-	return tweetID, nil
+	t.db.Wrote(ctx)
+
+	return &tweet, nil
 
 }
 
@@ -56,12 +66,19 @@ func (t *TweetPostgres) GetTweet(ctx context.Context, tweetID string) (*domain.T
 
 	var tweet domain.Tweet
 
-	q := "SELECT * FROM tweets WHERE tweet_id = $1"
+	q := "SELECT " + tweetColumns + " FROM tweets WHERE tweet_id = $1"
+
+	stmt, release, err := t.db.Reader(ctx).Prepared(ctx, q)
+
+	if err != nil {
+		return nil, classifyError(err, tweetResource, tweetID)
+	}
+	defer release()
 
-	err := t.db.QueryRowxContext(ctx, q, tweetID).StructScan(&tweet)
+	err = stmt.QueryRowxContext(ctx, tweetID).StructScan(&tweet)
 
 	if err != nil {
-		return nil, sql.ErrNoRows
+		return nil, classifyError(err, tweetResource, tweetID)
 	}
 
 	return &tweet, nil
@@ -82,13 +99,21 @@ func (t *TweetPostgres) GetAllTweets(ctx context.Context, cursor string) ([]*pb.
 		}
 	}
 
-	q := "SELECT * FROM tweets WHERE (created_at, tweet_id) > ($1, $2) ORDER BY created_at, tweet_id LIMIT $3"
+	q := "SELECT " + tweetColumns + " FROM tweets WHERE (created_at, tweet_id) > ($1, $2) AND NOT hidden ORDER BY created_at, tweet_id LIMIT $3"
 
-	rows, err := t.db.QueryxContext(ctx, q, createdAt, tweetID, paginationLimit)
+	stmt, release, err := t.db.Reader(ctx).Prepared(ctx, q)
 
 	if err != nil {
-		return nil, "", err
+		return nil, "", classifyError(err, tweetResource, "")
 	}
+	defer release()
+
+	rows, err := stmt.QueryxContext(ctx, createdAt, tweetID, t.settings.Get().PageSize)
+
+	if err != nil {
+		return nil, "", classifyError(err, tweetResource, "")
+	}
+	defer rows.Close()
 
 	var tweets []*pb.Tweet
 	var latestCreatedAt time.Time
@@ -97,7 +122,7 @@ func (t *TweetPostgres) GetAllTweets(ctx context.Context, cursor string) ([]*pb.
 		var item domain.Tweet
 		err = rows.StructScan(&item)
 		if err != nil {
-			return nil, "", err
+			return nil, "", classifyError(err, tweetResource, "")
 		}
 		tweets = append(tweets, &pb.Tweet{
 			UserId:    item.UserID.String(),
@@ -108,6 +133,10 @@ func (t *TweetPostgres) GetAllTweets(ctx context.Context, cursor string) ([]*pb.
 		latestCreatedAt = item.CreatedAt
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, "", classifyError(err, tweetResource, "")
+	}
+
 	var nextCursor string
 	if len(tweets) > 0 {
 		nextCursor = pagination.EncodeCursor(latestCreatedAt, tweets[len(tweets)-1].TweetId)
@@ -116,43 +145,188 @@ func (t *TweetPostgres) GetAllTweets(ctx context.Context, cursor string) ([]*pb.
 	return tweets, nextCursor, nil
 }
 
+func (t *TweetPostgres) GetTweetsByIDs(ctx context.Context, tweetIDs []string) ([]*domain.Tweet, error) {
+	ctx, span := t.tracer.Start(ctx, "tweetPostgres.GetTweetsByIDs")
+	defer span.End()
+
+	if len(tweetIDs) == 0 {
+		return nil, nil
+	}
+
+	q := "SELECT " + tweetColumns + " FROM tweets WHERE tweet_id = ANY($1::uuid[])"
+
+	stmt, release, err := t.db.Reader(ctx).Prepared(ctx, q)
+
+	if err != nil {
+		return nil, classifyError(err, tweetResource, "")
+	}
+	defer release()
+
+	var tweets []*domain.Tweet
+
+	if err := stmt.SelectContext(ctx, &tweets, pq.Array(tweetIDs)); err != nil {
+		return nil, classifyError(err, tweetResource, "")
+	}
+
+	return tweets, nil
+}
+
 func (t *TweetPostgres) UpdateTweet(ctx context.Context, input *pb.UpdateTweetRequest, imageName string) (*domain.Tweet, error) {
 	ctx, span := t.tracer.Start(ctx, "tweetPostgres.UpdateTweet")
 	defer span.End()
 
 	var tweet domain.Tweet
 
-	q := "UPDATE tweets SET text = $1, image_name = $2, updated_at = CURRENT_TIMESTAMP WHERE tweet_id = $3 RETURNING *"
+	q := "UPDATE tweets SET text = $1, image_name = $2, updated_at = CURRENT_TIMESTAMP WHERE tweet_id = $3 RETURNING " + tweetColumns
 
-	if err := t.db.QueryRowxContext(ctx, q, input.GetText(), imageName, input.GetTweetId()).StructScan(&tweet); err != nil {
-		return nil, err
+	if err := t.db.Primary().QueryRowxContext(ctx, q, input.GetText(), imageName, input.GetTweetId()).StructScan(&tweet); err != nil {
+		return nil, classifyError(err, tweetResource, input.GetTweetId())
 	}
 
+	t.db.Wrote(ctx)
+
 	return &tweet, nil
 
 }
 
-func (t *TweetPostgres) DeleteTweet(ctx context.Context, tweetID string) error {
+func (t *TweetPostgres) DeleteTweet(ctx context.Context, tweetID string, audit *domain.AuditRecord) error {
 	ctx, span := t.tracer.Start(ctx, "tweetPostgres.DeleteTweet")
 	defer span.End()
 
 	q := "DELETE FROM tweets WHERE tweet_id = $1"
 
-	res, err := t.db.ExecContext(ctx, q, tweetID)
+	err := t.writeAudited(ctx, audit, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, q, tweetID)
+
+		if err != nil {
+			return classifyError(err, tweetResource, tweetID)
+		}
+
+		rowsAffected, err := res.RowsAffected()
+
+		if err != nil {
+			return classifyError(err, tweetResource, tweetID)
+		}
+
+		if rowsAffected == 0 {
+			return classifyError(sql.ErrNoRows, tweetResource, tweetID)
+		}
+
+		return nil
+	})
 
 	if err != nil {
 		return err
 	}
 
-	rowsAffected, err := res.RowsAffected()
+	t.db.Wrote(ctx)
+
+	return nil
+}
+
+func (t *TweetPostgres) SetTweetHidden(ctx context.Context, tweetID string, hidden bool, audit *domain.AuditRecord) (*domain.Tweet, error) {
+	ctx, span := t.tracer.Start(ctx, "tweetPostgres.SetTweetHidden")
+	defer span.End()
+
+	var tweet domain.Tweet
+
+	q := "UPDATE tweets SET hidden = $1 WHERE tweet_id = $2 RETURNING " + tweetColumns
+
+	err := t.writeAudited(ctx, audit, func(tx *sqlx.Tx) error {
+		return classifyError(tx.QueryRowxContext(ctx, q, hidden, tweetID).StructScan(&tweet), tweetResource, tweetID)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	t.db.Wrote(ctx)
+
+	return &tweet, nil
+}
+
+func (t *TweetPostgres) SetRepliesLocked(ctx context.Context, tweetID string, locked bool, audit *domain.AuditRecord) (*domain.Tweet, error) {
+	ctx, span := t.tracer.Start(ctx, "tweetPostgres.SetRepliesLocked")
+	defer span.End()
+
+	var tweet domain.Tweet
+
+	q := "UPDATE tweets SET replies_locked = $1 WHERE tweet_id = $2 RETURNING " + tweetColumns
+
+	err := t.writeAudited(ctx, audit, func(tx *sqlx.Tx) error {
+		return classifyError(tx.QueryRowxContext(ctx, q, locked, tweetID).StructScan(&tweet), tweetResource, tweetID)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	t.db.Wrote(ctx)
+
+	return &tweet, nil
+}
+
+// writeAudited runs write in a transaction on the primary and stores audit, when not nil, in the
+// same transaction. A privileged change is never committed without its audit record, and a record
+// is never left behind for a change that failed.
+func (t *TweetPostgres) writeAudited(ctx context.Context, audit *domain.AuditRecord, write func(tx *sqlx.Tx) error) error {
+	tx, err := t.db.Primary().BeginTxx(ctx, nil)
 
 	if err != nil {
+		return classifyError(err, tweetResource, "")
+	}
+	defer tx.Rollback() // a no-op once committed
+
+	if err := write(tx); err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
-		return sql.ErrNoRows
+	if audit != nil {
+		if err := createAuditRecord(ctx, tx, audit); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return classifyError(tx.Commit(), tweetResource, "")
+}
+
+// ListTweets pages through the tweets matching filter, hidden ones included, in creation order.
+func (t *TweetPostgres) ListTweets(ctx context.Context, filter domain.TweetFilter, cursor string, limit int) ([]*domain.Tweet, string, error) {
+	ctx, span := t.tracer.Start(ctx, "tweetPostgres.ListTweets")
+	defer span.End()
+
+	var createdAt time.Time
+	var tweetID uuid.UUID
+	var err error
+
+	if cursor != "" {
+		createdAt, tweetID, err = pagination.DecodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	var userID interface{}
+
+	if filter.UserID != "" {
+		userID = filter.UserID
+	}
+
+	q := "SELECT " + tweetColumns + ` FROM tweets
+		WHERE (created_at, tweet_id) > ($1, $2) AND ($3::uuid IS NULL OR user_id = $3::uuid) AND created_at >= $4
+		ORDER BY created_at, tweet_id LIMIT $5`
+
+	var tweets []*domain.Tweet
+
+	if err := t.db.Primary().SelectContext(ctx, &tweets, q, createdAt, tweetID, userID, filter.CreatedSince, limit); err != nil {
+		return nil, "", classifyError(err, tweetResource, "")
+	}
+
+	var nextCursor string
+	if len(tweets) == limit {
+		last := tweets[len(tweets)-1]
+		nextCursor = pagination.EncodeCursor(last.CreatedAt, last.TweetID.String())
+	}
+
+	return tweets, nextCursor, nil
 }
@@ -0,0 +1,154 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"strings"
+)
+
+const (
+	feedStreamKey  = "feed:stream"
+	feedChannel    = "feed:events"
+	feedEventField = "event"
+)
+
+// publishFeedScript appends the event to the stream and broadcasts it with the assigned ID in one step,
+// so that live subscribers observe events in the same order as the stream stores them.
+var publishFeedScript = redis.NewScript(`
+local id = redis.call("XADD", KEYS[1], "MAXLEN", "~", ARGV[1], "*", "event", ARGV[2])
+redis.call("PUBLISH", ARGV[3], id .. "\n" .. ARGV[2])
+return id
+`)
+
+// FeedRedis keeps recent feed events in a capped Redis stream for resuming
+// and broadcasts them over pub/sub to every replica.
+type FeedRedis struct {
+	client redis.UniversalClient
+	tracer trace.Tracer
+	log    *zap.SugaredLogger
+	maxLen int64
+}
+
+func NewFeedRedis(client redis.UniversalClient, tracer trace.Tracer, log *zap.SugaredLogger, maxLen int64) *FeedRedis {
+	return &FeedRedis{client: client, tracer: tracer, log: log, maxLen: maxLen}
+}
+
+func (r *FeedRedis) PublishFeedEvent(ctx context.Context, event *domain.FeedEvent) (string, error) {
+	ctx, span := r.tracer.Start(ctx, "feedRedis.PublishFeedEvent")
+	defer span.End()
+
+	eventBytes, err := json.Marshal(event)
+
+	if err != nil {
+		return "", err
+	}
+
+	return publishFeedScript.Run(ctx, r.client, []string{feedStreamKey}, r.maxLen, eventBytes, feedChannel).Text()
+}
+
+// GetFeedEventsAfter returns up to count events that were published after the cursor.
+func (r *FeedRedis) GetFeedEventsAfter(ctx context.Context, cursor string, count int64) ([]*domain.FeedEvent, error) {
+	ctx, span := r.tracer.Start(ctx, "feedRedis.GetFeedEventsAfter")
+	defer span.End()
+
+	messages, err := r.client.XRangeN(ctx, feedStreamKey, "("+cursor, "+", count).Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]*domain.FeedEvent, 0, len(messages))
+
+	for _, message := range messages {
+		payload, _ := message.Values[feedEventField].(string)
+
+		event, err := decodeFeedEvent(message.ID, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// GetFirstFeedEventID returns the oldest retained event ID, or an empty string if the stream is empty.
+func (r *FeedRedis) GetFirstFeedEventID(ctx context.Context) (string, error) {
+	ctx, span := r.tracer.Start(ctx, "feedRedis.GetFirstFeedEventID")
+	defer span.End()
+
+	messages, err := r.client.XRangeN(ctx, feedStreamKey, "-", "+", 1).Result()
+
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	if len(messages) == 0 {
+		return "", nil
+	}
+
+	return messages[0].ID, nil
+}
+
+// SubscribeFeed streams live events until ctx is done or the returned close function is called.
+func (r *FeedRedis) SubscribeFeed(ctx context.Context) (<-chan *domain.FeedEvent, func() error) {
+	pubsub := r.client.Subscribe(ctx, feedChannel)
+	events := make(chan *domain.FeedEvent)
+
+	go func() {
+		defer close(events)
+
+		messages := pubsub.Channel()
+
+		for {
+			var message *redis.Message
+
+			select {
+			case <-ctx.Done():
+				return
+			case m, ok := <-messages:
+				if !ok {
+					return
+				}
+				message = m
+			}
+
+			id, payload, _ := strings.Cut(message.Payload, "\n")
+
+			event, err := decodeFeedEvent(id, payload)
+			if err != nil {
+				r.log.Errorf("cannot decode feed event %s: %v", id, err)
+				continue
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, pubsub.Close
+}
+
+func decodeFeedEvent(id string, payload string) (*domain.FeedEvent, error) {
+	var event domain.FeedEvent
+
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		return nil, err
+	}
+
+	event.ID = id
+
+	return &event, nil
+}
@@ -0,0 +1,101 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+// releaseScript deletes the lock only if it is still held by the caller.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type IdempotencyRedis struct {
+	client redis.UniversalClient
+	tracer trace.Tracer
+}
+
+func NewIdempotencyRedis(client redis.UniversalClient, tracer trace.Tracer) *IdempotencyRedis {
+	return &IdempotencyRedis{client: client, tracer: tracer}
+}
+
+// GetIdempotencyRecord returns nil without an error when there is no record for the key.
+func (r *IdempotencyRedis) GetIdempotencyRecord(ctx context.Context, key string) (*domain.IdempotencyRecord, error) {
+	ctx, span := r.tracer.Start(ctx, "idempotencyRedis.GetIdempotencyRecord")
+	defer span.End()
+
+	recordBytes, err := r.client.Get(ctx, r.createKey(key)).Bytes()
+
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var record domain.IdempotencyRecord
+
+	if err = json.Unmarshal(recordBytes, &record); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (r *IdempotencyRedis) SetIdempotencyRecord(ctx context.Context, key string, record *domain.IdempotencyRecord, ttl time.Duration) error {
+	ctx, span := r.tracer.Start(ctx, "idempotencyRedis.SetIdempotencyRecord")
+	defer span.End()
+
+	recordBytes, err := json.Marshal(record)
+
+	if err != nil {
+		return err
+	}
+
+	return r.client.Set(ctx, r.createKey(key), recordBytes, ttl).Err()
+}
+
+// AcquireIdempotencyLock returns the lock token, or an empty string if the lock is held by another request.
+func (r *IdempotencyRedis) AcquireIdempotencyLock(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	ctx, span := r.tracer.Start(ctx, "idempotencyRedis.AcquireIdempotencyLock")
+	defer span.End()
+
+	token := uuid.NewString()
+
+	ok, err := r.client.SetNX(ctx, r.createLockKey(key), token, ttl).Result()
+
+	if err != nil {
+		return "", err
+	}
+
+	if !ok {
+		return "", nil
+	}
+
+	return token, nil
+}
+
+func (r *IdempotencyRedis) ReleaseIdempotencyLock(ctx context.Context, key string, token string) error {
+	ctx, span := r.tracer.Start(ctx, "idempotencyRedis.ReleaseIdempotencyLock")
+	defer span.End()
+
+	return releaseScript.Run(ctx, r.client, []string{r.createLockKey(key)}, token).Err()
+}
+
+func (r *IdempotencyRedis) createKey(key string) string {
+	return fmt.Sprintf("idempotency:%s", key)
+}
+
+func (r *IdempotencyRedis) createLockKey(key string) string {
+	return fmt.Sprintf("idempotency:lock:%s", key)
+}
@@ -0,0 +1,82 @@
+package redis
+
+import (
+	"context"
+	"github.com/redis/go-redis/v9"
+	"strings"
+)
+
+// In cluster mode a command may only touch keys of one slot. Apart from the page cache, whose keys
+// share a hash tag, every layout is read and written one key at a time: tweets, the rate limit buckets,
+// idempotency records, recent writes, the feed stream and the settings hash. Batched tweet reads go through mget.
+
+const clusterSlots = 16384
+
+// hashSlot is the cluster slot of key, computed like Redis does: CRC16 of the hash tag when the key has a non-empty one.
+func hashSlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+
+	return int(crc16(key)) % clusterSlots
+}
+
+// crc16 is CRC-16/XMODEM, the checksum Redis Cluster uses for key slots.
+func crc16(s string) uint16 {
+	var crc uint16
+
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
+
+// mget is MGET that also works on a cluster, where the keys are grouped by slot and read in one pipeline.
+// The values are aligned with keys.
+func mget(ctx context.Context, client redis.UniversalClient, keys []string) ([]interface{}, error) {
+	if _, ok := client.(*redis.ClusterClient); !ok {
+		return client.MGet(ctx, keys...).Result()
+	}
+
+	slots := make(map[int][]int)
+	for i, key := range keys {
+		slot := hashSlot(key)
+		slots[slot] = append(slots[slot], i)
+	}
+
+	pipe := client.Pipeline()
+	cmds := make(map[int]*redis.SliceCmd, len(slots))
+
+	for slot, indexes := range slots {
+		slotKeys := make([]string, len(indexes))
+		for i, index := range indexes {
+			slotKeys[i] = keys[index]
+		}
+		cmds[slot] = pipe.MGet(ctx, slotKeys...)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, len(keys))
+
+	for slot, indexes := range slots {
+		for i, value := range cmds[slot].Val() {
+			values[indexes[i]] = value
+		}
+	}
+
+	return values, nil
+}
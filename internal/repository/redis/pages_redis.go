@@ -0,0 +1,171 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+	"strconv"
+	"time"
+)
+
+// Pages are indexed in a sorted set scored by the creation time of their last tweet, in microseconds
+// like Postgres timestamps, and +inf when they are not full. Members are "<after>|<page key>".
+// A tweet created at t belongs to the pages with after <= t <= score.
+//
+// Every invalidation increments the epoch and is logged as "<epoch>|<t>", scored by its epoch. A page read
+// at one epoch is stored unless one of the invalidations since covered it. The log keeps the latest
+// invalidationLogSize entries, a page read before the oldest of them is not stored.
+
+const (
+	invalidationLogSize = 1024
+)
+
+var getPageScript = redis.NewScript(`
+return {redis.call("GET", KEYS[1]), redis.call("GET", KEYS[2])}
+`)
+
+var setPageScript = redis.NewScript(`
+local epoch = tonumber(ARGV[1])
+local current = tonumber(redis.call("GET", KEYS[3]) or "0")
+if current ~= epoch then
+	local since = redis.call("ZRANGEBYSCORE", KEYS[4], "(" .. ARGV[1], "+inf")
+	if #since < current - epoch then
+		return 0
+	end
+	local after, last = tonumber(ARGV[6]), math.huge
+	if ARGV[4] ~= "+inf" then
+		last = tonumber(ARGV[4])
+	end
+	for _, entry in ipairs(since) do
+		local at = tonumber(string.match(entry, "|(%d+)$"))
+		if at >= after and at <= last then
+			return 0
+		end
+	end
+end
+redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+redis.call("ZADD", KEYS[2], ARGV[4], ARGV[5])
+if redis.call("PTTL", KEYS[2]) < tonumber(ARGV[3]) then
+	redis.call("PEXPIRE", KEYS[2], ARGV[3])
+end
+return 1
+`)
+
+var invalidatePagesScript = redis.NewScript(`
+local epoch = redis.call("INCR", KEYS[2])
+redis.call("ZADD", KEYS[3], epoch, epoch .. "|" .. ARGV[1])
+redis.call("ZREMRANGEBYRANK", KEYS[3], 0, -tonumber(ARGV[2]) - 1)
+local at = tonumber(ARGV[1])
+local dropped = 0
+for _, member in ipairs(redis.call("ZRANGEBYSCORE", KEYS[1], ARGV[1], "+inf")) do
+	local after, page = string.match(member, "^(%d+)|(.+)$")
+	if tonumber(after) <= at then
+		redis.call("DEL", page)
+		redis.call("ZREM", KEYS[1], member)
+		dropped = dropped + 1
+	end
+end
+return dropped
+`)
+
+type TweetPagesRedis struct {
+	client redis.UniversalClient
+	tracer trace.Tracer
+}
+
+func NewTweetPagesRedis(client redis.UniversalClient, tracer trace.Tracer) *TweetPagesRedis {
+	return &TweetPagesRedis{client: client, tracer: tracer}
+}
+
+func (r *TweetPagesRedis) GetTweetPage(ctx context.Context, key domain.TweetPageKey) (*domain.TweetPage, int64, error) {
+	ctx, span := r.tracer.Start(ctx, "tweetPagesRedis.GetTweetPage")
+	defer span.End()
+
+	values, err := getPageScript.Run(ctx, r.client, []string{r.createPageKey(key), r.createEpochKey(key.Filter, key.Size)}).Slice()
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var epoch int64
+
+	if epochString, ok := values[1].(string); ok {
+		if epoch, err = strconv.ParseInt(epochString, 10, 64); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	pageString, ok := values[0].(string)
+
+	if !ok {
+		return nil, epoch, nil
+	}
+
+	var page domain.TweetPage
+
+	if err := json.Unmarshal([]byte(pageString), &page); err != nil {
+		return nil, 0, err
+	}
+
+	return &page, epoch, nil
+}
+
+func (r *TweetPagesRedis) SetTweetPage(ctx context.Context, key domain.TweetPageKey, page *domain.TweetPage, epoch int64, ttl time.Duration) error {
+	ctx, span := r.tracer.Start(ctx, "tweetPagesRedis.SetTweetPage")
+	defer span.End()
+
+	pageBytes, err := json.Marshal(page)
+
+	if err != nil {
+		return err
+	}
+
+	last := "+inf"
+	if page.Full {
+		last = strconv.FormatInt(page.Last.UnixMicro(), 10)
+	}
+
+	pageKey := r.createPageKey(key)
+	after := key.After.UnixMicro()
+
+	if key.After.IsZero() {
+		after = 0
+	}
+
+	return setPageScript.Run(ctx, r.client,
+		[]string{pageKey, r.createIndexKey(key.Filter, key.Size), r.createEpochKey(key.Filter, key.Size), r.createInvalidationsKey(key.Filter, key.Size)},
+		epoch, pageBytes, ttl.Milliseconds(), last, fmt.Sprintf("%d|%s", after, pageKey), after,
+	).Err()
+}
+
+func (r *TweetPagesRedis) InvalidateTweetPages(ctx context.Context, filter string, size int, createdAt time.Time) error {
+	ctx, span := r.tracer.Start(ctx, "tweetPagesRedis.InvalidateTweetPages")
+	defer span.End()
+
+	return invalidatePagesScript.Run(ctx, r.client,
+		[]string{r.createIndexKey(filter, size), r.createEpochKey(filter, size), r.createInvalidationsKey(filter, size)},
+		createdAt.UnixMicro(), invalidationLogSize,
+	).Err()
+}
+
+// The scripts touch the pages, the index, the epoch and the invalidations of a filter and size together, and the
+// invalidation deletes pages that are only named in the index. The hash tag keeps them in one cluster slot.
+
+func (r *TweetPagesRedis) createPageKey(key domain.TweetPageKey) string {
+	return fmt.Sprintf("tweet-page:{%s:%d}:%s", key.Filter, key.Size, key.Cursor)
+}
+
+func (r *TweetPagesRedis) createIndexKey(filter string, size int) string {
+	return fmt.Sprintf("tweet-pages:{%s:%d}", filter, size)
+}
+
+func (r *TweetPagesRedis) createEpochKey(filter string, size int) string {
+	return fmt.Sprintf("tweet-pages-epoch:{%s:%d}", filter, size)
+}
+
+func (r *TweetPagesRedis) createInvalidationsKey(filter string, size int) string {
+	return fmt.Sprintf("tweet-pages-invalidations:{%s:%d}", filter, size)
+}
@@ -0,0 +1,195 @@
+package redis
+
+import (
+	"context"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace/noop"
+	"testing"
+	"time"
+)
+
+const pageTTL = time.Minute
+
+var base = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+func at(seconds int) time.Time {
+	return base.Add(time.Duration(seconds) * time.Second)
+}
+
+// testPage is a page of three, full when it has a last tweet.
+type testPage struct {
+	cursor string
+	after  int
+	last   int
+}
+
+func (p testPage) key() domain.TweetPageKey {
+	key := domain.TweetPageKey{Filter: "visible", Size: 3, Cursor: p.cursor}
+	if p.after > 0 {
+		key.After = at(p.after)
+	}
+	return key
+}
+
+func (p testPage) page() *domain.TweetPage {
+	if p.last == 0 {
+		return &domain.TweetPage{TweetIDs: []string{p.cursor}}
+	}
+	return &domain.TweetPage{TweetIDs: []string{p.cursor}, Last: at(p.last), Full: true}
+}
+
+func newTestPagesRedis(t *testing.T) *TweetPagesRedis {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return NewTweetPagesRedis(client, noop.NewTracerProvider().Tracer(""))
+}
+
+func store(t *testing.T, r *TweetPagesRedis, p testPage, epoch int64) {
+	t.Helper()
+
+	if err := r.SetTweetPage(context.Background(), p.key(), p.page(), epoch, pageTTL); err != nil {
+		t.Fatalf("SetTweetPage(%s) = %v", p.cursor, err)
+	}
+}
+
+func invalidate(t *testing.T, r *TweetPagesRedis, seconds int) {
+	t.Helper()
+
+	if err := r.InvalidateTweetPages(context.Background(), "visible", 3, at(seconds)); err != nil {
+		t.Fatalf("InvalidateTweetPages(%d) = %v", seconds, err)
+	}
+}
+
+func cached(t *testing.T, r *TweetPagesRedis, p testPage) bool {
+	t.Helper()
+
+	page, _, err := r.GetTweetPage(context.Background(), p.key())
+
+	if err != nil {
+		t.Fatalf("GetTweetPage(%s) = %v", p.cursor, err)
+	}
+
+	return page != nil
+}
+
+var (
+	first  = testPage{cursor: "first", last: 100}
+	second = testPage{cursor: "second", after: 100, last: 200}
+	tail   = testPage{cursor: "tail", after: 200}
+)
+
+func TestTweetPagesStore(t *testing.T) {
+	r := newTestPagesRedis(t)
+	store(t, r, second, 0)
+
+	page, epoch, err := r.GetTweetPage(context.Background(), second.key())
+
+	if err != nil || page == nil || epoch != 0 {
+		t.Fatalf("GetTweetPage() = %v, %d, %v", page, epoch, err)
+	}
+
+	if !page.Full || !page.Last.Equal(at(200)) || page.TweetIDs[0] != "second" {
+		t.Errorf("GetTweetPage() = %+v, want the stored page", page)
+	}
+}
+
+func TestTweetPagesInvalidateRange(t *testing.T) {
+	tests := []struct {
+		name string
+		at   int
+		kept []testPage
+		gone []testPage
+	}{
+		{"first page", 50, []testPage{second, tail}, []testPage{first}},
+		{"middle page", 150, []testPage{first, tail}, []testPage{second}},
+		{"between two pages", 100, []testPage{tail}, []testPage{first, second}},
+		{"newest tweets", 250, []testPage{first, second}, []testPage{tail}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestPagesRedis(t)
+			for _, p := range []testPage{first, second, tail} {
+				store(t, r, p, 0)
+			}
+
+			invalidate(t, r, tt.at)
+
+			for _, p := range tt.kept {
+				if !cached(t, r, p) {
+					t.Errorf("%s was dropped", p.cursor)
+				}
+			}
+
+			for _, p := range tt.gone {
+				if cached(t, r, p) {
+					t.Errorf("%s is still cached", p.cursor)
+				}
+			}
+		})
+	}
+}
+
+func TestTweetPagesRejectStaleEpoch(t *testing.T) {
+	tests := []struct {
+		name string
+		// invalidations run between reading the page and storing it
+		invalidations []int
+		stored        bool
+	}{
+		{"no invalidation", nil, true},
+		{"within the page", []int{150}, false},
+		{"at its start", []int{100}, false},
+		{"before the page", []int{50}, true},
+		{"after the page", []int{250, 300}, true},
+		{"one of several within", []int{50, 250, 180}, false},
+		{"log trimmed", make([]int, invalidationLogSize+1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestPagesRedis(t)
+			invalidate(t, r, 10) // the epoch read is not the first one
+
+			_, epoch, err := r.GetTweetPage(context.Background(), second.key())
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, seconds := range tt.invalidations {
+				invalidate(t, r, seconds)
+			}
+
+			store(t, r, second, epoch)
+
+			if got := cached(t, r, second); got != tt.stored {
+				t.Errorf("stored = %v, want %v", got, tt.stored)
+			}
+		})
+	}
+}
+
+func TestTweetPagesRejectStaleEpochOfTail(t *testing.T) {
+	r := newTestPagesRedis(t)
+
+	_, epoch, err := r.GetTweetPage(context.Background(), tail.key())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the tail covers every later tweet
+	invalidate(t, r, 100000)
+	store(t, r, tail, epoch)
+
+	if cached(t, r, tail) {
+		t.Error("tail was stored after a newer tweet was created")
+	}
+}
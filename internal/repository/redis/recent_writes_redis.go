@@ -0,0 +1,44 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+type RecentWritesRedis struct {
+	client redis.UniversalClient
+	tracer trace.Tracer
+	window time.Duration
+}
+
+// NewRecentWritesRedis remembers a write for window, which should exceed the replication lag.
+func NewRecentWritesRedis(client redis.UniversalClient, tracer trace.Tracer, window time.Duration) *RecentWritesRedis {
+	return &RecentWritesRedis{client: client, tracer: tracer, window: window}
+}
+
+func (r *RecentWritesRedis) MarkWrite(ctx context.Context, userID string) error {
+	ctx, span := r.tracer.Start(ctx, "recentWritesRedis.MarkWrite")
+	defer span.End()
+
+	return r.client.Set(ctx, r.createKey(userID), 1, r.window).Err()
+}
+
+func (r *RecentWritesRedis) WroteRecently(ctx context.Context, userID string) (bool, error) {
+	ctx, span := r.tracer.Start(ctx, "recentWritesRedis.WroteRecently")
+	defer span.End()
+
+	n, err := r.client.Exists(ctx, r.createKey(userID)).Result()
+
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+func (r *RecentWritesRedis) createKey(userID string) string {
+	return fmt.Sprintf("recent-write:%s", userID)
+}
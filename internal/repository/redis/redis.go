@@ -1,19 +1,89 @@
 package redis
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"github.com/Verce11o/yata-tweets/config"
 	"github.com/redis/go-redis/v9"
+	"log"
+	"net"
+	"os"
 )
 
-func NewRedis(cfg *config.Config) *redis.Client {
-	redisHost := fmt.Sprintf("%v:%v", cfg.Redis.Host, cfg.Redis.Port)
+// NewRedis connects to a single server, to the master found through the sentinels or to a cluster,
+// depending on cfg.Redis.Mode.
+func NewRedis(cfg *config.Config) redis.UniversalClient {
+	tlsConfig, err := newTLSConfig(cfg.Redis.TLS)
 
-	client := redis.NewClient(&redis.Options{
-		Addr:     redisHost,
-		Password: cfg.Redis.Password,
-		DB:       cfg.Redis.DB,
-	})
+	if err != nil {
+		log.Fatal("Error configuring redis tls: ", err)
+	}
 
-	return client
+	opts := &redis.UniversalOptions{
+		Addrs:            cfg.Redis.Addrs,
+		MasterName:       cfg.Redis.MasterName,
+		Username:         cfg.Redis.User,
+		Password:         cfg.Redis.Password,
+		SentinelPassword: cfg.Redis.SentinelPassword,
+		DB:               cfg.Redis.DB,
+		TLSConfig:        tlsConfig,
+		PoolSize:         cfg.Redis.Pool.Size,
+		MinIdleConns:     cfg.Redis.Pool.MinIdleConns,
+		PoolTimeout:      cfg.Redis.Pool.Timeout,
+		ConnMaxIdleTime:  cfg.Redis.Pool.ConnMaxIdleTime,
+		DialTimeout:      cfg.Redis.Timeouts.Dial,
+		ReadTimeout:      cfg.Redis.Timeouts.Read,
+		WriteTimeout:     cfg.Redis.Timeouts.Write,
+	}
+
+	// the mode is explicit, redis.NewUniversalClient would guess it from the number of addresses
+	switch cfg.Redis.Mode {
+	case "sentinel":
+		return redis.NewFailoverClient(opts.Failover())
+	case "cluster":
+		return redis.NewClusterClient(opts.Cluster())
+	default:
+		opts.Addrs = []string{net.JoinHostPort(cfg.Redis.Host, cfg.Redis.Port)}
+		return redis.NewClient(opts.Simple())
+	}
+}
+
+func newTLSConfig(cfg config.RedisTLS) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify, //nolint:gosec // opt-in for test environments
+	}
+
+	if cfg.CAFile != "" {
+		ca, err := os.ReadFile(cfg.CAFile)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, errors.New("no certificates found in " + cfg.CAFile)
+		}
+	}
+
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
 }
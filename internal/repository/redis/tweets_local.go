@@ -0,0 +1,180 @@
+package redis
+
+import (
+	"context"
+	"github.com/Verce11o/yata-tweets/config"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/lib/lru"
+	"github.com/Verce11o/yata-tweets/internal/repository"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	resubscribeAfter = time.Second
+)
+
+// TweetsLocal keeps tweets read from next in memory. Deletes are published on a pub/sub channel,
+// so that every replica drops its copy, and the short TTL bounds staleness when a message is missed.
+type TweetsLocal struct {
+	next   repository.RedisRepository
+	client redis.UniversalClient
+	tracer trace.Tracer
+	log    *zap.SugaredLogger
+	cfg    config.LocalCache
+	cache  *lru.Cache[string, *domain.CachedTweet]
+}
+
+func NewTweetsLocal(next repository.RedisRepository, client redis.UniversalClient, tracer trace.Tracer, log *zap.SugaredLogger, cfg config.LocalCache) *TweetsLocal {
+	return &TweetsLocal{
+		next:   next,
+		client: client,
+		tracer: tracer,
+		log:    log,
+		cfg:    cfg,
+		cache:  lru.New[string, *domain.CachedTweet](cfg.Size),
+	}
+}
+
+func (r *TweetsLocal) GetTweetByIDCtx(ctx context.Context, tweetID string) (*domain.CachedTweet, error) {
+	ctx, span := r.tracer.Start(ctx, "tweetLocal.GetTweetByIDCtx")
+	defer span.End()
+
+	if entry, ok := r.cache.Get(tweetID); ok {
+		return entry, nil
+	}
+
+	entry, err := r.next.GetTweetByIDCtx(ctx, tweetID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r.add(tweetID, entry)
+
+	return entry, nil
+}
+
+func (r *TweetsLocal) GetTweetsByIDs(ctx context.Context, tweetIDs []string) ([]*domain.CachedTweet, error) {
+	ctx, span := r.tracer.Start(ctx, "tweetLocal.GetTweetsByIDs")
+	defer span.End()
+
+	entries := make([]*domain.CachedTweet, len(tweetIDs))
+
+	var missing []string
+	var positions []int
+
+	for i, tweetID := range tweetIDs {
+		if entry, ok := r.cache.Get(tweetID); ok {
+			entries[i] = entry
+			continue
+		}
+
+		missing = append(missing, tweetID)
+		positions = append(positions, i)
+	}
+
+	if len(missing) == 0 {
+		return entries, nil
+	}
+
+	found, err := r.next.GetTweetsByIDs(ctx, missing)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i, entry := range found {
+		if entry != nil {
+			r.add(missing[i], entry)
+			entries[positions[i]] = entry
+		}
+	}
+
+	return entries, nil
+}
+
+func (r *TweetsLocal) SetByIDCtx(ctx context.Context, tweetID string, tweet *domain.Tweet, delta time.Duration) error {
+	ctx, span := r.tracer.Start(ctx, "tweetLocal.SetByIDCtx")
+	defer span.End()
+
+	// the entry written to Redis has a jittered expiry, read it back on the next miss instead of guessing it
+	r.cache.Remove(tweetID)
+
+	return r.next.SetByIDCtx(ctx, tweetID, tweet, delta)
+}
+
+func (r *TweetsLocal) SetNotFoundCtx(ctx context.Context, tweetID string, delta time.Duration) error {
+	ctx, span := r.tracer.Start(ctx, "tweetLocal.SetNotFoundCtx")
+	defer span.End()
+
+	r.cache.Remove(tweetID)
+
+	return r.next.SetNotFoundCtx(ctx, tweetID, delta)
+}
+
+func (r *TweetsLocal) DeleteTweetByIDCtx(ctx context.Context, tweetID string) error {
+	ctx, span := r.tracer.Start(ctx, "tweetLocal.DeleteTweetByIDCtx")
+	defer span.End()
+
+	r.cache.Remove(tweetID)
+
+	if err := r.next.DeleteTweetByIDCtx(ctx, tweetID); err != nil {
+		return err
+	}
+
+	return r.client.Publish(ctx, r.cfg.Channel, tweetID).Err()
+}
+
+// Stats returns the hits and misses of the in-memory tier only.
+func (r *TweetsLocal) Stats() lru.Stats {
+	return r.cache.Stats()
+}
+
+// Run drops the tweets invalidated by any replica until ctx is done. Invalidations published while
+// the subscription is down are lost, so the whole cache is dropped whenever it is (re)established.
+func (r *TweetsLocal) Run(ctx context.Context) {
+	pubsub := r.client.Subscribe(ctx, r.cfg.Channel)
+	defer pubsub.Close()
+
+	for {
+		msg, err := pubsub.Receive(ctx)
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			r.log.Errorf("cannot receive tweet cache invalidations: %v", err.Error())
+			r.cache.Purge()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(resubscribeAfter):
+			}
+
+			continue
+		}
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			r.cache.Purge()
+		case *redis.Message:
+			r.cache.Remove(msg.Payload)
+		}
+	}
+}
+
+// add keeps entry for at most cfg.TTL and never past its expiry in Redis.
+func (r *TweetsLocal) add(tweetID string, entry *domain.CachedTweet) {
+	ttl := time.Until(entry.ExpiresAt)
+
+	if ttl > r.cfg.TTL {
+		ttl = r.cfg.TTL
+	}
+
+	r.cache.Add(tweetID, entry, ttl)
+}
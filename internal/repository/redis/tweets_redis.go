@@ -2,62 +2,103 @@ package redis
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/lib/codec"
 	"github.com/Verce11o/yata-tweets/internal/lib/grpc_errors"
+	"github.com/Verce11o/yata-tweets/internal/lib/settings"
 	"github.com/redis/go-redis/v9"
 	"go.opentelemetry.io/otel/trace"
+	"math/rand"
 	"time"
 )
 
-const (
-	tweetTTL = 3600
-)
-
 type TweetsRedis struct {
-	client *redis.Client
-	tracer trace.Tracer
+	client   redis.UniversalClient
+	tracer   trace.Tracer
+	settings *settings.Store
+	envelope *codec.Envelope
 }
 
-func NewTweetsRedis(client *redis.Client, tracer trace.Tracer) *TweetsRedis {
-	return &TweetsRedis{client: client, tracer: tracer}
+func NewTweetsRedis(client redis.UniversalClient, tracer trace.Tracer, settings *settings.Store, envelope *codec.Envelope) *TweetsRedis {
+	return &TweetsRedis{client: client, tracer: tracer, settings: settings, envelope: envelope}
 }
 
-func (r *TweetsRedis) GetTweetByIDCtx(ctx context.Context, tweetID string) (*domain.Tweet, error) {
+func (r *TweetsRedis) GetTweetByIDCtx(ctx context.Context, tweetID string) (*domain.CachedTweet, error) {
 	ctx, span := r.tracer.Start(ctx, "tweetRedis.GetTweetByIDCtx")
 	defer span.End()
 
-	tweetBytes, err := r.client.Get(ctx, r.createKey(tweetID)).Bytes()
+	entryBytes, err := r.client.Get(ctx, r.createKey(tweetID)).Bytes()
 
 	if err != nil {
-		if !errors.Is(err, redis.Nil) {
+		if errors.Is(err, redis.Nil) {
 			return nil, grpc_errors.ErrNotFound
 		}
 		return nil, err
 	}
 
-	var tweet domain.Tweet
+	entry, err := r.decode(entryBytes)
 
-	if err = json.Unmarshal(tweetBytes, &tweet); err != nil {
+	if err != nil {
 		return nil, err
 	}
 
-	return &tweet, nil
+	if entry == nil {
+		return nil, grpc_errors.ErrNotFound
+	}
+
+	return entry, nil
 }
 
-func (r *TweetsRedis) SetByIDCtx(ctx context.Context, tweetID string, tweet *domain.Tweet) error {
-	ctx, span := r.tracer.Start(ctx, "tweetRedis.SetByIDCtx")
+func (r *TweetsRedis) GetTweetsByIDs(ctx context.Context, tweetIDs []string) ([]*domain.CachedTweet, error) {
+	ctx, span := r.tracer.Start(ctx, "tweetRedis.GetTweetsByIDs")
 	defer span.End()
 
-	tweetBytes, err := json.Marshal(tweet)
+	entries := make([]*domain.CachedTweet, len(tweetIDs))
+
+	if len(tweetIDs) == 0 {
+		return entries, nil
+	}
+
+	keys := make([]string, len(tweetIDs))
+	for i, tweetID := range tweetIDs {
+		keys[i] = r.createKey(tweetID)
+	}
+
+	values, err := mget(ctx, r.client, keys)
 
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	for i, value := range values {
+		entryString, ok := value.(string)
+
+		if !ok {
+			continue
+		}
+
+		if entries[i], err = r.decode([]byte(entryString)); err != nil {
+			return nil, err
+		}
 	}
 
-	return r.client.Set(ctx, r.createKey(tweetID), tweetBytes, time.Second*time.Duration(tweetTTL)).Err()
+	return entries, nil
+}
+
+func (r *TweetsRedis) SetByIDCtx(ctx context.Context, tweetID string, tweet *domain.Tweet, delta time.Duration) error {
+	ctx, span := r.tracer.Start(ctx, "tweetRedis.SetByIDCtx")
+	defer span.End()
+
+	return r.set(ctx, tweetID, tweet, delta, r.settings.Get().TweetCacheTTL)
+}
+
+func (r *TweetsRedis) SetNotFoundCtx(ctx context.Context, tweetID string, delta time.Duration) error {
+	ctx, span := r.tracer.Start(ctx, "tweetRedis.SetNotFoundCtx")
+	defer span.End()
+
+	return r.set(ctx, tweetID, nil, delta, r.settings.Get().TweetCacheNegativeTTL)
 }
 
 func (r *TweetsRedis) DeleteTweetByIDCtx(ctx context.Context, tweetID string) error {
@@ -67,6 +108,31 @@ func (r *TweetsRedis) DeleteTweetByIDCtx(ctx context.Context, tweetID string) er
 	return r.client.Del(ctx, r.createKey(tweetID)).Err()
 }
 
+// set shortens ttl by up to settings.tweetCacheJitter, so that entries written together do not expire together.
+func (r *TweetsRedis) set(ctx context.Context, tweetID string, tweet *domain.Tweet, delta time.Duration, ttl time.Duration) error {
+	ttl -= time.Duration(float64(ttl) * r.settings.Get().TweetCacheJitter * rand.Float64())
+
+	entryBytes, err := r.envelope.Encode(&domain.CachedTweet{Tweet: tweet, Delta: delta, ExpiresAt: time.Now().Add(ttl)})
+
+	if err != nil {
+		return err
+	}
+
+	return r.client.Set(ctx, r.createKey(tweetID), entryBytes, ttl).Err()
+}
+
+// decode returns nil for entries of an unknown version, e.g. written by a newer deploy or before
+// entries were versioned, so that they are reloaded like a miss.
+func (r *TweetsRedis) decode(entryBytes []byte) (*domain.CachedTweet, error) {
+	entry, err := r.envelope.Decode(entryBytes)
+
+	if errors.Is(err, codec.ErrUnknownVersion) {
+		return nil, nil
+	}
+
+	return entry, err
+}
+
 func (r *TweetsRedis) createKey(key string) string {
 	return fmt.Sprintf("tweet:%s", key)
 }
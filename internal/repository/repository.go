@@ -4,24 +4,88 @@ import (
 	"context"
 	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
 	"github.com/Verce11o/yata-tweets/internal/domain"
+	"time"
 )
 
 type RedisRepository interface {
-	GetTweetByIDCtx(ctx context.Context, key string) (*domain.Tweet, error)
-	SetByIDCtx(ctx context.Context, tweetID string, tweet *domain.Tweet) error
+	// GetTweetByIDCtx returns grpc_errors.ErrNotFound when the tweet is not cached.
+	GetTweetByIDCtx(ctx context.Context, key string) (*domain.CachedTweet, error)
+	// SetByIDCtx caches tweet, delta is how long loading it took.
+	SetByIDCtx(ctx context.Context, tweetID string, tweet *domain.Tweet, delta time.Duration) error
+	// SetNotFoundCtx records that the tweet does not exist.
+	SetNotFoundCtx(ctx context.Context, tweetID string, delta time.Duration) error
+	// GetTweetsByIDs returns the cached entries in the order of tweetIDs, nil where a tweet is not cached.
+	GetTweetsByIDs(ctx context.Context, tweetIDs []string) ([]*domain.CachedTweet, error)
 	DeleteTweetByIDCtx(ctx context.Context, tweetID string) error
 }
 
+type TweetPagesRepository interface {
+	// GetTweetPage returns a nil page when it is not cached. The epoch has to be passed to SetTweetPage.
+	GetTweetPage(ctx context.Context, key domain.TweetPageKey) (*domain.TweetPage, int64, error)
+	// SetTweetPage caches page unless it was invalidated since epoch was read.
+	SetTweetPage(ctx context.Context, key domain.TweetPageKey, page *domain.TweetPage, epoch int64, ttl time.Duration) error
+	// InvalidateTweetPages drops the cached pages that a tweet created at createdAt belongs to.
+	InvalidateTweetPages(ctx context.Context, filter string, size int, createdAt time.Time) error
+}
+
+type IdempotencyRepository interface {
+	GetIdempotencyRecord(ctx context.Context, key string) (*domain.IdempotencyRecord, error)
+	SetIdempotencyRecord(ctx context.Context, key string, record *domain.IdempotencyRecord, ttl time.Duration) error
+	AcquireIdempotencyLock(ctx context.Context, key string, ttl time.Duration) (string, error)
+	ReleaseIdempotencyLock(ctx context.Context, key string, token string) error
+}
+
+type FeedRepository interface {
+	PublishFeedEvent(ctx context.Context, event *domain.FeedEvent) (string, error)
+	GetFeedEventsAfter(ctx context.Context, cursor string, count int64) ([]*domain.FeedEvent, error)
+	GetFirstFeedEventID(ctx context.Context) (string, error)
+	SubscribeFeed(ctx context.Context) (<-chan *domain.FeedEvent, func() error)
+}
+
 type PostgresRepository interface {
-	CreateTweet(ctx context.Context, input *pb.CreateTweetRequest, imageName string) (string, error)
+	CreateTweet(ctx context.Context, userID string, input *pb.CreateTweetRequest, imageName string) (*domain.Tweet, error)
 	GetTweet(ctx context.Context, tweetID string) (*domain.Tweet, error)
 	GetAllTweets(ctx context.Context, cursor string) ([]*pb.Tweet, string, error)
+	// GetTweetsByIDs returns the tweets that exist, in no particular order.
+	GetTweetsByIDs(ctx context.Context, tweetIDs []string) ([]*domain.Tweet, error)
 	UpdateTweet(ctx context.Context, input *pb.UpdateTweetRequest, imageName string) (*domain.Tweet, error)
-	DeleteTweet(ctx context.Context, tweetID string) error
+	// DeleteTweet, SetTweetHidden and SetRepliesLocked store audit in the same transaction as the change.
+	// audit is nil for changes that are not privileged.
+	DeleteTweet(ctx context.Context, tweetID string, audit *domain.AuditRecord) error
+	SetTweetHidden(ctx context.Context, tweetID string, hidden bool, audit *domain.AuditRecord) (*domain.Tweet, error)
+	SetRepliesLocked(ctx context.Context, tweetID string, locked bool, audit *domain.AuditRecord) (*domain.Tweet, error)
+	ListTweets(ctx context.Context, filter domain.TweetFilter, cursor string, limit int) ([]*domain.Tweet, string, error)
+}
+
+// RecentWritesRepository remembers which users wrote recently, across all replicas of the service.
+type RecentWritesRepository interface {
+	MarkWrite(ctx context.Context, userID string) error
+	WroteRecently(ctx context.Context, userID string) (bool, error)
+}
+
+// ScheduleRepository stores scheduled tweets. Schedules are looked up by their author,
+// the ones of other users are not found.
+type ScheduleRepository interface {
+	CreateScheduledTweet(ctx context.Context, schedule *domain.ScheduledTweet) (*domain.ScheduledTweet, error)
+	GetScheduledTweet(ctx context.Context, userID string, scheduleID string) (*domain.ScheduledTweet, error)
+	ListScheduledTweets(ctx context.Context, userID string, status domain.ScheduleStatus, cursor string, limit int) ([]*domain.ScheduledTweet, string, error)
+	// UpdateScheduledTweet and CancelScheduledTweet fail with a failed precondition unless the schedule is pending.
+	UpdateScheduledTweet(ctx context.Context, schedule *domain.ScheduledTweet) (*domain.ScheduledTweet, error)
+	CancelScheduledTweet(ctx context.Context, userID string, scheduleID string) (*domain.ScheduledTweet, error)
+	// PublishDueTweet publishes one due schedule exactly once across replicas, it returns nil when none is due.
+	// The schedule is returned with the error when publishing it failed.
+	PublishDueTweet(ctx context.Context) (*domain.ScheduledTweet, *domain.Tweet, error)
+	RecordPublishFailure(ctx context.Context, scheduleID string, cause string, maxAttempts int, backoff time.Duration, maxBackoff time.Duration) (*domain.ScheduledTweet, error)
+	// GetPublishLag returns how long the oldest due schedule has been waiting, zero when none is due.
+	GetPublishLag(ctx context.Context) (time.Duration, error)
+	ListPendingImageNames(ctx context.Context) ([]string, error)
 }
 
-type MinioRepository interface {
+type StorageRepository interface {
 	AddTweetImage(ctx context.Context, image *pb.Image, fileName string) error
+	GetTweetImage(ctx context.Context, fileName string) (*pb.Image, error)
 	UpdateTweetImage(ctx context.Context, oldName string, newName string, image *pb.Image) error
 	DeleteFile(ctx context.Context, fileName string) error
+	Ping(ctx context.Context) error
+	ListFiles(ctx context.Context) ([]domain.StoredFile, error)
 }
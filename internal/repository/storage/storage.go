@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/Verce11o/yata-tweets/config"
+	"github.com/Verce11o/yata-tweets/internal/repository"
+	"github.com/Verce11o/yata-tweets/internal/repository/local"
+	"github.com/Verce11o/yata-tweets/internal/repository/minio"
+	"go.opentelemetry.io/otel/trace"
+	"path/filepath"
+)
+
+const (
+	BackendMinio = "minio"
+	BackendLocal = "local"
+)
+
+// NewStorage builds the image storage selected by cfg.MinioConfig.Backend
+// and makes sure its bucket exists.
+func NewStorage(ctx context.Context, cfg *config.Config, tracer trace.Tracer) (repository.StorageRepository, error) {
+	switch cfg.MinioConfig.Backend {
+	case BackendMinio, "":
+		repo := minio.NewTweetMinio(minio.NewMinio(cfg), cfg.MinioConfig.Bucket, tracer)
+		if err := repo.EnsureBucket(ctx, cfg.MinioConfig.ExpireDays); err != nil {
+			return nil, fmt.Errorf("ensure minio bucket %s: %w", cfg.MinioConfig.Bucket, err)
+		}
+		return repo, nil
+	case BackendLocal:
+		repo := local.NewTweetLocal(filepath.Join(cfg.MinioConfig.LocalPath, cfg.MinioConfig.Bucket), tracer)
+		if err := repo.EnsureBucket(ctx); err != nil {
+			return nil, fmt.Errorf("ensure local bucket %s: %w", cfg.MinioConfig.Bucket, err)
+		}
+		return repo, nil
+	}
+
+	return nil, fmt.Errorf("unknown storage backend: %s", cfg.MinioConfig.Backend)
+}
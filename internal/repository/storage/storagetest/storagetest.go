@@ -0,0 +1,147 @@
+// Package storagetest implements a conformance suite that every
+// repository.StorageRepository backend is expected to pass.
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
+	"github.com/Verce11o/yata-tweets/internal/lib/grpc_errors"
+	"github.com/Verce11o/yata-tweets/internal/repository"
+	"testing"
+)
+
+// Factory returns an empty storage. Each subtest gets its own instance.
+type Factory func(t *testing.T) repository.StorageRepository
+
+func Run(t *testing.T, newStorage Factory) {
+	t.Run("AddAndGet", func(t *testing.T) { testAddAndGet(t, newStorage(t)) })
+	t.Run("GetMissing", func(t *testing.T) { testGetMissing(t, newStorage(t)) })
+	t.Run("Overwrite", func(t *testing.T) { testOverwrite(t, newStorage(t)) })
+	t.Run("Update", func(t *testing.T) { testUpdate(t, newStorage(t)) })
+	t.Run("UpdateWithoutOld", func(t *testing.T) { testUpdateWithoutOld(t, newStorage(t)) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newStorage(t)) })
+	t.Run("DeleteMissing", func(t *testing.T) { testDeleteMissing(t, newStorage(t)) })
+	t.Run("Ping", func(t *testing.T) { testPing(t, newStorage(t)) })
+	t.Run("ListFiles", func(t *testing.T) { testListFiles(t, newStorage(t)) })
+}
+
+func newImage(name string, content string) *pb.Image {
+	return &pb.Image{Chunk: []byte(content), ContentType: "image/png", Name: name}
+}
+
+func mustAdd(t *testing.T, s repository.StorageRepository, image *pb.Image) {
+	t.Helper()
+	if err := s.AddTweetImage(context.Background(), image, image.GetName()); err != nil {
+		t.Fatalf("AddTweetImage(%s): %v", image.GetName(), err)
+	}
+}
+
+func assertImage(t *testing.T, s repository.StorageRepository, want *pb.Image) {
+	t.Helper()
+	got, err := s.GetTweetImage(context.Background(), want.GetName())
+	if err != nil {
+		t.Fatalf("GetTweetImage(%s): %v", want.GetName(), err)
+	}
+	if !bytes.Equal(got.GetChunk(), want.GetChunk()) {
+		t.Errorf("GetTweetImage(%s) chunk = %q, want %q", want.GetName(), got.GetChunk(), want.GetChunk())
+	}
+	if got.GetContentType() != want.GetContentType() {
+		t.Errorf("GetTweetImage(%s) content type = %q, want %q", want.GetName(), got.GetContentType(), want.GetContentType())
+	}
+	if got.GetName() != want.GetName() {
+		t.Errorf("GetTweetImage(%s) name = %q", want.GetName(), got.GetName())
+	}
+}
+
+func assertMissing(t *testing.T, s repository.StorageRepository, name string) {
+	t.Helper()
+	_, err := s.GetTweetImage(context.Background(), name)
+	if !errors.Is(err, grpc_errors.ErrNotFound) {
+		t.Errorf("GetTweetImage(%s) error = %v, want %v", name, err, grpc_errors.ErrNotFound)
+	}
+}
+
+func testAddAndGet(t *testing.T, s repository.StorageRepository) {
+	image := newImage("cat.png", "cat")
+	mustAdd(t, s, image)
+	assertImage(t, s, image)
+}
+
+func testGetMissing(t *testing.T, s repository.StorageRepository) {
+	assertMissing(t, s, "missing.png")
+}
+
+func testOverwrite(t *testing.T, s repository.StorageRepository) {
+	mustAdd(t, s, newImage("cat.png", "cat"))
+	image := newImage("cat.png", "another cat")
+	mustAdd(t, s, image)
+	assertImage(t, s, image)
+}
+
+func testUpdate(t *testing.T, s repository.StorageRepository) {
+	mustAdd(t, s, newImage("old.png", "old"))
+	image := newImage("new.png", "new")
+
+	if err := s.UpdateTweetImage(context.Background(), "old.png", image.GetName(), image); err != nil {
+		t.Fatalf("UpdateTweetImage: %v", err)
+	}
+
+	assertImage(t, s, image)
+	assertMissing(t, s, "old.png")
+}
+
+func testUpdateWithoutOld(t *testing.T, s repository.StorageRepository) {
+	image := newImage("new.png", "new")
+
+	if err := s.UpdateTweetImage(context.Background(), "", image.GetName(), image); err != nil {
+		t.Fatalf("UpdateTweetImage: %v", err)
+	}
+
+	assertImage(t, s, image)
+}
+
+func testDelete(t *testing.T, s repository.StorageRepository) {
+	mustAdd(t, s, newImage("cat.png", "cat"))
+
+	if err := s.DeleteFile(context.Background(), "cat.png"); err != nil {
+		t.Fatalf("DeleteFile: %v", err)
+	}
+
+	assertMissing(t, s, "cat.png")
+}
+
+func testDeleteMissing(t *testing.T, s repository.StorageRepository) {
+	if err := s.DeleteFile(context.Background(), "missing.png"); err != nil {
+		t.Errorf("DeleteFile(missing.png) error = %v, want nil", err)
+	}
+}
+
+func testPing(t *testing.T, s repository.StorageRepository) {
+	if err := s.Ping(context.Background()); err != nil {
+		t.Errorf("Ping() error = %v, want nil", err)
+	}
+}
+
+func testListFiles(t *testing.T, s repository.StorageRepository) {
+	mustAdd(t, s, newImage("cat.png", "cat"))
+	mustAdd(t, s, newImage("dog.png", "dog!"))
+
+	files, err := s.ListFiles(context.Background())
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+
+	sizes := make(map[string]int64, len(files))
+	for _, f := range files {
+		sizes[f.Name] = f.Size
+		if f.ModifiedAt.IsZero() {
+			t.Errorf("ListFiles %s has no modification time", f.Name)
+		}
+	}
+
+	if len(sizes) != 2 || sizes["cat.png"] != 3 || sizes["dog.png"] != 4 {
+		t.Errorf("ListFiles sizes = %v, want cat.png:3 dog.png:4", sizes)
+	}
+}
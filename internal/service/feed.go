@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"github.com/Verce11o/yata-tweets/config"
+	feedPb "github.com/Verce11o/yata-tweets/gen/go/feed"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/lib/grpc_errors"
+	"github.com/Verce11o/yata-tweets/internal/lib/logger"
+	"github.com/Verce11o/yata-tweets/internal/repository"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	feedReplayBatch = 100
+)
+
+// FeedService fans out feed events received from Redis to the streams connected to this replica.
+type FeedService struct {
+	log    *zap.SugaredLogger
+	tracer trace.Tracer
+	repo   repository.FeedRepository
+	cfg    config.Feed
+
+	mu          sync.Mutex
+	subscribers map[*feedSubscription]struct{}
+	done        chan struct{}
+}
+
+type feedSubscription struct {
+	events   chan *domain.FeedEvent
+	overflow chan struct{}
+}
+
+func NewFeedService(log *zap.SugaredLogger, tracer trace.Tracer, repo repository.FeedRepository, cfg config.Feed) *FeedService {
+	return &FeedService{log: log, tracer: tracer, repo: repo, cfg: cfg, subscribers: make(map[*feedSubscription]struct{}), done: make(chan struct{})}
+}
+
+// Run receives events from other replicas until ctx is done.
+// Open streams are ended once it returns, so that the server can stop gracefully.
+func (f *FeedService) Run(ctx context.Context) {
+	events, closeSubscription := f.repo.SubscribeFeed(ctx)
+
+	defer close(f.done)
+
+	defer func() {
+		if err := closeSubscription(); err != nil {
+			f.log.Errorf("cannot close feed subscription: %v", err)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			f.broadcast(event)
+		}
+	}
+}
+
+func (f *FeedService) StreamTweets(ctx context.Context, input *feedPb.StreamTweetsRequest, send func(event *domain.FeedEvent) error) error {
+	filter := domain.FeedFilter{AuthorIDs: input.GetAuthorIds(), Hashtag: strings.TrimPrefix(input.GetHashtag(), "#")}
+
+	// subscribe before replaying, so nothing published in between is missed
+	sub := f.subscribe()
+	defer f.unsubscribe(sub)
+
+	last := input.GetCursor()
+
+	if last != "" {
+		var err error
+		if last, err = f.replay(ctx, last, filter, send); err != nil {
+			return err
+		}
+	}
+
+	heartbeat := time.NewTicker(f.cfg.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-sub.overflow:
+			return grpc_errors.ErrSlowConsumer
+		case <-f.done:
+			return grpc_errors.ErrFeedClosed
+		case event := <-sub.events:
+			// already sent during replay
+			if last != "" && compareFeedIDs(event.ID, last) <= 0 {
+				continue
+			}
+
+			last = event.ID
+
+			if !filter.Match(event) {
+				continue
+			}
+
+			if err := send(event); err != nil {
+				return err
+			}
+		case <-heartbeat.C:
+			if err := send(&domain.FeedEvent{ID: last, Type: domain.FeedEventHeartbeat, OccurredAt: time.Now()}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// replay sends the retained events published after the cursor and returns the new cursor.
+func (f *FeedService) replay(ctx context.Context, cursor string, filter domain.FeedFilter, send func(event *domain.FeedEvent) error) (string, error) {
+	ctx, span := f.tracer.Start(ctx, "feedService.replay")
+	defer span.End()
+
+	first, err := f.repo.GetFirstFeedEventID(ctx)
+
+	if err != nil {
+		logger.Ctx(ctx, f.log).Errorf("cannot get first feed event: %v", err)
+		return "", err
+	}
+
+	if first != "" && compareFeedIDs(cursor, first) < 0 {
+		return "", grpc_errors.ErrCursorExpired
+	}
+
+	for {
+		events, err := f.repo.GetFeedEventsAfter(ctx, cursor, feedReplayBatch)
+
+		if err != nil {
+			logger.Ctx(ctx, f.log).Errorf("cannot get feed events after %s: %v", cursor, err)
+			return "", err
+		}
+
+		for _, event := range events {
+			cursor = event.ID
+
+			if !filter.Match(event) {
+				continue
+			}
+
+			if err := send(event); err != nil {
+				return "", err
+			}
+		}
+
+		if len(events) < feedReplayBatch {
+			return cursor, nil
+		}
+	}
+}
+
+func (f *FeedService) subscribe() *feedSubscription {
+	sub := &feedSubscription{
+		events:   make(chan *domain.FeedEvent, f.cfg.BufferSize),
+		overflow: make(chan struct{}),
+	}
+
+	f.mu.Lock()
+	f.subscribers[sub] = struct{}{}
+	f.mu.Unlock()
+
+	return sub
+}
+
+func (f *FeedService) unsubscribe(sub *feedSubscription) {
+	f.mu.Lock()
+	delete(f.subscribers, sub)
+	f.mu.Unlock()
+}
+
+// broadcast never blocks: a subscriber whose buffer is full is dropped
+// and has to reconnect from its last cursor.
+func (f *FeedService) broadcast(event *domain.FeedEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for sub := range f.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			close(sub.overflow)
+			delete(f.subscribers, sub)
+		}
+	}
+}
+
+// compareFeedIDs compares Redis stream IDs of the form <milliseconds>-<sequence>.
+func compareFeedIDs(a string, b string) int {
+	aMs, aSeq := parseFeedID(a)
+	bMs, bSeq := parseFeedID(b)
+
+	switch {
+	case aMs < bMs, aMs == bMs && aSeq < bSeq:
+		return -1
+	case aMs == bMs && aSeq == bSeq:
+		return 0
+	}
+	return 1
+}
+
+func parseFeedID(id string) (uint64, uint64) {
+	msPart, seqPart, _ := strings.Cut(id, "-")
+	ms, _ := strconv.ParseUint(msPart, 10, 64)
+	seq, _ := strconv.ParseUint(seqPart, 10, 64)
+	return ms, seq
+}
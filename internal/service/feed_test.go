@@ -0,0 +1,26 @@
+package service
+
+import "testing"
+
+func TestCompareFeedIDs(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{"1700000000000-0", "1700000000000-0", 0},
+		{"1700000000000-0", "1700000000000-1", -1},
+		{"1700000000000-10", "1700000000000-9", 1},
+		{"999-5", "1000-0", -1},
+		{"1700000000001-0", "1700000000000-99", 1},
+		{"0-0", "1-0", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			if got := compareFeedIDs(tt.a, tt.b); got != tt.want {
+				t.Errorf("compareFeedIDs(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/lib/auth"
+	"github.com/Verce11o/yata-tweets/internal/lib/grpc_errors"
+	"github.com/Verce11o/yata-tweets/internal/lib/idempotency"
+	"github.com/Verce11o/yata-tweets/internal/lib/logger"
+	"time"
+)
+
+const (
+	idempotencyPollInterval = 50 * time.Millisecond
+)
+
+// createTweetIdempotent creates the tweet at most once per idempotency key.
+// Replays return the original tweet ID, concurrent duplicates wait for the first request to finish.
+func (t *TweetService) createTweetIdempotent(ctx context.Context, principal *auth.Principal, key string, input *pb.CreateTweetRequest) (string, error) {
+	ctx, span := t.tracer.Start(ctx, "tweetService.createTweetIdempotent")
+	defer span.End()
+
+	// keys are only unique per client
+	key = fmt.Sprintf("%s:%s", principal.UserID, key)
+
+	hash, err := idempotency.HashRequest(input)
+
+	if err != nil {
+		return "", err
+	}
+
+	tweetID, found, err := t.replayCreateTweet(ctx, key, hash)
+
+	if err != nil || found {
+		return tweetID, err
+	}
+
+	token, err := t.acquireIdempotencyLock(ctx, key)
+
+	if err != nil {
+		return "", err
+	}
+
+	if token == "" {
+		tweetID, token, err = t.awaitCreateTweet(ctx, key, hash)
+
+		if err != nil || token == "" {
+			return tweetID, err
+		}
+	}
+
+	defer func() {
+		if err := t.idempotency.ReleaseIdempotencyLock(context.WithoutCancel(ctx), key, token); err != nil {
+			logger.Ctx(ctx, t.log).Errorf("cannot release idempotency lock: %v", err.Error())
+		}
+	}()
+
+	// the first request may have finished between the lookup and acquiring the lock
+	tweetID, found, err = t.replayCreateTweet(ctx, key, hash)
+
+	if err != nil || found {
+		return tweetID, err
+	}
+
+	tweet, err := t.createTweet(ctx, principal, input)
+
+	if err != nil {
+		return "", err
+	}
+
+	// recorded before the tweet is announced, a retry must replay it even when the announcement fails
+	record := &domain.IdempotencyRecord{RequestHash: hash, TweetID: tweet.TweetID.String()}
+
+	if err := t.idempotency.SetIdempotencyRecord(context.WithoutCancel(ctx), key, record, t.idempotencyCfg.TTL); err != nil {
+		logger.Ctx(ctx, t.log).Errorf("cannot save idempotency record: %v", err.Error())
+	}
+
+	t.tweetCreated(ctx, tweet)
+
+	return record.TweetID, nil
+}
+
+func (t *TweetService) replayCreateTweet(ctx context.Context, key string, hash string) (string, bool, error) {
+	record, err := t.idempotency.GetIdempotencyRecord(ctx, key)
+
+	if err != nil {
+		logger.Ctx(ctx, t.log).Errorf("cannot get idempotency record: %v", err.Error())
+		return "", false, err
+	}
+
+	if record == nil {
+		return "", false, nil
+	}
+
+	if record.RequestHash != hash {
+		return "", false, grpc_errors.ErrIdempotencyKey
+	}
+
+	return record.TweetID, true, nil
+}
+
+// awaitCreateTweet waits for the request holding the lock to store its result. When the lock is
+// released without a result, e.g. the request failed or its lock expired, the lock is acquired again
+// and its token returned, so the caller creates the tweet instead.
+func (t *TweetService) awaitCreateTweet(ctx context.Context, key string, hash string) (string, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.idempotencyCfg.WaitTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(idempotencyPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", "", grpc_errors.ErrRequestInFlight
+		case <-ticker.C:
+			tweetID, found, err := t.replayCreateTweet(ctx, key, hash)
+			if err != nil || found {
+				return tweetID, "", err
+			}
+
+			token, err := t.acquireIdempotencyLock(ctx, key)
+			if err != nil || token != "" {
+				return "", token, err
+			}
+		}
+	}
+}
+
+func (t *TweetService) acquireIdempotencyLock(ctx context.Context, key string) (string, error) {
+	token, err := t.idempotency.AcquireIdempotencyLock(ctx, key, t.idempotencyCfg.LockTTL)
+
+	if err != nil {
+		logger.Ctx(ctx, t.log).Errorf("cannot acquire idempotency lock: %v", err.Error())
+		return "", err
+	}
+
+	return token, nil
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
+	"github.com/Verce11o/yata-tweets/config"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/lib/auth"
+	"github.com/Verce11o/yata-tweets/internal/lib/grpc_errors"
+	"github.com/Verce11o/yata-tweets/internal/lib/settings"
+	"github.com/Verce11o/yata-tweets/internal/repository"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"sync"
+	"testing"
+	"time"
+)
+
+// idempotencyStub keeps records and a single lock in memory.
+type idempotencyStub struct {
+	mu      sync.Mutex
+	records map[string]*domain.IdempotencyRecord
+	locked  bool
+}
+
+func (s *idempotencyStub) GetIdempotencyRecord(_ context.Context, key string) (*domain.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records[key], nil
+}
+
+func (s *idempotencyStub) SetIdempotencyRecord(_ context.Context, key string, record *domain.IdempotencyRecord, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = record
+	return nil
+}
+
+func (s *idempotencyStub) AcquireIdempotencyLock(context.Context, string, time.Duration) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locked {
+		return "", nil
+	}
+	s.locked = true
+	return "token", nil
+}
+
+func (s *idempotencyStub) ReleaseIdempotencyLock(context.Context, string, string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.locked = false
+	return nil
+}
+
+func TestAwaitCreateTweet(t *testing.T) {
+	tests := []struct {
+		name string
+		// finish runs while the request waits, as the request holding the lock
+		finish    func(stub *idempotencyStub)
+		wantTweet string
+		wantToken string
+		wantErr   error
+	}{
+		{
+			name: "result stored",
+			finish: func(stub *idempotencyStub) {
+				_ = stub.SetIdempotencyRecord(context.Background(), "key", &domain.IdempotencyRecord{RequestHash: "hash", TweetID: "tweet"}, 0)
+				_ = stub.ReleaseIdempotencyLock(context.Background(), "key", "token")
+			},
+			wantTweet: "tweet",
+		},
+		{
+			name: "different request",
+			finish: func(stub *idempotencyStub) {
+				_ = stub.SetIdempotencyRecord(context.Background(), "key", &domain.IdempotencyRecord{RequestHash: "other", TweetID: "tweet"}, 0)
+			},
+			wantErr: grpc_errors.ErrIdempotencyKey,
+		},
+		{
+			name: "released without a result",
+			finish: func(stub *idempotencyStub) {
+				_ = stub.ReleaseIdempotencyLock(context.Background(), "key", "token")
+			},
+			wantToken: "token",
+		},
+		{
+			name:    "still running",
+			finish:  func(*idempotencyStub) {},
+			wantErr: grpc_errors.ErrRequestInFlight,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &idempotencyStub{records: make(map[string]*domain.IdempotencyRecord), locked: true}
+			service := &TweetService{log: zap.NewNop().Sugar(), idempotency: stub, idempotencyCfg: config.Idempotency{WaitTimeout: 500 * time.Millisecond}}
+
+			time.AfterFunc(2*idempotencyPollInterval, func() { tt.finish(stub) })
+
+			tweetID, token, err := service.awaitCreateTweet(context.Background(), "key", "hash")
+
+			if !errors.Is(err, tt.wantErr) || tweetID != tt.wantTweet || token != tt.wantToken {
+				t.Errorf("awaitCreateTweet() = %q, %q, %v, want %q, %q, %v", tweetID, token, err, tt.wantTweet, tt.wantToken, tt.wantErr)
+			}
+		})
+	}
+}
+
+// tweetsStub stores tweets in memory.
+type tweetsStub struct {
+	repository.PostgresRepository
+
+	created int
+}
+
+func (s *tweetsStub) CreateTweet(_ context.Context, userID string, input *pb.CreateTweetRequest, imageName string) (*domain.Tweet, error) {
+	s.created++
+	return &domain.Tweet{TweetID: uuid.New(), UserID: uuid.MustParse(userID), Text: input.GetText(), ImageName: imageName, CreatedAt: time.Now()}, nil
+}
+
+type pagesStub struct {
+	repository.TweetPagesRepository
+}
+
+func (pagesStub) InvalidateTweetPages(context.Context, string, int, time.Time) error {
+	return nil
+}
+
+type feedStub struct {
+	repository.FeedRepository
+}
+
+func (feedStub) PublishFeedEvent(context.Context, *domain.FeedEvent) (string, error) {
+	return "", nil
+}
+
+// brokenPublisher fails like a broker that went away, after the client cancelled its request.
+type brokenPublisher struct {
+	cancel func()
+}
+
+func (p brokenPublisher) Publish(ctx context.Context, _ []byte) error {
+	p.cancel()
+	return errors.New("channel closed")
+}
+
+func TestCreateTweetIdempotentStoresTweetOnce(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tweets := &tweetsStub{}
+	stub := &idempotencyStub{records: make(map[string]*domain.IdempotencyRecord)}
+	service := &TweetService{
+		log:            zap.NewNop().Sugar(),
+		tracer:         noop.NewTracerProvider().Tracer(""),
+		tweetPublisher: brokenPublisher{cancel: cancel},
+		repo:           tweets,
+		pages:          pagesStub{},
+		idempotency:    stub,
+		idempotencyCfg: config.Idempotency{WaitTimeout: time.Second},
+		feed:           feedStub{},
+		settings:       settings.NewStore(zap.NewNop().Sugar(), &config.Config{}, "", nil),
+	}
+	principal := &auth.Principal{UserID: uuid.NewString()}
+	input := &pb.CreateTweetRequest{Text: "hello"}
+
+	first, err := service.createTweetIdempotent(ctx, principal, "key", input)
+
+	if err != nil {
+		t.Fatalf("createTweetIdempotent() = %v, the tweet is stored", err)
+	}
+
+	retry, err := service.createTweetIdempotent(context.Background(), principal, "key", input)
+
+	if err != nil || retry != first {
+		t.Errorf("retry = %q, %v, want %q", retry, err, first)
+	}
+
+	if tweets.created != 1 {
+		t.Errorf("%d tweets created, want 1", tweets.created)
+	}
+}
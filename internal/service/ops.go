@@ -0,0 +1,315 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/lib/notification"
+	"github.com/Verce11o/yata-tweets/internal/repository"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+const (
+	opsBatch      = 500
+	seedImageSize = 64
+	seedTextWords = 12
+	seedMaxUsers  = 1000
+	seedMaxTweets = 1000000
+	seedImageType = "image/png"
+)
+
+var seedWords = strings.Fields("yata tweet load test hello world golang grpc redis postgres minio rabbitmq cache feed stream image timeline follow reply like share")
+
+// OpsService implements the operational commands of the binary. Unlike TweetService it works
+// on all tweets at once and bypasses authorization.
+type OpsService struct {
+	log            *zap.SugaredLogger
+	tracer         trace.Tracer
+	tweetPublisher notification.TweetPublisher
+	repo           repository.PostgresRepository
+	redis          repository.RedisRepository
+	schedules      repository.ScheduleRepository
+	storage        repository.StorageRepository
+}
+
+// NewOpsService builds the service. tweetPublisher is only needed by ReplayEvents and may be nil otherwise.
+func NewOpsService(log *zap.SugaredLogger, tracer trace.Tracer, tweetPublisher notification.TweetPublisher, repo repository.PostgresRepository, redis repository.RedisRepository, schedules repository.ScheduleRepository, storage repository.StorageRepository) *OpsService {
+	return &OpsService{log: log, tracer: tracer, tweetPublisher: tweetPublisher, repo: repo, redis: redis, schedules: schedules, storage: storage}
+}
+
+type SeedOptions struct {
+	Count int
+	Users int
+	// ImageEvery attaches a generated image to every n-th tweet, zero disables images.
+	ImageEvery int
+}
+
+// Seed creates fake tweets for load testing. No notifications or feed events are published for them.
+func (o *OpsService) Seed(ctx context.Context, opts SeedOptions) (int, error) {
+	ctx, span := o.tracer.Start(ctx, "opsService.Seed")
+	defer span.End()
+
+	if opts.Count < 1 || opts.Count > seedMaxTweets {
+		return 0, fmt.Errorf("count must be between 1 and %d", seedMaxTweets)
+	}
+
+	if opts.Users < 1 || opts.Users > seedMaxUsers {
+		return 0, fmt.Errorf("users must be between 1 and %d", seedMaxUsers)
+	}
+
+	users := make([]string, opts.Users)
+	for i := range users {
+		users[i] = uuid.NewString()
+	}
+
+	for i := 0; i < opts.Count; i++ {
+		if err := ctx.Err(); err != nil {
+			return i, err
+		}
+
+		input := &pb.CreateTweetRequest{Text: seedText()}
+
+		if opts.ImageEvery > 0 && i%opts.ImageEvery == 0 {
+			img, err := seedImage()
+
+			if err != nil {
+				return i, err
+			}
+
+			if err := o.storage.AddTweetImage(ctx, img, img.GetName()); err != nil {
+				o.log.Errorf("cannot add seed image to storage: %v", err.Error())
+				return i, err
+			}
+
+			input.Image = img
+		}
+
+		if _, err := o.repo.CreateTweet(ctx, users[rand.Intn(len(users))], input, input.GetImage().GetName()); err != nil {
+			o.log.Errorf("cannot create seed tweet: %v", err.Error())
+			return i, err
+		}
+	}
+
+	return opts.Count, nil
+}
+
+// Reindex refreshes the cached copy of every tweet created since, and evicts hidden ones.
+// There is no search index yet, the tweet cache is the only derived data.
+func (o *OpsService) Reindex(ctx context.Context, since time.Time) (int, error) {
+	ctx, span := o.tracer.Start(ctx, "opsService.Reindex")
+	defer span.End()
+
+	return o.eachTweet(ctx, domain.TweetFilter{CreatedSince: since}, func(tweet *domain.Tweet) error {
+		if tweet.Hidden {
+			return o.redis.DeleteTweetByIDCtx(ctx, tweet.TweetID.String())
+		}
+		return o.redis.SetByIDCtx(ctx, tweet.TweetID.String(), tweet, 0)
+	})
+}
+
+type PurgeOptions struct {
+	// MinAge protects images of tweets being created right now, they are uploaded before the tweet is stored.
+	MinAge time.Duration
+	DryRun bool
+}
+
+// PurgeOrphans deletes stored images that no tweet or pending scheduled tweet refers to and returns their names.
+func (o *OpsService) PurgeOrphans(ctx context.Context, opts PurgeOptions) ([]string, error) {
+	ctx, span := o.tracer.Start(ctx, "opsService.PurgeOrphans")
+	defer span.End()
+
+	// list files first, so that images uploaded while tweets are read are not older than MinAge
+	files, err := o.storage.ListFiles(ctx)
+
+	if err != nil {
+		o.log.Errorf("cannot list stored images: %v", err.Error())
+		return nil, err
+	}
+
+	referenced := make(map[string]struct{})
+
+	// images of scheduled tweets are uploaded before the tweets exist. They are listed before the tweets:
+	// a schedule is marked published in the transaction that creates its tweet, so an image of a schedule
+	// published in between is still found among the tweets.
+	pending, err := o.schedules.ListPendingImageNames(ctx)
+
+	if err != nil {
+		o.log.Errorf("cannot list images of scheduled tweets: %v", err.Error())
+		return nil, err
+	}
+
+	for _, name := range pending {
+		referenced[name] = struct{}{}
+	}
+
+	if _, err := o.eachTweet(ctx, domain.TweetFilter{}, func(tweet *domain.Tweet) error {
+		if tweet.ImageName != "" {
+			referenced[tweet.ImageName] = struct{}{}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	var purged []string
+
+	for _, file := range files {
+		if _, ok := referenced[file.Name]; ok || time.Since(file.ModifiedAt) < opts.MinAge {
+			continue
+		}
+
+		if !opts.DryRun {
+			if err := o.storage.DeleteFile(ctx, file.Name); err != nil {
+				o.log.Errorf("cannot delete orphaned image %s: %v", file.Name, err.Error())
+				return purged, err
+			}
+		}
+
+		purged = append(purged, file.Name)
+	}
+
+	return purged, nil
+}
+
+// ReplayEvents publishes the new tweet notifications of the visible tweets created since,
+// e.g. after notifications were lost. Consumers have to tolerate duplicates.
+func (o *OpsService) ReplayEvents(ctx context.Context, since time.Time, dryRun bool) (int, error) {
+	ctx, span := o.tracer.Start(ctx, "opsService.ReplayEvents")
+	defer span.End()
+
+	replayed := 0
+
+	_, err := o.eachTweet(ctx, domain.TweetFilter{CreatedSince: since}, func(tweet *domain.Tweet) error {
+		if tweet.Hidden {
+			return nil
+		}
+
+		if dryRun {
+			replayed++
+			return nil
+		}
+
+		messageBytes, err := json.Marshal(domain.SendNewTweetNotification{
+			SenderID: tweet.UserID.String(),
+			Type:     domain.NewTweetNotificationType,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if err := o.tweetPublisher.Publish(ctx, messageBytes); err != nil {
+			return err
+		}
+
+		replayed++
+
+		return nil
+	})
+
+	return replayed, err
+}
+
+// ExportUser collects the tweets of a user, hidden ones included, and optionally their images.
+func (o *OpsService) ExportUser(ctx context.Context, userID string, withImages bool) (*domain.UserExport, error) {
+	ctx, span := o.tracer.Start(ctx, "opsService.ExportUser")
+	defer span.End()
+
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, fmt.Errorf("invalid user id %q: %w", userID, err)
+	}
+
+	export := &domain.UserExport{UserID: userID, ExportedAt: time.Now().UTC(), Tweets: []*domain.Tweet{}}
+
+	_, err := o.eachTweet(ctx, domain.TweetFilter{UserID: userID}, func(tweet *domain.Tweet) error {
+		export.Tweets = append(export.Tweets, tweet)
+
+		if !withImages || tweet.ImageName == "" {
+			return nil
+		}
+
+		img, err := o.storage.GetTweetImage(ctx, tweet.ImageName)
+
+		if err != nil {
+			o.log.Errorf("cannot get image %s of tweet %s: %v", tweet.ImageName, tweet.TweetID.String(), err.Error())
+			return err
+		}
+
+		export.Images = append(export.Images, &domain.ExportImage{Name: img.GetName(), ContentType: img.GetContentType(), Data: img.GetChunk()})
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// eachTweet calls fn for every tweet matching filter, in batches, and returns how many there were.
+func (o *OpsService) eachTweet(ctx context.Context, filter domain.TweetFilter, fn func(tweet *domain.Tweet) error) (int, error) {
+	var cursor string
+	count := 0
+
+	for {
+		tweets, next, err := o.repo.ListTweets(ctx, filter, cursor, opsBatch)
+
+		if err != nil {
+			o.log.Errorf("cannot list tweets after cursor %q: %v", cursor, err.Error())
+			return count, err
+		}
+
+		for _, tweet := range tweets {
+			if err := fn(tweet); err != nil {
+				return count, err
+			}
+			count++
+		}
+
+		if next == "" {
+			return count, nil
+		}
+
+		cursor = next
+	}
+}
+
+func seedText() string {
+	words := make([]string, seedTextWords)
+	for i := range words {
+		words[i] = seedWords[rand.Intn(len(seedWords))]
+	}
+	return strings.Join(words, " ")
+}
+
+// seedImage draws a square of a random colour.
+func seedImage() (*pb.Image, error) {
+	img := image.NewRGBA(image.Rect(0, 0, seedImageSize, seedImageSize))
+	fill := color.RGBA{R: uint8(rand.Intn(256)), G: uint8(rand.Intn(256)), B: uint8(rand.Intn(256)), A: 255}
+
+	for x := 0; x < seedImageSize; x++ {
+		for y := 0; y < seedImageSize; y++ {
+			img.Set(x, y, fill)
+		}
+	}
+
+	var buf bytes.Buffer
+
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	return &pb.Image{Chunk: buf.Bytes(), ContentType: seedImageType, Name: uuid.NewString() + ".png"}, nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/repository"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+// publishingStore holds one scheduled tweet with an image, it is published as soon as the first of
+// its tweets or of the pending images is read.
+type publishingStore struct {
+	repository.PostgresRepository
+	repository.ScheduleRepository
+	repository.StorageRepository
+
+	published bool
+	deleted   []string
+}
+
+const scheduledImage = "scheduled.png"
+
+func (s *publishingStore) ListTweets(context.Context, domain.TweetFilter, string, int) ([]*domain.Tweet, string, error) {
+	defer func() { s.published = true }()
+
+	if !s.published {
+		return nil, "", nil
+	}
+
+	return []*domain.Tweet{{ImageName: scheduledImage}}, "", nil
+}
+
+func (s *publishingStore) ListPendingImageNames(context.Context) ([]string, error) {
+	defer func() { s.published = true }()
+
+	if s.published {
+		return nil, nil
+	}
+
+	return []string{scheduledImage}, nil
+}
+
+func (s *publishingStore) ListFiles(context.Context) ([]domain.StoredFile, error) {
+	old := time.Now().Add(-time.Hour)
+
+	return []domain.StoredFile{{Name: scheduledImage, ModifiedAt: old}, {Name: "orphan.png", ModifiedAt: old}}, nil
+}
+
+func (s *publishingStore) DeleteFile(_ context.Context, name string) error {
+	s.deleted = append(s.deleted, name)
+	return nil
+}
+
+func TestPurgeOrphansKeepsImageOfSchedulePublishedMeanwhile(t *testing.T) {
+	store := &publishingStore{}
+	ops := NewOpsService(zap.NewNop().Sugar(), noop.NewTracerProvider().Tracer(""), nil, store, nil, store, store)
+
+	purged, err := ops.PurgeOrphans(context.Background(), PurgeOptions{MinAge: time.Minute})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(purged) != 1 || purged[0] != "orphan.png" || len(store.deleted) != 1 {
+		t.Errorf("purged %v, deleted %v, want only orphan.png", purged, store.deleted)
+	}
+}
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
+	"github.com/Verce11o/yata-tweets/config"
+	schedulePb "github.com/Verce11o/yata-tweets/gen/go/schedule"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/lib/auth"
+	"github.com/Verce11o/yata-tweets/internal/lib/logger"
+	"github.com/Verce11o/yata-tweets/internal/lib/settings"
+	"github.com/Verce11o/yata-tweets/internal/repository"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	scheduleResource = "scheduled tweet"
+	// LocalTimeLayout is the format of publish times, they are read in the time zone of the request.
+	LocalTimeLayout = "2006-01-02T15:04:05"
+
+	schedulePublishTimeout = 10 * time.Second
+)
+
+var scheduleStatuses = map[schedulePb.ScheduledTweet_Status]domain.ScheduleStatus{
+	schedulePb.ScheduledTweet_STATUS_UNSPECIFIED: domain.ScheduleStatusPending,
+	schedulePb.ScheduledTweet_STATUS_PENDING:     domain.ScheduleStatusPending,
+	schedulePb.ScheduledTweet_STATUS_PUBLISHED:   domain.ScheduleStatusPublished,
+	schedulePb.ScheduledTweet_STATUS_CANCELLED:   domain.ScheduleStatusCancelled,
+	schedulePb.ScheduledTweet_STATUS_FAILED:      domain.ScheduleStatusFailed,
+}
+
+// ScheduleService keeps the tweets users scheduled and publishes them once they are due.
+type ScheduleService struct {
+	log      *zap.SugaredLogger
+	tracer   trace.Tracer
+	tweets   *TweetService
+	repo     repository.ScheduleRepository
+	storage  repository.StorageRepository
+	settings *settings.Store
+	cfg      config.Scheduler
+}
+
+// NewScheduleService builds the service. Published tweets are announced through tweets, like created ones.
+func NewScheduleService(log *zap.SugaredLogger, tracer trace.Tracer, tweets *TweetService, repo repository.ScheduleRepository, storage repository.StorageRepository, settings *settings.Store, cfg config.Scheduler) *ScheduleService {
+	return &ScheduleService{log: log, tracer: tracer, tweets: tweets, repo: repo, storage: storage, settings: settings, cfg: cfg}
+}
+
+func (s *ScheduleService) CreateScheduledTweet(ctx context.Context, input *schedulePb.CreateScheduledTweetRequest) (*domain.ScheduledTweet, error) {
+	ctx, span := s.tracer.Start(ctx, "scheduleService.CreateScheduledTweet")
+	defer span.End()
+
+	principal, err := auth.RequirePrincipal(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := uuid.Parse(principal.UserID)
+
+	if err != nil {
+		return nil, domain.InvalidArgument(scheduleResource, "user id of the caller is not a uuid", err)
+	}
+
+	publishAt, timeZone, err := s.publishTime(input.GetPublishAt(), input.GetTimeZone())
+
+	if err != nil {
+		return nil, err
+	}
+
+	image := tweetImage(input.GetImage())
+
+	if image != nil {
+		if err := s.storage.AddTweetImage(ctx, image, image.GetName()); err != nil {
+			logger.Ctx(ctx, s.log).Errorf("cannot add image of scheduled tweet to storage: %v", err.Error())
+			return nil, err
+		}
+	}
+
+	schedule, err := s.repo.CreateScheduledTweet(ctx, &domain.ScheduledTweet{
+		UserID:    userID,
+		Text:      input.GetText(),
+		ImageName: image.GetName(),
+		PublishAt: publishAt,
+		TimeZone:  timeZone,
+	})
+
+	if err != nil {
+		logger.Ctx(ctx, s.log).Errorf("cannot create scheduled tweet: %v", err.Error())
+		s.deleteImage(ctx, image.GetName())
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (s *ScheduleService) ListScheduledTweets(ctx context.Context, input *schedulePb.ListScheduledTweetsRequest) ([]*domain.ScheduledTweet, string, error) {
+	ctx, span := s.tracer.Start(ctx, "scheduleService.ListScheduledTweets")
+	defer span.End()
+
+	principal, err := auth.RequirePrincipal(ctx)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	schedules, cursor, err := s.repo.ListScheduledTweets(ctx, principal.UserID, scheduleStatuses[input.GetStatus()], input.GetCursor(), s.settings.Get().PageSize)
+
+	if err != nil {
+		logger.Ctx(ctx, s.log).Errorf("cannot list scheduled tweets by cursor: %v err: %v", input.GetCursor(), err)
+		return nil, "", err
+	}
+
+	return schedules, cursor, nil
+}
+
+func (s *ScheduleService) UpdateScheduledTweet(ctx context.Context, input *schedulePb.UpdateScheduledTweetRequest) (*domain.ScheduledTweet, error) {
+	ctx, span := s.tracer.Start(ctx, "scheduleService.UpdateScheduledTweet")
+	defer span.End()
+
+	principal, err := auth.RequirePrincipal(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.repo.GetScheduledTweet(ctx, principal.UserID, input.GetScheduleId())
+
+	if err != nil {
+		logger.Ctx(ctx, s.log).Errorf("cannot get scheduled tweet by id: %v", err.Error())
+		return nil, err
+	}
+
+	publishAt, timeZone, err := s.publishTime(input.GetPublishAt(), input.GetTimeZone())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if input.GetText() == "" && input.GetImage() == nil && schedule.ImageName == "" {
+		return nil, domain.InvalidArgument(scheduleResource, "a tweet needs text or an image", nil)
+	}
+
+	oldImageName := schedule.ImageName
+	image := tweetImage(input.GetImage())
+
+	// the new image is added next to the old one, the schedule may be published before it is updated
+	if image != nil {
+		if err := s.storage.AddTweetImage(ctx, image, image.GetName()); err != nil {
+			logger.Ctx(ctx, s.log).Errorf("cannot add image of scheduled tweet to storage: %v", err.Error())
+			return nil, err
+		}
+
+		schedule.ImageName = image.GetName()
+	}
+
+	schedule.Text = input.GetText()
+	schedule.PublishAt = publishAt
+	schedule.TimeZone = timeZone
+
+	updated, err := s.repo.UpdateScheduledTweet(ctx, schedule)
+
+	if err != nil {
+		logger.Ctx(ctx, s.log).Errorf("cannot update scheduled tweet: %v", err.Error())
+		if image != nil && image.GetName() != oldImageName {
+			s.deleteImage(ctx, image.GetName())
+		}
+		return nil, err
+	}
+
+	if image != nil && oldImageName != image.GetName() {
+		s.deleteImage(ctx, oldImageName)
+	}
+
+	return updated, nil
+}
+
+func (s *ScheduleService) CancelScheduledTweet(ctx context.Context, input *schedulePb.CancelScheduledTweetRequest) (*domain.ScheduledTweet, error) {
+	ctx, span := s.tracer.Start(ctx, "scheduleService.CancelScheduledTweet")
+	defer span.End()
+
+	principal, err := auth.RequirePrincipal(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := s.repo.CancelScheduledTweet(ctx, principal.UserID, input.GetScheduleId())
+
+	if err != nil {
+		logger.Ctx(ctx, s.log).Errorf("cannot cancel scheduled tweet: %v", err.Error())
+		return nil, err
+	}
+
+	s.deleteImage(ctx, schedule.ImageName)
+
+	return schedule, nil
+}
+
+// Run publishes due tweets every scheduler.interval until ctx is done. Every replica runs it,
+// the repository makes sure each tweet is published by one of them.
+func (s *ScheduleService) Run(ctx context.Context) {
+	if s.cfg.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.PublishDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishDue publishes up to scheduler.batch due tweets and returns how many were published.
+// A tweet that fails is put off by scheduler.retryBackoff and the batch goes on with the next one,
+// it is retried until scheduler.maxAttempts.
+func (s *ScheduleService) PublishDue(ctx context.Context) int {
+	ctx, span := s.tracer.Start(ctx, "scheduleService.PublishDue")
+	defer span.End()
+
+	published := 0
+
+	for attempt := 0; attempt < s.cfg.Batch; attempt++ {
+		if ctx.Err() != nil {
+			return published
+		}
+
+		next, err := s.publishOne(ctx)
+
+		if err == nil && next {
+			published++
+		}
+
+		if !next {
+			return published
+		}
+	}
+
+	return published
+}
+
+// publishOne publishes the next due tweet and reports whether the batch can go on: there was a due
+// tweet and, if it failed, the failure was recorded so it is not picked again right away. Once started
+// it is not cancelled with ctx, a stored tweet has to be announced even when the scheduler is stopping.
+func (s *ScheduleService) publishOne(ctx context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), schedulePublishTimeout)
+	defer cancel()
+
+	schedule, tweet, err := s.repo.PublishDueTweet(ctx)
+
+	if err != nil {
+		return s.publishFailed(ctx, schedule, err), err
+	}
+
+	if schedule == nil {
+		return false, nil
+	}
+
+	s.tweets.tweetCreated(ctx, tweet)
+
+	return true, nil
+}
+
+// publishFailed records the failure of schedule and reports whether it did. Without a schedule
+// the due tweets could not be looked up at all.
+func (s *ScheduleService) publishFailed(ctx context.Context, schedule *domain.ScheduledTweet, cause error) bool {
+	if schedule == nil {
+		s.log.Errorf("cannot publish due tweets: %v", cause.Error())
+		return false
+	}
+
+	s.log.Errorf("cannot publish scheduled tweet %s: %v", schedule.ScheduleID.String(), cause.Error())
+
+	failed, err := s.repo.RecordPublishFailure(ctx, schedule.ScheduleID.String(), cause.Error(), s.cfg.MaxAttempts, s.cfg.RetryBackoff, s.cfg.MaxRetryBackoff)
+
+	if err != nil {
+		s.log.Errorf("cannot record failure of scheduled tweet %s: %v", schedule.ScheduleID.String(), err.Error())
+		return false
+	}
+
+	if failed.Status == domain.ScheduleStatusFailed {
+		s.log.Errorf("gave up publishing scheduled tweet %s after %d attempts", failed.ScheduleID.String(), failed.Attempts)
+	}
+
+	return true
+}
+
+// publishTime reads the local time in timeZone, UTC when empty, and checks that it is ahead but not too far.
+func (s *ScheduleService) publishTime(localTime string, timeZone string) (time.Time, string, error) {
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+
+	location, err := time.LoadLocation(timeZone)
+
+	if err != nil {
+		return time.Time{}, "", domain.InvalidArgument(scheduleResource, "unknown time zone", err)
+	}
+
+	publishAt, err := time.ParseInLocation(LocalTimeLayout, localTime, location)
+
+	if err != nil {
+		return time.Time{}, "", domain.InvalidArgument(scheduleResource, "publish time must look like "+LocalTimeLayout, err)
+	}
+
+	now := time.Now()
+
+	if !publishAt.After(now) {
+		return time.Time{}, "", domain.InvalidArgument(scheduleResource, "publish time must be in the future", nil)
+	}
+
+	if publishAt.After(now.Add(s.cfg.MaxAhead)) {
+		return time.Time{}, "", domain.InvalidArgument(scheduleResource, "publish time is too far in the future", nil)
+	}
+
+	return publishAt, timeZone, nil
+}
+
+// deleteImage removes an image no schedule refers to anymore. A failure leaves it to purge-orphans.
+func (s *ScheduleService) deleteImage(ctx context.Context, name string) {
+	if name == "" {
+		return
+	}
+
+	if err := s.storage.DeleteFile(ctx, name); err != nil {
+		logger.Ctx(ctx, s.log).Errorf("cannot delete image %s of scheduled tweet: %v", name, err.Error())
+	}
+}
+
+func tweetImage(image *schedulePb.Image) *pb.Image {
+	if image == nil {
+		return nil
+	}
+
+	return &pb.Image{Chunk: image.GetChunk(), ContentType: image.GetContentType(), Name: image.GetName()}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Verce11o/yata-tweets/config"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/repository"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+type publishResult struct {
+	schedule *domain.ScheduledTweet
+	err      error
+}
+
+// scheduleStub hands out the due schedules in order, they all fail to publish.
+type scheduleStub struct {
+	repository.ScheduleRepository
+
+	due       []publishResult
+	calls     int
+	recordErr error
+	recorded  []uuid.UUID
+}
+
+func (s *scheduleStub) PublishDueTweet(context.Context) (*domain.ScheduledTweet, *domain.Tweet, error) {
+	s.calls++
+
+	if len(s.due) == 0 {
+		return nil, nil, nil
+	}
+
+	next := s.due[0]
+	s.due = s.due[1:]
+
+	return next.schedule, nil, next.err
+}
+
+func (s *scheduleStub) RecordPublishFailure(_ context.Context, scheduleID string, _ string, _ int, _ time.Duration, _ time.Duration) (*domain.ScheduledTweet, error) {
+	if s.recordErr != nil {
+		return nil, s.recordErr
+	}
+
+	id := uuid.MustParse(scheduleID)
+	s.recorded = append(s.recorded, id)
+
+	return &domain.ScheduledTweet{ScheduleID: id, Status: domain.ScheduleStatusPending, Attempts: 1}, nil
+}
+
+func TestPublishDueGoesOnAfterFailures(t *testing.T) {
+	failure := errors.New("image is gone")
+	poisoned := func() publishResult {
+		return publishResult{schedule: &domain.ScheduledTweet{ScheduleID: uuid.New()}, err: failure}
+	}
+
+	tests := []struct {
+		name      string
+		due       []publishResult
+		recordErr error
+		batch     int
+		// wantCalls counts the lookups of due schedules, wantRecorded the failures recorded
+		wantCalls    int
+		wantRecorded int
+	}{
+		{"failures are skipped", []publishResult{poisoned(), poisoned()}, nil, 10, 3, 2},
+		{"batch is a limit", []publishResult{poisoned(), poisoned(), poisoned()}, nil, 2, 2, 2},
+		{"lookup fails", []publishResult{{err: failure}, poisoned()}, nil, 10, 1, 0},
+		{"failure cannot be recorded", []publishResult{poisoned(), poisoned()}, errors.New("connection refused"), 10, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &scheduleStub{due: tt.due, recordErr: tt.recordErr}
+			service := NewScheduleService(zap.NewNop().Sugar(), noop.NewTracerProvider().Tracer(""), nil, stub, nil, nil, config.Scheduler{Batch: tt.batch, MaxAttempts: 5})
+
+			if published := service.PublishDue(context.Background()); published != 0 {
+				t.Errorf("PublishDue() = %d, want 0", published)
+			}
+
+			if stub.calls != tt.wantCalls || len(stub.recorded) != tt.wantRecorded {
+				t.Errorf("looked up %d times and recorded %d failures, want %d and %d", stub.calls, len(stub.recorded), tt.wantCalls, tt.wantRecorded)
+			}
+		})
+	}
+}
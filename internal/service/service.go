@@ -3,6 +3,9 @@ package service
 import (
 	"context"
 	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
+	feedPb "github.com/Verce11o/yata-tweets/gen/go/feed"
+	moderationPb "github.com/Verce11o/yata-tweets/gen/go/moderation"
+	schedulePb "github.com/Verce11o/yata-tweets/gen/go/schedule"
 	"github.com/Verce11o/yata-tweets/internal/domain"
 )
 
@@ -13,3 +16,19 @@ type Tweet interface {
 	UpdateTweet(ctx context.Context, input *pb.UpdateTweetRequest) (*domain.Tweet, error)
 	DeleteTweet(ctx context.Context, input *pb.DeleteTweetRequest) error
 }
+
+type Moderation interface {
+	HideTweet(ctx context.Context, input *moderationPb.HideTweetRequest) (*domain.Tweet, error)
+	LockReplies(ctx context.Context, input *moderationPb.LockRepliesRequest) (*domain.Tweet, error)
+}
+
+type Schedule interface {
+	CreateScheduledTweet(ctx context.Context, input *schedulePb.CreateScheduledTweetRequest) (*domain.ScheduledTweet, error)
+	ListScheduledTweets(ctx context.Context, input *schedulePb.ListScheduledTweetsRequest) ([]*domain.ScheduledTweet, string, error)
+	UpdateScheduledTweet(ctx context.Context, input *schedulePb.UpdateScheduledTweetRequest) (*domain.ScheduledTweet, error)
+	CancelScheduledTweet(ctx context.Context, input *schedulePb.CancelScheduledTweetRequest) (*domain.ScheduledTweet, error)
+}
+
+type Feed interface {
+	StreamTweets(ctx context.Context, input *feedPb.StreamTweetsRequest, send func(event *domain.FeedEvent) error) error
+}
@@ -4,12 +4,26 @@ import (
 	"context"
 	"encoding/json"
 	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
+	"github.com/Verce11o/yata-tweets/config"
+	moderationPb "github.com/Verce11o/yata-tweets/gen/go/moderation"
 	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/lib/auth"
 	"github.com/Verce11o/yata-tweets/internal/lib/grpc_errors"
+	"github.com/Verce11o/yata-tweets/internal/lib/idempotency"
+	"github.com/Verce11o/yata-tweets/internal/lib/logger"
 	"github.com/Verce11o/yata-tweets/internal/lib/notification"
+	"github.com/Verce11o/yata-tweets/internal/lib/policy"
+	"github.com/Verce11o/yata-tweets/internal/lib/settings"
 	"github.com/Verce11o/yata-tweets/internal/repository"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"strings"
+	"time"
+)
+
+const (
+	announceTimeout = 10 * time.Second
 )
 
 type TweetService struct {
@@ -18,84 +32,101 @@ type TweetService struct {
 	tweetPublisher notification.TweetPublisher
 	repo           repository.PostgresRepository
 	redis          repository.RedisRepository
-	minio          repository.MinioRepository
+	pages          repository.TweetPagesRepository
+	storage        repository.StorageRepository
+	idempotency    repository.IdempotencyRepository
+	idempotencyCfg config.Idempotency
+	feed           repository.FeedRepository
+	settings       *settings.Store
+	loads          singleflight.Group
 }
 
-func NewTweetService(log *zap.SugaredLogger, tracer trace.Tracer, tweetPublisher notification.TweetPublisher, repo repository.PostgresRepository, redis repository.RedisRepository, minio repository.MinioRepository) *TweetService {
-	return &TweetService{log: log, tracer: tracer, tweetPublisher: tweetPublisher, repo: repo, redis: redis, minio: minio}
+func NewTweetService(log *zap.SugaredLogger, tracer trace.Tracer, tweetPublisher notification.TweetPublisher, repo repository.PostgresRepository, redis repository.RedisRepository, pages repository.TweetPagesRepository, storage repository.StorageRepository, idempotency repository.IdempotencyRepository, idempotencyCfg config.Idempotency, feed repository.FeedRepository, settings *settings.Store) *TweetService {
+	return &TweetService{log: log, tracer: tracer, tweetPublisher: tweetPublisher, repo: repo, redis: redis, pages: pages, storage: storage, idempotency: idempotency, idempotencyCfg: idempotencyCfg, feed: feed, settings: settings}
 }
 
 func (t *TweetService) CreateTweet(ctx context.Context, input *pb.CreateTweetRequest) (string, error) {
 	ctx, span := t.tracer.Start(ctx, "tweetService.CreateTweet")
 	defer span.End()
 
+	principal, err := auth.RequirePrincipal(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	if key := idempotency.KeyFromContext(ctx); key != "" {
+		return t.createTweetIdempotent(ctx, principal, key, input)
+	}
+
+	tweet, err := t.createTweet(ctx, principal, input)
+
+	if err != nil {
+		return "", err
+	}
+
+	t.tweetCreated(ctx, tweet)
+
+	return tweet.TweetID.String(), nil
+}
+
+// createTweet stores the tweet. It is not announced yet, see tweetCreated.
+func (t *TweetService) createTweet(ctx context.Context, principal *auth.Principal, input *pb.CreateTweetRequest) (*domain.Tweet, error) {
 	image := input.GetImage()
 
 	var err error
 
 	if image != nil {
 
-		err = t.minio.AddTweetImage(ctx, image, image.GetName())
+		err = t.storage.AddTweetImage(ctx, image, image.GetName())
 
 		if err != nil {
-			t.log.Errorf("cannot add image to tweet in minio: %v", err.Error())
+			logger.Ctx(ctx, t.log).Errorf("cannot add image to tweet in storage: %v", err.Error())
 		}
 
 	}
 
-	tweetID, err := t.repo.CreateTweet(ctx, input, image.GetName())
+	return t.repo.CreateTweet(ctx, principal.UserID, input, image.GetName())
+}
 
-	if err != nil {
-		return "", err
-	}
+// tweetCreated announces a stored tweet: cached pages are invalidated, feed subscribers and the notification service are told.
+// The tweet exists whatever happens here, so the announcement outlives the request and its failures are only logged,
+// a lost notification can be sent again with replay-events.
+func (t *TweetService) tweetCreated(ctx context.Context, tweet *domain.Tweet) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), announceTimeout)
+	defer cancel()
+
+	t.invalidatePages(ctx, tweet)
+	t.publishFeedEvent(ctx, domain.FeedEventCreated, tweet)
 
 	SendNewTweetNotification := domain.SendNewTweetNotification{
-		SenderID: input.GetUserId(),
+		SenderID: tweet.UserID.String(),
 		Type:     domain.NewTweetNotificationType,
 	}
 
 	messageBytes, err := json.Marshal(SendNewTweetNotification)
 
 	if err != nil {
-		return "", err
+		logger.Ctx(ctx, t.log).Errorf("cannot marshal notification of tweet %s: %v", tweet.TweetID.String(), err.Error())
+		return
 	}
 
-	err = t.tweetPublisher.Publish(ctx, messageBytes)
-
-	if err != nil {
-		return "", err
+	if err := t.tweetPublisher.Publish(ctx, messageBytes); err != nil {
+		logger.Ctx(ctx, t.log).Errorf("cannot publish notification of tweet %s: %v", tweet.TweetID.String(), err.Error())
 	}
-
-	return tweetID, nil
 }
 
 func (t *TweetService) GetTweet(ctx context.Context, tweetID string) (domain.Tweet, error) {
 	ctx, span := t.tracer.Start(ctx, "tweetService.GetTweet")
 	defer span.End()
 
-	cachedTweet, err := t.redis.GetTweetByIDCtx(ctx, tweetID)
-
-	if err != nil {
-		t.log.Infof("cannot get tweet by id in redis: %v", err.Error())
-	}
-
-	if cachedTweet != nil {
-		t.log.Info("returned cache")
-		return *cachedTweet, nil
-	}
+	tweet, err := t.cachedTweet(ctx, tweetID)
 
-	tweet, err := t.repo.GetTweet(ctx, tweetID)
-	t.log.Debugf("tweet: %v", tweet)
 	if err != nil {
-		t.log.Errorf("cannot get tweet by id in postgres: %v", err.Error())
 		return domain.Tweet{}, err
 	}
 
-	if err := t.redis.SetByIDCtx(ctx, tweetID, tweet); err != nil {
-		t.log.Errorf("cannot set tweet by id in redis: %v", err.Error())
-	}
-
-	return *tweet, nil
+	return t.viewTweet(ctx, tweet)
 
 }
 
@@ -103,10 +134,11 @@ func (t *TweetService) GetAllTweets(ctx context.Context, input *pb.GetAllTweetsR
 	ctx, span := t.tracer.Start(ctx, "tweetService.GetAllTweets")
 	defer span.End()
 
-	tweets, nextCursor, err := t.repo.GetAllTweets(ctx, input.GetCursor())
+	tweets, nextCursor, err := t.cachedPage(ctx, input.GetCursor())
 
 	if err != nil {
-		t.log.Errorf("cannot get all tweets by cursor: %v err: %v", input.GetCursor(), err)
+		logger.Ctx(ctx, t.log).Errorf("cannot get all tweets by cursor: %v err: %v", input.GetCursor(), err)
+		return nil, "", err
 	}
 
 	return tweets, nextCursor, nil
@@ -117,16 +149,26 @@ func (t *TweetService) UpdateTweet(ctx context.Context, input *pb.UpdateTweetReq
 	ctx, span := t.tracer.Start(ctx, "tweetService.UpdateTweet")
 	defer span.End()
 
+	principal, err := auth.RequirePrincipal(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
 	tweet, err := t.repo.GetTweet(ctx, input.GetTweetId())
 
 	if err != nil {
-		t.log.Errorf("cannot get tweet by id in postgres: %v", err.Error())
+		logger.Ctx(ctx, t.log).Errorf("cannot get tweet by id in postgres: %v", err.Error())
 		return nil, err
 	}
 
-	if tweet.UserID.String() != input.GetUserId() {
-		t.log.Errorf("cannot update tweet by id: permission denied")
-		return nil, grpc_errors.ErrPermissionDenied
+	if _, err := policy.Authorize(principal, policy.ActionUpdateTweet, tweet); err != nil {
+		logger.Ctx(ctx, t.log).Errorf("cannot update tweet by id: %v", err.Error())
+		return nil, err
+	}
+
+	if window := t.settings.Get().EditWindow; window > 0 && time.Since(tweet.CreatedAt) > window {
+		return nil, grpc_errors.ErrEditWindowClosed
 	}
 
 	image := input.GetImage()
@@ -134,10 +176,10 @@ func (t *TweetService) UpdateTweet(ctx context.Context, input *pb.UpdateTweetReq
 
 	if image != nil { // if input image is not nil, we need to update it
 
-		err = t.minio.UpdateTweetImage(ctx, tweet.ImageName, image.GetName(), image)
+		err = t.storage.UpdateTweetImage(ctx, tweet.ImageName, image.GetName(), image)
 
 		if err != nil {
-			t.log.Errorf("cannot update comment image: %v", err.Error())
+			logger.Ctx(ctx, t.log).Errorf("cannot update comment image: %v", err.Error())
 			return nil, err
 		}
 
@@ -148,14 +190,17 @@ func (t *TweetService) UpdateTweet(ctx context.Context, input *pb.UpdateTweetReq
 	newTweet, err := t.repo.UpdateTweet(ctx, input, newImageName)
 
 	if err != nil {
-		t.log.Errorf("cannot update tweet: %v", err.Error())
+		logger.Ctx(ctx, t.log).Errorf("cannot update tweet: %v", err.Error())
 		return nil, err
 	}
 
+	// pages only hold tweet IDs, evicting the tweet is enough for them to show the edit
 	if err := t.redis.DeleteTweetByIDCtx(ctx, tweet.TweetID.String()); err != nil {
-		t.log.Errorf("cannot remove tweet by id in redis: %v", err.Error())
+		logger.Ctx(ctx, t.log).Errorf("cannot remove tweet by id in redis: %v", err.Error())
 	}
 
+	t.publishFeedEvent(ctx, domain.FeedEventUpdated, newTweet)
+
 	return newTweet, nil
 }
 
@@ -163,29 +208,173 @@ func (t *TweetService) DeleteTweet(ctx context.Context, input *pb.DeleteTweetReq
 	ctx, span := t.tracer.Start(ctx, "tweetService.DeleteTweet")
 	defer span.End()
 
+	principal, err := auth.RequirePrincipal(ctx)
+
+	if err != nil {
+		return err
+	}
+
 	tweet, err := t.repo.GetTweet(ctx, input.GetTweetId())
 
 	if err != nil {
-		t.log.Errorf("cannot get tweet by id in postgres: %v", err.Error())
+		logger.Ctx(ctx, t.log).Errorf("cannot get tweet by id in postgres: %v", err.Error())
 		return err
 	}
 
-	if tweet.UserID.String() != input.GetUserId() {
-		t.log.Errorf("cannot delete tweet by id: permission denied")
-		return grpc_errors.ErrPermissionDenied
+	decision, err := policy.Authorize(principal, policy.ActionDeleteTweet, tweet)
+
+	if err != nil {
+		logger.Ctx(ctx, t.log).Errorf("cannot delete tweet by id: %v", err.Error())
+		return err
 	}
 
-	err = t.repo.DeleteTweet(ctx, tweet.TweetID.String())
+	err = t.repo.DeleteTweet(ctx, tweet.TweetID.String(), auditRecord(decision, principal, policy.ActionDeleteTweet, tweet, ""))
 
 	if err != nil {
-		t.log.Errorf("cannot delete tweet by id: %v", err.Error())
+		logger.Ctx(ctx, t.log).Errorf("cannot delete tweet by id: %v", err.Error())
 		return err
 	}
 
 	if err := t.redis.DeleteTweetByIDCtx(ctx, tweet.TweetID.String()); err != nil {
-		t.log.Errorf("cannot delete tweet by id in redis: %v", err.Error())
+		logger.Ctx(ctx, t.log).Errorf("cannot delete tweet by id in redis: %v", err.Error())
 	}
 
+	t.invalidatePages(ctx, tweet)
+	t.publishFeedEvent(ctx, domain.FeedEventDeleted, tweet)
+
 	return nil
 
 }
+
+func (t *TweetService) HideTweet(ctx context.Context, input *moderationPb.HideTweetRequest) (*domain.Tweet, error) {
+	ctx, span := t.tracer.Start(ctx, "tweetService.HideTweet")
+	defer span.End()
+
+	action := policy.ActionHideTweet
+	if !input.GetHidden() {
+		action = policy.ActionUnhideTweet
+	}
+
+	audit, err := t.moderate(ctx, action, input.GetTweetId(), input.GetReason())
+
+	if err != nil {
+		return nil, err
+	}
+
+	tweet, err := t.repo.SetTweetHidden(ctx, input.GetTweetId(), input.GetHidden(), audit)
+
+	if err != nil {
+		logger.Ctx(ctx, t.log).Errorf("cannot set tweet hidden: %v", err.Error())
+		return nil, err
+	}
+
+	if err := t.redis.DeleteTweetByIDCtx(ctx, input.GetTweetId()); err != nil {
+		logger.Ctx(ctx, t.log).Errorf("cannot delete tweet by id in redis: %v", err.Error())
+	}
+
+	t.invalidatePages(ctx, tweet)
+
+	// for feed subscribers hiding a tweet is the same as deleting it
+	if tweet.Hidden {
+		t.publishFeedEvent(ctx, domain.FeedEventDeleted, &domain.Tweet{TweetID: tweet.TweetID, UserID: tweet.UserID, Text: tweet.Text})
+	} else {
+		t.publishFeedEvent(ctx, domain.FeedEventCreated, tweet)
+	}
+
+	return tweet, nil
+}
+
+func (t *TweetService) LockReplies(ctx context.Context, input *moderationPb.LockRepliesRequest) (*domain.Tweet, error) {
+	ctx, span := t.tracer.Start(ctx, "tweetService.LockReplies")
+	defer span.End()
+
+	action := policy.ActionLockReplies
+	if !input.GetLocked() {
+		action = policy.ActionUnlockReplies
+	}
+
+	audit, err := t.moderate(ctx, action, input.GetTweetId(), input.GetReason())
+
+	if err != nil {
+		return nil, err
+	}
+
+	tweet, err := t.repo.SetRepliesLocked(ctx, input.GetTweetId(), input.GetLocked(), audit)
+
+	if err != nil {
+		logger.Ctx(ctx, t.log).Errorf("cannot set tweet replies locked: %v", err.Error())
+		return nil, err
+	}
+
+	if err := t.redis.DeleteTweetByIDCtx(ctx, input.GetTweetId()); err != nil {
+		logger.Ctx(ctx, t.log).Errorf("cannot delete tweet by id in redis: %v", err.Error())
+	}
+
+	return tweet, nil
+}
+
+// moderate authorizes a moderation action on the tweet. It returns the audit record to store
+// with the change, nil when the action is not privileged.
+func (t *TweetService) moderate(ctx context.Context, action policy.Action, tweetID string, reason string) (*domain.AuditRecord, error) {
+	principal, err := auth.RequirePrincipal(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tweet, err := t.repo.GetTweet(ctx, tweetID)
+
+	if err != nil {
+		logger.Ctx(ctx, t.log).Errorf("cannot get tweet by id in postgres: %v", err.Error())
+		return nil, err
+	}
+
+	decision, err := policy.Authorize(principal, action, tweet)
+
+	if err != nil {
+		logger.Ctx(ctx, t.log).Errorf("cannot perform %s on tweet %s: %v", action, tweetID, err.Error())
+		return nil, err
+	}
+
+	return auditRecord(decision, principal, action, tweet, reason), nil
+}
+
+// auditRecord describes a privileged action for the audit log. The repository stores it in the
+// transaction of the action, so the action fails when it cannot be audited.
+func auditRecord(decision policy.Decision, principal *auth.Principal, action policy.Action, tweet *domain.Tweet, reason string) *domain.AuditRecord {
+	if !decision.Privileged {
+		return nil
+	}
+
+	return &domain.AuditRecord{
+		ActorID:    principal.UserID,
+		ActorRoles: strings.Join(principal.Roles, ","),
+		Action:     string(action),
+		TweetID:    tweet.TweetID.String(),
+		OwnerID:    tweet.UserID.String(),
+		Reason:     reason,
+	}
+}
+
+func (t *TweetService) viewTweet(ctx context.Context, tweet *domain.Tweet) (domain.Tweet, error) {
+	principal, _ := auth.PrincipalFromContext(ctx)
+
+	if _, err := policy.Authorize(principal, policy.ActionViewTweet, tweet); err != nil {
+		return domain.Tweet{}, err
+	}
+
+	return *tweet, nil
+}
+
+// publishFeedEvent notifies live feed subscribers. Changes of hidden tweets are not published.
+func (t *TweetService) publishFeedEvent(ctx context.Context, eventType domain.FeedEventType, tweet *domain.Tweet) {
+	if tweet.Hidden {
+		return
+	}
+
+	event := &domain.FeedEvent{Type: eventType, Tweet: *tweet, OccurredAt: time.Now()}
+
+	if _, err := t.feed.PublishFeedEvent(ctx, event); err != nil {
+		logger.Ctx(ctx, t.log).Errorf("cannot publish %s feed event of tweet %s: %v", eventType, tweet.TweetID.String(), err.Error())
+	}
+}
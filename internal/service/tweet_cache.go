@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/lib/grpc_errors"
+	"github.com/Verce11o/yata-tweets/internal/lib/logger"
+	"github.com/Verce11o/yata-tweets/internal/repository"
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	tweetResource    = "tweet"
+	tweetLoadTimeout = 5 * time.Second
+)
+
+// cachedTweet is the cache-aside lookup of GetTweet. Concurrent loads of the same tweet are coalesced,
+// missing tweets are cached too and entries close to expiry are refreshed early, so that neither a hot
+// tweet expiring nor lookups of unknown IDs reach Postgres once per request.
+func (t *TweetService) cachedTweet(ctx context.Context, tweetID string) (*domain.Tweet, error) {
+	ctx, span := t.tracer.Start(ctx, "tweetService.cachedTweet")
+	defer span.End()
+
+	entry, err := t.redis.GetTweetByIDCtx(ctx, tweetID)
+
+	switch {
+	case err == nil && !refreshEarly(entry, time.Now(), t.settings.Get().TweetCacheEarlyRefresh):
+		return cachedResult(entry, tweetID)
+	case err != nil && !errors.Is(err, grpc_errors.ErrNotFound):
+		logger.Ctx(ctx, t.log).Errorf("cannot get tweet by id in redis: %v", err.Error())
+	}
+
+	tweet, err := t.loadTweet(ctx, tweetID)
+
+	// an early refresh happens while the entry is still valid, so a failed one does not fail the request
+	if err != nil && entry != nil && !domain.IsKind(err, domain.KindNotFound) {
+		logger.Ctx(ctx, t.log).Errorf("cannot refresh cached tweet %s: %v", tweetID, err.Error())
+		return cachedResult(entry, tweetID)
+	}
+
+	return tweet, err
+}
+
+// loadTweet reads the tweet from Postgres and caches the result. Only one load per tweet runs at a time,
+// concurrent callers wait for it and share its result.
+func (t *TweetService) loadTweet(ctx context.Context, tweetID string) (*domain.Tweet, error) {
+	res := t.loads.DoChan(tweetID, func() (interface{}, error) {
+		// the load is shared, so it must not be cancelled with the request that happened to start it
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tweetLoadTimeout)
+		defer cancel()
+
+		// a stale row from a replica would be served to everyone until the entry expires
+		ctx = repository.ReadPrimary(ctx)
+
+		start := time.Now()
+		tweet, err := t.repo.GetTweet(ctx, tweetID)
+		delta := time.Since(start)
+
+		if domain.IsKind(err, domain.KindNotFound) {
+			if err := t.redis.SetNotFoundCtx(ctx, tweetID, delta); err != nil {
+				logger.Ctx(ctx, t.log).Errorf("cannot set missing tweet by id in redis: %v", err.Error())
+			}
+			return nil, err
+		}
+
+		if err != nil {
+			logger.Ctx(ctx, t.log).Errorf("cannot get tweet by id in postgres: %v", err.Error())
+			return nil, err
+		}
+
+		if err := t.redis.SetByIDCtx(ctx, tweetID, tweet, delta); err != nil {
+			logger.Ctx(ctx, t.log).Errorf("cannot set tweet by id in redis: %v", err.Error())
+		}
+
+		return tweet, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-res:
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		return r.Val.(*domain.Tweet), nil
+	}
+}
+
+// refreshEarly implements XFetch (Vattani et al., "Optimal Probabilistic Cache Stampede Prevention"):
+// the closer an entry is to expiry and the longer it took to load, the likelier a request reloads it.
+// Requests rarely agree to reload at the same time, and the ones that do are coalesced by loadTweet.
+func refreshEarly(entry *domain.CachedTweet, now time.Time, beta float64) bool {
+	if beta <= 0 {
+		return false
+	}
+
+	// 1 - rand.Float64() is in (0, 1], the logarithm is never infinite
+	gap := -float64(entry.Delta) * beta * math.Log(1-rand.Float64())
+
+	return now.Add(time.Duration(gap)).After(entry.ExpiresAt)
+}
+
+func cachedResult(entry *domain.CachedTweet, tweetID string) (*domain.Tweet, error) {
+	if entry.Tweet == nil {
+		return nil, domain.NotFound(tweetResource, tweetID, nil)
+	}
+	return entry.Tweet, nil
+}
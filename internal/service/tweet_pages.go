@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	pb "github.com/Verce11o/yata-protos/gen/go/tweets"
+	"github.com/Verce11o/yata-tweets/internal/domain"
+	"github.com/Verce11o/yata-tweets/internal/lib/logger"
+	"github.com/Verce11o/yata-tweets/internal/lib/pagination"
+	"github.com/Verce11o/yata-tweets/internal/repository"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"time"
+)
+
+const (
+	// visibleTweets is the filter of GetAllTweets, hidden tweets are never listed.
+	visibleTweets = "visible"
+)
+
+// cachedPage serves GetAllTweets from cached pages. A page only holds tweet IDs, so edits are picked up
+// through the tweet cache and only creating, deleting or hiding a tweet invalidates pages, see invalidatePages.
+func (t *TweetService) cachedPage(ctx context.Context, cursor string) ([]*pb.Tweet, string, error) {
+	ctx, span := t.tracer.Start(ctx, "tweetService.cachedPage")
+	defer span.End()
+
+	current := t.settings.Get()
+
+	if current.PageCacheTTL <= 0 {
+		return t.repo.GetAllTweets(ctx, cursor)
+	}
+
+	key := domain.TweetPageKey{Filter: visibleTweets, Size: current.PageSize, Cursor: cursor}
+
+	if cursor != "" {
+		after, _, err := pagination.DecodeCursor(cursor)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		key.After = after
+	}
+
+	page, epoch, pageErr := t.pages.GetTweetPage(ctx, key)
+
+	if pageErr != nil {
+		logger.Ctx(ctx, t.log).Errorf("cannot get tweet page in redis: %v", pageErr.Error())
+	}
+
+	if page != nil {
+		if tweets, ok := t.hydratePage(ctx, page); ok {
+			return tweets, page.NextCursor, nil
+		}
+	}
+
+	// a replica lagging behind an invalidation would fill the page without a tweet created just before
+	tweets, nextCursor, err := t.repo.GetAllTweets(repository.ReadPrimary(ctx), cursor)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if pageErr == nil {
+		if err := t.pages.SetTweetPage(ctx, key, newTweetPage(tweets, nextCursor, key.Size), epoch, current.PageCacheTTL); err != nil {
+			logger.Ctx(ctx, t.log).Errorf("cannot set tweet page in redis: %v", err.Error())
+		}
+	}
+
+	return tweets, nextCursor, nil
+}
+
+// hydratePage reads the tweets of page from the tweet cache in one round trip and the missing ones from Postgres.
+// It reports false when a tweet was deleted or hidden in the meantime, the page is loaded again then.
+func (t *TweetService) hydratePage(ctx context.Context, page *domain.TweetPage) ([]*pb.Tweet, bool) {
+	entries, err := t.redis.GetTweetsByIDs(ctx, page.TweetIDs)
+
+	if err != nil {
+		logger.Ctx(ctx, t.log).Errorf("cannot get tweets by ids in redis: %v", err.Error())
+		entries = make([]*domain.CachedTweet, len(page.TweetIDs))
+	}
+
+	found := make(map[string]*domain.Tweet, len(page.TweetIDs))
+	var missing []string
+
+	for i, entry := range entries {
+		switch {
+		case entry == nil:
+			missing = append(missing, page.TweetIDs[i])
+		case entry.Tweet == nil:
+			return nil, false
+		default:
+			found[page.TweetIDs[i]] = entry.Tweet
+		}
+	}
+
+	if len(missing) > 0 {
+		start := time.Now()
+		loaded, err := t.repo.GetTweetsByIDs(repository.ReadPrimary(ctx), missing)
+		delta := time.Since(start)
+
+		if err != nil {
+			logger.Ctx(ctx, t.log).Errorf("cannot get tweets by ids in postgres: %v", err.Error())
+			return nil, false
+		}
+
+		for _, tweet := range loaded {
+			found[tweet.TweetID.String()] = tweet
+
+			if err := t.redis.SetByIDCtx(ctx, tweet.TweetID.String(), tweet, delta); err != nil {
+				logger.Ctx(ctx, t.log).Errorf("cannot set tweet by id in redis: %v", err.Error())
+			}
+		}
+	}
+
+	tweets := make([]*pb.Tweet, 0, len(page.TweetIDs))
+
+	for _, tweetID := range page.TweetIDs {
+		tweet, ok := found[tweetID]
+
+		if !ok || tweet.Hidden {
+			return nil, false
+		}
+
+		tweets = append(tweets, &pb.Tweet{
+			UserId:    tweet.UserID.String(),
+			TweetId:   tweetID,
+			Text:      tweet.Text,
+			CreatedAt: timestamppb.New(tweet.CreatedAt),
+		})
+	}
+
+	return tweets, true
+}
+
+// invalidatePages drops the cached pages a tweet was added to or removed from. Pages cached under
+// a previous page size are not touched, they expire after settings.pageCacheTTL.
+func (t *TweetService) invalidatePages(ctx context.Context, tweet *domain.Tweet) {
+	if err := t.pages.InvalidateTweetPages(ctx, visibleTweets, t.settings.Get().PageSize, tweet.CreatedAt); err != nil {
+		logger.Ctx(ctx, t.log).Errorf("cannot invalidate tweet pages of tweet %s: %v", tweet.TweetID.String(), err.Error())
+	}
+}
+
+func newTweetPage(tweets []*pb.Tweet, nextCursor string, size int) *domain.TweetPage {
+	page := &domain.TweetPage{TweetIDs: make([]string, len(tweets)), NextCursor: nextCursor, Full: len(tweets) == size}
+
+	for i, tweet := range tweets {
+		page.TweetIDs[i] = tweet.GetTweetId()
+	}
+
+	if len(tweets) > 0 {
+		page.Last = tweets[len(tweets)-1].GetCreatedAt().AsTime()
+	}
+
+	return page
+}
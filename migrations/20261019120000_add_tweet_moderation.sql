@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE tweets
+    ADD COLUMN hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN replies_locked BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS "audit_log" (
+    audit_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    actor_id UUID NOT NULL,
+    actor_roles VARCHAR(255) NOT NULL,
+    action VARCHAR(64) NOT NULL,
+    tweet_id UUID NOT NULL,
+    owner_id UUID NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_audit_log_tweet_id ON audit_log (tweet_id);
+CREATE INDEX idx_audit_log_actor_id_created_at ON audit_log (actor_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "audit_log";
+ALTER TABLE tweets
+    DROP COLUMN IF EXISTS hidden,
+    DROP COLUMN IF EXISTS replies_locked;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- tweet length is validated by the service in weighted characters, URLs count as 23 of them
+ALTER TABLE tweets ALTER COLUMN text TYPE TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE tweets ALTER COLUMN text TYPE VARCHAR(255);
+-- +goose StatementEnd
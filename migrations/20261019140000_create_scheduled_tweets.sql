@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS "scheduled_tweets" (
+    schedule_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    text TEXT NOT NULL,
+    image_name VARCHAR(255) NOT NULL DEFAULT '',
+    publish_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    -- IANA name the publish time was given in, kept to show and edit it in the author's time zone
+    time_zone VARCHAR(64) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    tweet_id UUID NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    published_at TIMESTAMP WITH TIME ZONE NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+CREATE INDEX idx_scheduled_tweets_due ON scheduled_tweets (publish_at) WHERE status = 'pending';
+CREATE INDEX idx_scheduled_tweets_user_id_status ON scheduled_tweets (user_id, status, publish_at, schedule_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS "scheduled_tweets";
+-- +goose StatementEnd
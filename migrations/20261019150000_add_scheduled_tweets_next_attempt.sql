@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- a schedule that failed to publish is not retried before next_attempt_at, so it does not hold up the others
+ALTER TABLE scheduled_tweets ADD COLUMN next_attempt_at TIMESTAMP WITH TIME ZONE NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE scheduled_tweets DROP COLUMN IF EXISTS next_attempt_at;
+-- +goose StatementEnd
@@ -0,0 +1,7 @@
+// Package migrations embeds the goose migrations, so that the binary can apply them itself.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS